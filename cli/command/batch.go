@@ -0,0 +1,174 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/docker/cli/opts"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/net/context"
+)
+
+// BatchOptions holds the options of the commands acting on the objects
+// selected with --filter or --all, instead of the objects passed as arguments
+type BatchOptions struct {
+	Filter opts.FilterOpt
+	All    bool
+	Yes    bool
+}
+
+// NewBatchOptions returns batch options selecting no objects
+func NewBatchOptions() BatchOptions {
+	return BatchOptions{Filter: opts.NewFilterOpt()}
+}
+
+// AddBatchFlags adds the --filter, --all and --yes flags of the batch
+// options. Objects is the plural name of the objects the command acts on,
+// like "services".
+func AddBatchFlags(flags *pflag.FlagSet, options *BatchOptions, objects string) {
+	flags.Var(&options.Filter, "filter", fmt.Sprintf("Act on the %s matching the filter", objects))
+	flags.BoolVar(&options.All, "all", false, fmt.Sprintf("Act on all %s", objects))
+	flags.BoolVarP(&options.Yes, "yes", "y", false, fmt.Sprintf("Do not prompt for confirmation when acting on %s selected with --filter or --all", objects))
+}
+
+// Enabled returns whether objects are selected with --filter or --all
+func (o *BatchOptions) Enabled() bool {
+	return o.All || o.Filter.Value().Len() > 0
+}
+
+// BatchArgs validates the arguments with single, or with batch if objects
+// are selected with --filter or --all
+func BatchArgs(options *BatchOptions, single, batch cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if !options.Enabled() {
+			return single(cmd, args)
+		}
+		if options.All && options.Filter.Value().Len() > 0 {
+			return errors.New("--filter and --all can not be used together")
+		}
+		return batch(cmd, args)
+	}
+}
+
+// batchParallelism is the maximum number of objects a batch operation
+// changes at the same time
+const batchParallelism = 10
+
+// RunBatch calls op for each of the ids, with at most batchParallelism calls
+// running at the same time. Each call is given its own Cli, whose output is
+// printed once the call returns, in the order of the ids, so that the output
+// of concurrent calls is not interleaved. The errors of the calls are
+// returned as a single error, one line per failed id, in the order of the ids.
+func RunBatch(ctx context.Context, dockerCli Cli, ids []string, op func(ctx context.Context, dockerCli Cli, id string) error) error {
+	output := newBatchOutput(dockerCli, len(ids))
+	return runBatch(ctx, ids, batchParallelism, func(ctx context.Context, i int) error {
+		defer output.done(i)
+		return op(ctx, output.clis[i], ids[i])
+	})
+}
+
+// RunBatchWithParallelism calls op for each of the ids like RunBatch, with at
+// most parallelism calls running at the same time. The calls share the
+// output of the caller.
+func RunBatchWithParallelism(ctx context.Context, ids []string, parallelism int, op func(ctx context.Context, id string) error) error {
+	return runBatch(ctx, ids, parallelism, func(ctx context.Context, i int) error {
+		return op(ctx, ids[i])
+	})
+}
+
+func runBatch(ctx context.Context, ids []string, parallelism int, op func(ctx context.Context, i int) error) error {
+	errs := make([]error, len(ids))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = op(ctx, i)
+			<-sem
+		}(i)
+	}
+	wg.Wait()
+
+	var msgs []string
+	for i, err := range errs {
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", ids[i], err))
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+// batchCli is a Cli whose output is buffered
+type batchCli struct {
+	Cli
+	out    *OutStream
+	outBuf bytes.Buffer
+	errBuf bytes.Buffer
+}
+
+func (c *batchCli) Out() *OutStream {
+	return c.out
+}
+
+func (c *batchCli) Err() io.Writer {
+	return &c.errBuf
+}
+
+// batchOutput prints the buffered output of the calls of a batch, in the
+// order of the calls, as soon as the calls before them are done
+type batchOutput struct {
+	dockerCli Cli
+	clis      []*batchCli
+
+	mu       sync.Mutex
+	finished []bool
+	next     int
+}
+
+func newBatchOutput(dockerCli Cli, n int) *batchOutput {
+	output := &batchOutput{
+		dockerCli: dockerCli,
+		clis:      make([]*batchCli, n),
+		finished:  make([]bool, n),
+	}
+	for i := range output.clis {
+		c := &batchCli{Cli: dockerCli}
+		c.out = NewOutStream(&c.outBuf)
+		output.clis[i] = c
+	}
+	return output
+}
+
+func (o *batchOutput) done(i int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.finished[i] = true
+	for ; o.next < len(o.clis) && o.finished[o.next]; o.next++ {
+		c := o.clis[o.next]
+		io.Copy(o.dockerCli.Out(), &c.outBuf)
+		io.Copy(o.dockerCli.Err(), &c.errBuf)
+	}
+}
+
+// ConfirmBatch prints the objects a batch operation is about to change, and
+// requests confirmation from the user, unless yes is set.
+func ConfirmBatch(dockerCli Cli, action string, names []string, yes bool) bool {
+	if yes {
+		return true
+	}
+	fmt.Fprintf(dockerCli.Out(), "The following will be %s:\n", action)
+	for _, name := range names {
+		fmt.Fprintf(dockerCli.Out(), "  %s\n", name)
+	}
+	return PromptForConfirmation(dockerCli.In(), dockerCli.Out(), "")
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestRunBatchPrintsOutputInOrder(t *testing.T) {
+	out := new(bytes.Buffer)
+	errOut := new(bytes.Buffer)
+	dockerCli := NewDockerCli(ioutil.NopCloser(strings.NewReader("")), out, errOut)
+
+	ids := []string{"a", "b", "c"}
+	delays := map[string]time.Duration{"a": 20 * time.Millisecond, "b": 10 * time.Millisecond}
+	err := RunBatch(context.Background(), dockerCli, ids, func(ctx context.Context, dockerCli Cli, id string) error {
+		fmt.Fprintf(dockerCli.Out(), "%s: start\n", id)
+		time.Sleep(delays[id])
+		fmt.Fprintf(dockerCli.Out(), "%s: done\n", id)
+		if id == "b" {
+			fmt.Fprintln(dockerCli.Err(), "b: warning")
+			return errors.New("failed")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "b: failed")
+	assert.Equal(t, "a: start\na: done\nb: start\nb: done\nc: start\nc: done\n", out.String())
+	assert.Equal(t, "b: warning\n", errOut.String())
+}
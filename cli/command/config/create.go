@@ -7,6 +7,7 @@ import (
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/templating"
 	"github.com/docker/cli/opts"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/pkg/system"
@@ -16,9 +17,11 @@ import (
 )
 
 type createOptions struct {
-	name   string
-	file   string
-	labels opts.ListOpts
+	name           string
+	templateDriver string
+	secretProvider string
+	file           string
+	labels         opts.ListOpts
 }
 
 func newConfigCreateCommand(dockerCli command.Cli) *cobra.Command {
@@ -38,6 +41,8 @@ func newConfigCreateCommand(dockerCli command.Cli) *cobra.Command {
 	}
 	flags := cmd.Flags()
 	flags.VarP(&createOpts.labels, "label", "l", "Config labels")
+	flags.StringVar(&createOpts.templateDriver, "template-driver", "", `Render the config content as a template ("`+templating.DriverGolang+`")`)
+	flags.StringVar(&createOpts.secretProvider, "secret-provider", "", "Executable providing the values of secrets used in the template")
 
 	return cmd
 }
@@ -46,6 +51,13 @@ func runConfigCreate(dockerCli command.Cli, options createOptions) error {
 	client := dockerCli.Client()
 	ctx := context.Background()
 
+	if err := templating.ValidateDriver(options.templateDriver); err != nil {
+		return err
+	}
+	if options.secretProvider != "" && options.templateDriver == "" {
+		return errors.Errorf("--secret-provider can only be used with --template-driver")
+	}
+
 	var in io.Reader = dockerCli.In()
 	if options.file != "-" {
 		file, err := system.OpenSequential(options.file)
@@ -60,6 +72,16 @@ func runConfigCreate(dockerCli command.Cli, options createOptions) error {
 	if err != nil {
 		return errors.Errorf("Error reading content from %q: %v", options.file, err)
 	}
+	if options.templateDriver != "" {
+		configData, err = templating.Render(configData, templating.Options{
+			Driver:         options.templateDriver,
+			BaseDir:        templating.BaseDir(options.file),
+			SecretProvider: options.secretProvider,
+		})
+		if err != nil {
+			return err
+		}
+	}
 
 	spec := swarm.ConfigSpec{
 		Annotations: swarm.Annotations{
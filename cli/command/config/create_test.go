@@ -2,6 +2,7 @@ package config
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -31,6 +32,14 @@ func TestConfigCreateErrors(t *testing.T) {
 		{args: []string{"too", "many", "arguments"},
 			expectedError: "requires exactly 2 arguments",
 		},
+		{
+			args:          []string{"name", filepath.Join("testdata", configDataFile), "--template-driver", "jinja"},
+			expectedError: `invalid template driver "jinja"`,
+		},
+		{
+			args:          []string{"name", filepath.Join("testdata", configDataFile), "--secret-provider", "provider"},
+			expectedError: "--secret-provider can only be used with --template-driver",
+		},
 		{
 			args: []string{"name", filepath.Join("testdata", configDataFile)},
 			configCreateFunc: func(configSpec swarm.ConfigSpec) (types.ConfigCreateResponse, error) {
@@ -105,3 +114,22 @@ func TestConfigCreateWithLabels(t *testing.T) {
 	assert.NoError(t, cmd.Execute())
 	assert.Equal(t, "ID-"+name, strings.TrimSpace(cli.OutBuffer().String()))
 }
+
+func TestConfigCreateWithTemplate(t *testing.T) {
+	os.Setenv("CONFIG_CREATE_TEST_PORT", "8080")
+	defer os.Unsetenv("CONFIG_CREATE_TEST_PORT")
+
+	var actual []byte
+	cli := test.NewFakeCli(&fakeClient{
+		configCreateFunc: func(spec swarm.ConfigSpec) (types.ConfigCreateResponse, error) {
+			actual = spec.Data
+			return types.ConfigCreateResponse{ID: "ID-" + spec.Name}, nil
+		},
+	})
+
+	cmd := newConfigCreateCommand(cli)
+	cmd.SetArgs([]string{"foo", filepath.Join("testdata", "config-create-template.txt")})
+	cmd.Flags().Set("template-driver", "golang")
+	assert.NoError(t, cmd.Execute())
+	golden.Assert(t, string(actual), "config-create-with-template.golden")
+}
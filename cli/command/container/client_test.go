@@ -2,9 +2,12 @@ package container
 
 import (
 	"io"
+	"io/ioutil"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"golang.org/x/net/context"
@@ -18,6 +21,9 @@ type fakeClient struct {
 	createContainerFunc func(config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, containerName string) (container.ContainerCreateCreatedBody, error)
 	imageCreateFunc     func(parentReference string, options types.ImageCreateOptions) (io.ReadCloser, error)
 	infoFunc            func() (types.Info, error)
+	containerLogsFunc   func(container string, options types.ContainerLogsOptions) (io.ReadCloser, error)
+	containerListFunc   func(options types.ContainerListOptions) ([]types.Container, error)
+	eventsFunc          func(options types.EventsOptions) (<-chan events.Message, <-chan error)
 }
 
 func (f *fakeClient) ContainerInspect(_ context.Context, containerID string) (types.ContainerJSON, error) {
@@ -71,3 +77,24 @@ func (f *fakeClient) Info(_ context.Context) (types.Info, error) {
 	}
 	return types.Info{}, nil
 }
+
+func (f *fakeClient) ContainerLogs(_ context.Context, container string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	if f.containerLogsFunc != nil {
+		return f.containerLogsFunc(container, options)
+	}
+	return ioutil.NopCloser(strings.NewReader("")), nil
+}
+
+func (f *fakeClient) ContainerList(_ context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+	if f.containerListFunc != nil {
+		return f.containerListFunc(options)
+	}
+	return nil, nil
+}
+
+func (f *fakeClient) Events(_ context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
+	if f.eventsFunc != nil {
+		return f.eventsFunc(options)
+	}
+	return nil, nil
+}
@@ -20,6 +20,13 @@ import (
 	"golang.org/x/net/context"
 )
 
+// pull policies of the image of new containers
+const (
+	pullImageAlways  = "always"
+	pullImageMissing = "missing"
+	pullImageNever   = "never"
+)
+
 type createOptions struct {
 	name string
 }
@@ -62,7 +69,7 @@ func runCreate(dockerCli command.Cli, flags *pflag.FlagSet, opts *createOptions,
 		reportError(dockerCli.Err(), "create", err.Error(), true)
 		return cli.StatusError{StatusCode: 125}
 	}
-	response, err := createContainer(context.Background(), dockerCli, containerConfig, opts.name)
+	response, err := createContainer(context.Background(), dockerCli, containerConfig, opts.name, copts)
 	if err != nil {
 		return err
 	}
@@ -155,7 +162,8 @@ func newCIDFile(path string) (*cidFile, error) {
 	return &cidFile{path: path, file: f}, nil
 }
 
-func createContainer(ctx context.Context, dockerCli command.Cli, containerConfig *containerConfig, name string) (*container.ContainerCreateCreatedBody, error) {
+// nolint: gocyclo
+func createContainer(ctx context.Context, dockerCli command.Cli, containerConfig *containerConfig, name string, copts *containerOptions) (*container.ContainerCreateCreatedBody, error) {
 	config := containerConfig.Config
 	hostConfig := containerConfig.HostConfig
 	networkingConfig := containerConfig.NetworkingConfig
@@ -166,6 +174,15 @@ func createContainer(ctx context.Context, dockerCli command.Cli, containerConfig
 		namedRef   reference.Named
 	)
 
+	pullPolicy := copts.pull
+	switch pullPolicy {
+	case "":
+		pullPolicy = pullImageMissing
+	case pullImageAlways, pullImageMissing, pullImageNever:
+	default:
+		return nil, errors.Errorf("invalid pull option %q: must be one of %q, %q or %q", pullPolicy, pullImageAlways, pullImageMissing, pullImageNever)
+	}
+
 	containerIDFile, err := newCIDFile(hostConfig.ContainerIDFile)
 	if err != nil {
 		return nil, err
@@ -189,23 +206,35 @@ func createContainer(ctx context.Context, dockerCli command.Cli, containerConfig
 		}
 	}
 
+	pullAndTagImage := func() error {
+		// we don't want to write to stdout anything apart from container.ID
+		if err := pullImage(ctx, dockerCli, config.Image, stderr); err != nil {
+			return err
+		}
+		if taggedRef, ok := namedRef.(reference.NamedTagged); ok && trustedRef != nil {
+			return image.TagTrusted(ctx, dockerCli, trustedRef, taggedRef)
+		}
+		return nil
+	}
+
+	// refresh the image, so that mutable tags like latest are up to date
+	if pullPolicy == pullImageAlways && namedRef != nil {
+		if err := pullAndTagImage(); err != nil {
+			return nil, err
+		}
+	}
+
 	//create the container
 	response, err := dockerCli.Client().ContainerCreate(ctx, config, hostConfig, networkingConfig, name)
 
 	//if image not found try to pull it
 	if err != nil {
-		if apiclient.IsErrImageNotFound(err) && namedRef != nil {
+		if apiclient.IsErrImageNotFound(err) && namedRef != nil && pullPolicy == pullImageMissing {
 			fmt.Fprintf(stderr, "Unable to find image '%s' locally\n", reference.FamiliarString(namedRef))
 
-			// we don't want to write to stdout anything apart from container.ID
-			if err := pullImage(ctx, dockerCli, config.Image, stderr); err != nil {
+			if err := pullAndTagImage(); err != nil {
 				return nil, err
 			}
-			if taggedRef, ok := namedRef.(reference.NamedTagged); ok && trustedRef != nil {
-				if err := image.TagTrusted(ctx, dockerCli, trustedRef, taggedRef); err != nil {
-					return nil, err
-				}
-			}
 			// Retry
 			var retryErr error
 			response, retryErr = dockerCli.Client().ContainerCreate(ctx, config, hostConfig, networkingConfig, name)
@@ -106,7 +106,7 @@ func TestCreateContainerPullsImageIfMissing(t *testing.T) {
 		},
 		HostConfig: &container.HostConfig{},
 	}
-	body, err := createContainer(context.Background(), cli, config, "name")
+	body, err := createContainer(context.Background(), cli, config, "name", &containerOptions{})
 	require.NoError(t, err)
 	expected := container.ContainerCreateCreatedBody{ID: containerID}
 	assert.Equal(t, expected, *body)
@@ -114,6 +114,59 @@ func TestCreateContainerPullsImageIfMissing(t *testing.T) {
 	assert.Contains(t, stderr, "Unable to find image 'does-not-exist-locally:latest' locally")
 }
 
+func TestCreateContainerPullPolicy(t *testing.T) {
+	testCases := []struct {
+		pull          string
+		imageExists   bool
+		expectedPulls int
+		expectedError string
+	}{
+		{pull: "always", imageExists: true, expectedPulls: 1},
+		{pull: "always", expectedPulls: 1},
+		{pull: "missing", imageExists: true},
+		{pull: "missing", expectedPulls: 1},
+		{pull: "never", imageExists: true},
+		{pull: "never", expectedError: "error fake not found"},
+		{pull: "sometimes", expectedError: `invalid pull option "sometimes": must be one of "always", "missing" or "never"`},
+	}
+	for _, tc := range testCases {
+		pulls := 0
+		pulled := false
+		client := &fakeClient{
+			createContainerFunc: func(
+				config *container.Config,
+				hostConfig *container.HostConfig,
+				networkingConfig *network.NetworkingConfig,
+				containerName string,
+			) (container.ContainerCreateCreatedBody, error) {
+				if !tc.imageExists && !pulled {
+					return container.ContainerCreateCreatedBody{}, fakeNotFound{}
+				}
+				return container.ContainerCreateCreatedBody{ID: "abcdef"}, nil
+			},
+			imageCreateFunc: func(parentReference string, options types.ImageCreateOptions) (io.ReadCloser, error) {
+				pulls++
+				pulled = true
+				return ioutil.NopCloser(strings.NewReader("")), nil
+			},
+			infoFunc: func() (types.Info, error) {
+				return types.Info{IndexServerAddress: "http://indexserver"}, nil
+			},
+		}
+		config := &containerConfig{
+			Config:     &container.Config{Image: "busybox:latest"},
+			HostConfig: &container.HostConfig{},
+		}
+		_, err := createContainer(context.Background(), test.NewFakeCli(client), config, "name", &containerOptions{pull: tc.pull})
+		if tc.expectedError != "" {
+			assert.EqualError(t, err, tc.expectedError, tc.pull)
+		} else {
+			assert.NoError(t, err, tc.pull)
+		}
+		assert.Equal(t, tc.expectedPulls, pulls, tc.pull)
+	}
+}
+
 type fakeNotFound struct{}
 
 func (f fakeNotFound) NotFound() bool { return true }
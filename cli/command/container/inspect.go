@@ -1,6 +1,8 @@
 package container
 
 import (
+	"fmt"
+
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/inspect"
@@ -29,7 +31,7 @@ func newInspectCommand(dockerCli *command.DockerCli) *cobra.Command {
 	}
 
 	flags := cmd.Flags()
-	flags.StringVarP(&opts.format, "format", "f", "", "Format the output using the given Go template")
+	flags.StringVarP(&opts.format, "format", "f", "", "Format the output using the given Go template, or 'spec' to print the spec of the containers")
 	flags.BoolVarP(&opts.size, "size", "s", false, "Display total file sizes")
 
 	return cmd
@@ -39,8 +41,33 @@ func runInspect(dockerCli *command.DockerCli, opts inspectOptions) error {
 	client := dockerCli.Client()
 	ctx := context.Background()
 
+	if opts.format == specFormat {
+		return inspectSpecs(ctx, dockerCli, opts.refs)
+	}
+
 	getRefFunc := func(ref string) (interface{}, []byte, error) {
 		return client.ContainerInspectWithRaw(ctx, ref, opts.size)
 	}
 	return inspect.Inspect(dockerCli.Out(), opts.refs, opts.format, getRefFunc)
 }
+
+// inspectSpecs prints the specs of containers as YAML documents
+func inspectSpecs(ctx context.Context, dockerCli command.Cli, refs []string) error {
+	for i, ref := range refs {
+		c, err := dockerCli.Client().ContainerInspect(ctx, ref)
+		if err != nil {
+			return err
+		}
+		data, err := marshalContainerSpec(specFromContainer(c))
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintln(dockerCli.Out(), "---")
+		}
+		if _, err := dockerCli.Out().Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,12 +1,23 @@
 package container
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/opts"
+	"github.com/docker/cli/service/logs"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/context"
 )
@@ -14,23 +25,33 @@ import (
 type logsOptions struct {
 	follow     bool
 	since      string
+	until      string
 	timestamps bool
 	details    bool
 	tail       string
+	filter     opts.FilterOpt
+	noColor    bool
+	output     string
+	gzip       bool
 
-	container string
+	containers []string
 }
 
 // NewLogsCommand creates a new cobra.Command for `docker logs`
-func NewLogsCommand(dockerCli *command.DockerCli) *cobra.Command {
-	var opts logsOptions
+func NewLogsCommand(dockerCli command.Cli) *cobra.Command {
+	opts := logsOptions{filter: opts.NewFilterOpt()}
 
 	cmd := &cobra.Command{
-		Use:   "logs [OPTIONS] CONTAINER",
-		Short: "Fetch the logs of a container",
-		Args:  cli.ExactArgs(1),
+		Use:   "logs [OPTIONS] CONTAINER [CONTAINER...]",
+		Short: "Fetch the logs of one or more containers",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.filter.Value().Len() > 0 {
+				return nil
+			}
+			return cli.RequiresMinArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			opts.container = args[0]
+			opts.containers = args
 			return runLogs(dockerCli, &opts)
 		},
 	}
@@ -38,39 +59,423 @@ func NewLogsCommand(dockerCli *command.DockerCli) *cobra.Command {
 	flags := cmd.Flags()
 	flags.BoolVarP(&opts.follow, "follow", "f", false, "Follow log output")
 	flags.StringVar(&opts.since, "since", "", "Show logs since timestamp (e.g. 2013-01-02T13:23:37) or relative (e.g. 42m for 42 minutes)")
+	flags.StringVar(&opts.until, "until", "", "Show logs before a timestamp (e.g. 2013-01-02T13:23:37) or relative (e.g. 42m for 42 minutes)")
 	flags.BoolVarP(&opts.timestamps, "timestamps", "t", false, "Show timestamps")
 	flags.BoolVar(&opts.details, "details", false, "Show extra details provided to logs")
 	flags.StringVar(&opts.tail, "tail", "all", "Number of lines to show from the end of the logs")
+	flags.Var(&opts.filter, "filter", "Fetch the logs of the containers matching a filter (e.g. label=app=billing)")
+	flags.BoolVar(&opts.noColor, "no-color", false, "Do not colorize the container names")
+	flags.StringVarP(&opts.output, "output", "o", "", "Write the logs of each container to a file in a directory")
+	flags.BoolVar(&opts.gzip, "gzip", false, "Compress the log files written with --output")
 	return cmd
 }
 
-func runLogs(dockerCli *command.DockerCli, opts *logsOptions) error {
-	ctx := context.Background()
+// logSource is a container to fetch the logs of
+type logSource struct {
+	id   string
+	name string
+	tty  bool
+}
+
+func runLogs(dockerCli command.Cli, opts *logsOptions) error {
+	if opts.output != "" && opts.follow {
+		return errors.New("--output can not be used with --follow")
+	}
+	if opts.gzip && opts.output == "" {
+		return errors.New("--gzip can only be used with --output")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	apiClient := dockerCli.Client()
+	filter := opts.filter.Value()
+
+	// the vendored types.ContainerLogsOptions has no Until, so the daemon
+	// sends all the logs after since, and the lines written after until are
+	// dropped by the CLI
+	var until time.Time
+	if opts.until != "" {
+		var err error
+		until, err = logs.ParseUntil(opts.until, time.Now())
+		if err != nil {
+			return err
+		}
+		if opts.follow {
+			if until.After(time.Now()) {
+				// stop following the logs at the end of the time window
+				ctx, cancel = context.WithDeadline(ctx, until)
+				defer cancel()
+			} else {
+				// the time window has already ended: there is nothing to follow
+				opts.follow = false
+			}
+		}
+	}
+	watch := opts.follow && filter.Len() > 0
 
 	options := types.ContainerLogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
 		Since:      opts.since,
-		Timestamps: opts.timestamps,
+		Timestamps: opts.timestamps || !until.IsZero(),
 		Follow:     opts.follow,
 		Tail:       opts.tail,
 		Details:    opts.details,
 	}
-	responseBody, err := dockerCli.Client().ContainerLogs(ctx, opts.container, options)
+
+	// subscribe to start events before listing the containers, so that no
+	// container that starts in the meantime is missed
+	var (
+		eventq <-chan events.Message
+		errq   <-chan error
+	)
+	if watch {
+		eventFilter := filters.NewArgs()
+		eventFilter.Add("type", "container")
+		eventFilter.Add("event", "start")
+		eventq, errq = apiClient.Events(ctx, types.EventsOptions{Until: opts.until, Filters: eventFilter})
+	}
+
+	sources, err := getLogSources(ctx, dockerCli, opts.containers, filter)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 && !watch {
+		return errors.New("no container matches the filter")
+	}
+
+	if opts.output != "" {
+		return exportLogs(ctx, apiClient, sources, options, opts, until)
+	}
+
+	// the logs of a single container are printed as-is. the lines of several
+	// containers are prefixed with the name of their container, and sorted
+	// by timestamp when timestamps are shown, unless they are followed.
+	prefixed := len(sources) > 1 || filter.Len() > 0
+	sorted := prefixed && options.Timestamps && !opts.follow
+	printer := &logPrinter{
+		ctx:        ctx,
+		client:     apiClient,
+		merger:     logs.NewMerger(dockerCli.Out(), dockerCli.Err(), sorted),
+		prefixed:   prefixed,
+		color:      !opts.noColor && dockerCli.Out().IsTerminal(),
+		timestamps: sorted || !until.IsZero(),
+		strip:      !opts.timestamps,
+		until:      until,
+		attached:   map[string]bool{},
+		options:    options,
+	}
+	for _, source := range sources {
+		printer.setPadding(source.name)
+	}
+	for _, source := range sources {
+		printer.attach(source, printer.options)
+	}
+
+	if watch {
+		if err := printer.watch(eventq, errq, filter); err != nil {
+			return err
+		}
+	}
+	return printer.wait()
+}
+
+// exportLogs writes the logs of each container to a file of an export
+func exportLogs(ctx context.Context, apiClient client.APIClient, sources []logSource, options types.ContainerLogsOptions, opts *logsOptions, until time.Time) error {
+	export, err := logs.NewExport(opts.output, logs.ExportOptions{Compress: opts.gzip, Since: opts.since, Until: opts.until})
+	if err != nil {
+		return err
+	}
+	for _, source := range sources {
+		lines := lineWriter{timestamps: !until.IsZero(), strip: !opts.timestamps, until: until}
+		if err := exportContainerLogs(ctx, apiClient, export, source, options, lines); err != nil {
+			export.Close()
+			return errors.Wrapf(err, "failed to export the logs of %s", source.name)
+		}
+	}
+	return export.Close()
+}
+
+func exportContainerLogs(ctx context.Context, apiClient client.APIClient, export *logs.Export, source logSource, options types.ContainerLogsOptions, lines lineWriter) error {
+	file, err := export.Create(source.name, logs.ManifestEntry{ContainerID: source.id, ContainerName: source.name})
+	if err != nil {
+		return err
+	}
+	responseBody, err := apiClient.ContainerLogs(ctx, source.id, options)
 	if err != nil {
 		return err
 	}
 	defer responseBody.Close()
 
-	c, err := dockerCli.Client().ContainerInspect(ctx, opts.container)
+	// stdout and stderr are written to the same file, in the order they
+	// were logged
+	lines.merger = logs.NewMerger(file, file, false)
+	stdout := &lines
+	if source.tty {
+		_, err = io.Copy(stdout, responseBody)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stdout, responseBody)
+	}
+	if flushErr := stdout.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// getLogSources returns the containers passed as arguments, and the
+// containers matching the filter
+func getLogSources(ctx context.Context, dockerCli command.Cli, containers []string, filter filters.Args) ([]logSource, error) {
+	apiClient := dockerCli.Client()
+	refs := containers
+	if filter.Len() > 0 {
+		matches, err := apiClient.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: filter})
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			refs = append(refs, match.ID)
+		}
+	}
+
+	var sources []logSource
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		c, err := apiClient.ContainerInspect(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if seen[c.ID] {
+			continue
+		}
+		seen[c.ID] = true
+		sources = append(sources, logSource{id: c.ID, name: strings.TrimPrefix(c.Name, "/"), tty: c.Config.Tty})
+	}
+	return sources, nil
+}
+
+// logPrinter copies the logs of containers to the output
+type logPrinter struct {
+	ctx      context.Context
+	client   client.APIClient
+	merger   *logs.Merger
+	options  types.ContainerLogsOptions
+	prefixed bool
+	color    bool
+	// timestamps is true if lines start with a timestamp that is used to
+	// sort or filter them, and strip is true if it must then be removed
+	timestamps bool
+	strip      bool
+	until      time.Time
+
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	padding  int
+	attached map[string]bool
+	errs     []string
+}
+
+func (p *logPrinter) setPadding(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(name) > p.padding {
+		p.padding = len(name)
+	}
+}
+
+// prefix returns the prefix of the log lines of a container
+func (p *logPrinter) prefix(name string) string {
+	if !p.prefixed {
+		return ""
+	}
+	p.mu.Lock()
+	padding := p.padding
+	p.mu.Unlock()
+
+	prefix := name + strings.Repeat(" ", padding-len(name)) + " | "
+	if p.color {
+		prefix = logs.Colorize(name, prefix)
+	}
+	return prefix
+}
+
+// attach starts copying the logs of a container, unless they are already
+// being copied
+func (p *logPrinter) attach(source logSource, options types.ContainerLogsOptions) {
+	p.mu.Lock()
+	if p.attached[source.id] {
+		p.mu.Unlock()
+		return
+	}
+	p.attached[source.id] = true
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		err := p.copyLogs(source, options)
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		// the container can be attached again if it restarts
+		delete(p.attached, source.id)
+		// errors are expected once following the logs is stopped
+		if err != nil && p.ctx.Err() == nil {
+			p.errs = append(p.errs, fmt.Sprintf("%s: %v", source.name, err))
+		}
+	}()
+}
+
+func (p *logPrinter) copyLogs(source logSource, options types.ContainerLogsOptions) error {
+	responseBody, err := p.client.ContainerLogs(p.ctx, source.id, options)
 	if err != nil {
 		return err
 	}
+	defer responseBody.Close()
 
-	if c.Config.Tty {
-		_, err = io.Copy(dockerCli.Out(), responseBody)
+	prefix := p.prefix(source.name)
+	stdout := &lineWriter{merger: p.merger, prefix: prefix, timestamps: p.timestamps, strip: p.strip, until: p.until}
+	stderr := &lineWriter{merger: p.merger, prefix: prefix, timestamps: p.timestamps, strip: p.strip, until: p.until, stderr: true}
+	if source.tty {
+		_, err = io.Copy(stdout, responseBody)
 	} else {
-		_, err = stdcopy.StdCopy(dockerCli.Out(), dockerCli.Err(), responseBody)
+		_, err = stdcopy.StdCopy(stdout, stderr, responseBody)
+	}
+	if flushErr := stdout.Flush(); err == nil {
+		err = flushErr
+	}
+	if flushErr := stderr.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// watch attaches to the containers matching the filter when they start,
+// until the event stream ends
+func (p *logPrinter) watch(eventq <-chan events.Message, errq <-chan error, filter filters.Args) error {
+	for {
+		select {
+		case event := <-eventq:
+			if err := p.attachStarted(event, filter); err != nil {
+				return err
+			}
+		case err := <-errq:
+			if err == io.EOF || p.ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// attachStarted attaches to a container that started, if it matches the
+// filter. Only the logs written since the container started are fetched.
+func (p *logPrinter) attachStarted(event events.Message, filter filters.Args) error {
+	matches, err := p.client.ContainerList(p.ctx, types.ContainerListOptions{All: true, Filters: filter})
+	if err != nil {
+		return err
 	}
+	matched := false
+	for _, match := range matches {
+		if match.ID == event.Actor.ID {
+			matched = true
+		}
+	}
+	if !matched {
+		return nil
+	}
+	c, err := p.client.ContainerInspect(p.ctx, event.Actor.ID)
+	if err != nil {
+		return err
+	}
+	source := logSource{id: c.ID, name: strings.TrimPrefix(c.Name, "/"), tty: c.Config.Tty}
+	p.setPadding(source.name)
+
+	options := p.options
+	options.Tail = "all"
+	options.Since = fmt.Sprintf("%d.%09d", event.TimeNano/int64(time.Second), event.TimeNano%int64(time.Second))
+	p.attach(source, options)
+	return nil
+}
+
+// wait waits for the logs of all containers to be copied, and writes the
+// sorted logs
+func (p *logPrinter) wait() error {
+	p.wg.Wait()
+	if err := p.merger.Flush(); err != nil {
+		return err
+	}
+	if len(p.errs) > 0 {
+		return errors.New(strings.Join(p.errs, "\n"))
+	}
+	return nil
+}
+
+// lineWriter writes the log lines of a container to a merger, with a prefix.
+// Partial lines are buffered until the end of the line is written, or the
+// writer is flushed.
+type lineWriter struct {
+	merger *logs.Merger
+	prefix string
+	// timestamps is true if lines start with a timestamp that is used to
+	// sort or filter them, and strip is true if it must then be removed
+	timestamps bool
+	strip      bool
+	// until drops the lines written after it, if set
+	until  time.Time
+	stderr bool
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	if w.prefix == "" && !w.timestamps {
+		// nothing to add to the lines, so they are written as they come
+		if err := w.merger.WriteLine(time.Time{}, w.stderr, p); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes the buffered partial line, if any
+func (w *lineWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.writeLine(w.buf)
+	w.buf = nil
 	return err
 }
+
+func (w *lineWriter) writeLine(line []byte) error {
+	var timestamp time.Time
+	if w.timestamps {
+		if i := bytes.IndexByte(line, ' '); i > 0 {
+			// lines without a valid timestamp are sorted first
+			var err error
+			timestamp, err = time.Parse(time.RFC3339Nano, string(line[:i]))
+			if err == nil && w.strip {
+				line = line[i+1:]
+			}
+		}
+	}
+	if logs.After(timestamp, w.until) {
+		return nil
+	}
+	output := make([]byte, 0, len(w.prefix)+len(line))
+	output = append(output, w.prefix...)
+	output = append(output, line...)
+	return w.merger.WriteLine(timestamp, w.stderr, output)
+}
@@ -0,0 +1,262 @@
+package container
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/cli/service/logs"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeContainerLogs returns a multiplexed log stream, as returned by the
+// daemon for a container without a tty
+func fakeContainerLogs(stdoutLines, stderrLines []string) io.ReadCloser {
+	buf := new(bytes.Buffer)
+	stdout := stdcopy.NewStdWriter(buf, stdcopy.Stdout)
+	stderr := stdcopy.NewStdWriter(buf, stdcopy.Stderr)
+	for _, line := range stdoutLines {
+		stdout.Write([]byte(line + "\n"))
+	}
+	for _, line := range stderrLines {
+		stderr.Write([]byte(line + "\n"))
+	}
+	return ioutil.NopCloser(buf)
+}
+
+func fakeContainer(id, name string) types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: id, Name: "/" + name},
+		Config:            &container.Config{},
+	}
+}
+
+func newContainerLogsFakeClient() *fakeClient {
+	containers := map[string]string{"web": "web-id", "web-id": "web-id", "database": "db-id", "db-id": "db-id"}
+	return &fakeClient{
+		inspectFunc: func(ref string) (types.ContainerJSON, error) {
+			id, ok := containers[ref]
+			if !ok {
+				return types.ContainerJSON{}, errors.Errorf("No such container: %s", ref)
+			}
+			if id == "web-id" {
+				return fakeContainer(id, "web"), nil
+			}
+			return fakeContainer(id, "database"), nil
+		},
+		containerListFunc: func(options types.ContainerListOptions) ([]types.Container, error) {
+			if options.Filters.ExactMatch("label", "app=billing") {
+				return []types.Container{{ID: "web-id"}, {ID: "db-id"}}, nil
+			}
+			return nil, nil
+		},
+		containerLogsFunc: func(id string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+			if id == "web-id" {
+				return fakeContainerLogs([]string{
+					"2017-10-19T10:00:01.000000000Z GET /",
+					"2017-10-19T10:00:03.000000000Z GET /index.html",
+				}, nil), nil
+			}
+			return fakeContainerLogs([]string{
+				"2017-10-19T10:00:02.000000000Z ready to accept connections",
+			}, []string{
+				"2017-10-19T10:00:04.000000000Z connection refused",
+			}), nil
+		},
+	}
+}
+
+func TestContainerLogsSingle(t *testing.T) {
+	cli := test.NewFakeCli(newContainerLogsFakeClient())
+	cmd := NewLogsCommand(cli)
+	cmd.SetArgs([]string{"web"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "2017-10-19T10:00:01.000000000Z GET /\n2017-10-19T10:00:03.000000000Z GET /index.html\n", cli.OutBuffer().String())
+	assert.Equal(t, "", cli.ErrBuffer().String())
+}
+
+func TestContainerLogsSortedByTimestamp(t *testing.T) {
+	cli := test.NewFakeCli(newContainerLogsFakeClient())
+	cmd := NewLogsCommand(cli)
+	cmd.SetArgs([]string{"-t", "web", "database"})
+	require.NoError(t, cmd.Execute())
+	expected := `web      | 2017-10-19T10:00:01.000000000Z GET /
+database | 2017-10-19T10:00:02.000000000Z ready to accept connections
+web      | 2017-10-19T10:00:03.000000000Z GET /index.html
+`
+	assert.Equal(t, expected, cli.OutBuffer().String())
+	assert.Equal(t, "database | 2017-10-19T10:00:04.000000000Z connection refused\n", cli.ErrBuffer().String())
+}
+
+func TestContainerLogsUntil(t *testing.T) {
+	client := newContainerLogsFakeClient()
+	logsFunc := client.containerLogsFunc
+	client.containerLogsFunc = func(id string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+		assert.True(t, options.Timestamps)
+		return logsFunc(id, options)
+	}
+	cli := test.NewFakeCli(client)
+	cmd := NewLogsCommand(cli)
+	cmd.SetArgs([]string{"--until", "2017-10-19T10:00:02Z", "web", "database"})
+	require.NoError(t, cmd.Execute())
+	expected := `web      | GET /
+database | ready to accept connections
+`
+	assert.Equal(t, expected, cli.OutBuffer().String())
+	assert.Equal(t, "", cli.ErrBuffer().String())
+}
+
+func TestContainerLogsFollowUntil(t *testing.T) {
+	testCases := []struct {
+		until    string
+		follow   bool
+		expected string
+	}{
+		{until: "2017-10-19T10:00:02Z", follow: false, expected: "GET /\n"},
+		{until: "10m", follow: false, expected: "GET /\nGET /index.html\n"},
+		{until: time.Now().Add(time.Hour).Format(time.RFC3339), follow: true, expected: "GET /\nGET /index.html\n"},
+	}
+	for _, tc := range testCases {
+		client := newContainerLogsFakeClient()
+		logsFunc := client.containerLogsFunc
+		client.containerLogsFunc = func(id string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+			// the logs are only followed if the time window has not ended
+			assert.Equal(t, tc.follow, options.Follow, tc.until)
+			return logsFunc(id, options)
+		}
+		cli := test.NewFakeCli(client)
+		cmd := NewLogsCommand(cli)
+		cmd.SetArgs([]string{"--follow", "--until", tc.until, "web"})
+		require.NoError(t, cmd.Execute(), tc.until)
+		assert.Equal(t, tc.expected, cli.OutBuffer().String(), tc.until)
+	}
+}
+
+func TestContainerLogsFilter(t *testing.T) {
+	cli := test.NewFakeCli(newContainerLogsFakeClient())
+	cmd := NewLogsCommand(cli)
+	// the container passed as argument also matches the filter, and its logs
+	// must only be printed once
+	cmd.SetArgs([]string{"--filter", "label=app=billing", "web"})
+	require.NoError(t, cmd.Execute())
+	out := cli.OutBuffer().String()
+	assert.Contains(t, out, "web      | 2017-10-19T10:00:01.000000000Z GET /\nweb      | 2017-10-19T10:00:03.000000000Z GET /index.html\n")
+	assert.Contains(t, out, "database | 2017-10-19T10:00:02.000000000Z ready to accept connections\n")
+	assert.Len(t, bytes.Split([]byte(out), []byte("\n")), 4)
+}
+
+func TestContainerLogsFollowFilter(t *testing.T) {
+	started := time.Date(2017, 10, 19, 10, 0, 5, 0, time.UTC)
+	client := newContainerLogsFakeClient()
+	// the database container starts after the logs are fetched
+	listed := 0
+	client.containerListFunc = func(options types.ContainerListOptions) ([]types.Container, error) {
+		assert.True(t, options.Filters.ExactMatch("label", "app=billing"))
+		listed++
+		if listed == 1 {
+			return []types.Container{{ID: "web-id"}}, nil
+		}
+		return []types.Container{{ID: "web-id"}, {ID: "db-id"}}, nil
+	}
+	client.eventsFunc = func(options types.EventsOptions) (<-chan events.Message, <-chan error) {
+		assert.True(t, options.Filters.ExactMatch("event", "start"))
+		eventq := make(chan events.Message)
+		errq := make(chan error)
+		go func() {
+			eventq <- events.Message{Type: "container", Action: "start", Actor: events.Actor{ID: "db-id"}, TimeNano: started.UnixNano()}
+			errq <- io.EOF
+		}()
+		return eventq, errq
+	}
+	logsFunc := client.containerLogsFunc
+	client.containerLogsFunc = func(id string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+		assert.True(t, options.Follow)
+		if id == "db-id" {
+			assert.Equal(t, "1508407205.000000000", options.Since)
+		}
+		return logsFunc(id, options)
+	}
+
+	cli := test.NewFakeCli(client)
+	cmd := NewLogsCommand(cli)
+	cmd.SetArgs([]string{"--follow", "--filter", "label=app=billing"})
+	require.NoError(t, cmd.Execute())
+	out := cli.OutBuffer().String()
+	assert.Contains(t, out, "| 2017-10-19T10:00:01.000000000Z GET /\n")
+	assert.Contains(t, out, "database | 2017-10-19T10:00:02.000000000Z ready to accept connections\n")
+}
+
+func TestContainerLogsOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "container-logs-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cli := test.NewFakeCli(newContainerLogsFakeClient())
+	cmd := NewLogsCommand(cli)
+	cmd.SetArgs([]string{"--output", dir, "web", "database"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "", cli.OutBuffer().String())
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "database.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "2017-10-19T10:00:02.000000000Z ready to accept connections\n2017-10-19T10:00:04.000000000Z connection refused\n", string(data))
+
+	var manifest logs.Manifest
+	data, err = ioutil.ReadFile(filepath.Join(dir, logs.ManifestFile))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, []logs.ManifestEntry{
+		{File: "web.log", ContainerID: "web-id", ContainerName: "web", Lines: 2},
+		{File: "database.log", ContainerID: "db-id", ContainerName: "database", Lines: 2},
+	}, manifest.Files)
+}
+
+func TestContainerLogsErrors(t *testing.T) {
+	testCases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			expectedError: "requires at least 1 argument",
+		},
+		{
+			args:          []string{"unknown"},
+			expectedError: "No such container: unknown",
+		},
+		{
+			args:          []string{"--filter", "label=app=unknown"},
+			expectedError: "no container matches the filter",
+		},
+		{
+			args:          []string{"--output", "logs", "--follow", "web"},
+			expectedError: "--output can not be used with --follow",
+		},
+		{
+			args:          []string{"--gzip", "web"},
+			expectedError: "--gzip can only be used with --output",
+		},
+		{
+			args:          []string{"--until", "yesterday", "web"},
+			expectedError: "invalid --until value",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := NewLogsCommand(test.NewFakeCli(newContainerLogsFakeClient()))
+		cmd.SetArgs(tc.args)
+		cmd.SetOutput(ioutil.Discard)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
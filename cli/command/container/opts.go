@@ -118,6 +118,7 @@ type containerOptions struct {
 	runtime            string
 	autoRemove         bool
 	init               bool
+	pull               string
 
 	Image string
 	Args  []string
@@ -283,6 +284,8 @@ func addFlags(flags *pflag.FlagSet) *containerOptions {
 
 	flags.BoolVar(&copts.init, "init", false, "Run an init inside the container that forwards signals and reaps processes")
 	flags.SetAnnotation("init", "version", []string{"1.25"})
+
+	flags.StringVar(&copts.pull, "pull", pullImageMissing, `Pull image before creating the container ("always"|"missing"|"never")`)
 	return copts
 }
 
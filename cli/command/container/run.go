@@ -30,6 +30,7 @@ type runOptions struct {
 	sigProxy   bool
 	name       string
 	detachKeys string
+	fromSpec   string
 }
 
 // NewRunCommand create a new `docker run` command
@@ -40,9 +41,17 @@ func NewRunCommand(dockerCli *command.DockerCli) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "run [OPTIONS] IMAGE [COMMAND] [ARG...]",
 		Short: "Run a command in a new container",
-		Args:  cli.RequiresMinArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			// the image can be set by the spec file
+			if opts.fromSpec != "" {
+				return nil
+			}
+			return cli.RequiresMinArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			copts.Image = args[0]
+			if len(args) > 0 {
+				copts.Image = args[0]
+			}
 			if len(args) > 1 {
 				copts.Args = args[1:]
 			}
@@ -58,6 +67,7 @@ func NewRunCommand(dockerCli *command.DockerCli) *cobra.Command {
 	flags.BoolVar(&opts.sigProxy, "sig-proxy", true, "Proxy received signals to the process")
 	flags.StringVar(&opts.name, "name", "", "Assign a name to the container")
 	flags.StringVar(&opts.detachKeys, "detach-keys", "", "Override the key sequence for detaching a container")
+	flags.StringVar(&opts.fromSpec, "from-spec", "", "Run a container from a spec file, with flags overriding the spec")
 
 	// Add an explicit help that doesn't have a `-h` to prevent the conflict
 	// with hostname
@@ -109,6 +119,9 @@ func runRun(dockerCli *command.DockerCli, flags *pflag.FlagSet, ropts *runOption
 	}
 	copts.env = *opts.NewListOptsRef(&newEnv, nil)
 	containerConfig, err := parse(flags, copts)
+	if err == nil && ropts.fromSpec != "" {
+		containerConfig, err = applyContainerSpec(ropts.fromSpec, flags, containerConfig, copts)
+	}
 	// just in case the parse does not exit
 	if err != nil {
 		reportError(dockerCli.Err(), "run", err.Error(), true)
@@ -161,7 +174,7 @@ func runContainer(dockerCli *command.DockerCli, opts *runOptions, copts *contain
 
 	ctx, cancelFun := context.WithCancel(context.Background())
 
-	createResponse, err := createContainer(ctx, dockerCli, containerConfig, opts.name)
+	createResponse, err := createContainer(ctx, dockerCli, containerConfig, opts.name, copts)
 	if err != nil {
 		reportError(stderr, cmdPath, err.Error(), true)
 		return runStartContainerErr(err)
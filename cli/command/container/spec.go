@@ -0,0 +1,438 @@
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	networktypes "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// specFormat is the format of `docker container inspect` printing the spec
+// of containers, that `docker run --from-spec` can run
+const specFormat = "spec"
+
+// specFromContainer returns the spec of a container, without its runtime
+// state. Only the network of the network mode is kept, as a container is
+// created connected to a single network.
+func specFromContainer(c types.ContainerJSON) *containerConfig {
+	config := *c.Config
+	// the hostname defaults to the short ID of the container
+	if config.Hostname == stringid.TruncateID(c.ID) {
+		config.Hostname = ""
+	}
+	// attaching to the streams of a container is decided when running it
+	config.AttachStdin = false
+	config.AttachStdout = false
+	config.AttachStderr = false
+	config.StdinOnce = false
+
+	hostConfig := *c.HostConfig
+	hostConfig.ContainerIDFile = ""
+
+	networkingConfig := &networktypes.NetworkingConfig{}
+	if c.NetworkSettings != nil {
+		network := string(hostConfig.NetworkMode)
+		if endpoint, ok := c.NetworkSettings.Networks[network]; ok && endpoint != nil {
+			settings := &networktypes.EndpointSettings{
+				IPAMConfig: endpoint.IPAMConfig,
+				Links:      endpoint.Links,
+				DriverOpts: endpoint.DriverOpts,
+			}
+			// the daemon adds the short ID of the container as an alias
+			for _, alias := range endpoint.Aliases {
+				if alias != stringid.TruncateID(c.ID) {
+					settings.Aliases = append(settings.Aliases, alias)
+				}
+			}
+			if !reflect.DeepEqual(settings, &networktypes.EndpointSettings{}) {
+				networkingConfig.EndpointsConfig = map[string]*networktypes.EndpointSettings{network: settings}
+			}
+		}
+	}
+
+	return &containerConfig{
+		Config:           &config,
+		HostConfig:       &hostConfig,
+		NetworkingConfig: networkingConfig,
+	}
+}
+
+// marshalContainerSpec returns the spec of a container as YAML. Fields are
+// named as in the API, and empty fields are left out.
+func marshalContainerSpec(spec *containerConfig) ([]byte, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML. It is parsed into an ordered map, so that the fields
+	// keep the order of the API types.
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(withoutEmptyFields(doc))
+}
+
+// withoutEmptyFields removes the null, false, zero and empty values of a
+// document, and the lists of empty values, like the console size of
+// containers
+func withoutEmptyFields(value interface{}) interface{} {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		cleaned := yaml.MapSlice{}
+		for _, item := range v {
+			if value := withoutEmptyFields(item.Value); !isEmptyField(value) {
+				cleaned = append(cleaned, yaml.MapItem{Key: item.Key, Value: value})
+			}
+		}
+		return cleaned
+	case []interface{}:
+		cleaned := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			cleaned = append(cleaned, withoutEmptyFields(item))
+		}
+		return cleaned
+	}
+	return value
+}
+
+func isEmptyField(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case yaml.MapSlice:
+		return len(v) == 0
+	case []interface{}:
+		for _, item := range v {
+			if !isEmptyField(item) {
+				return false
+			}
+		}
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case int:
+		return v == 0
+	case float64:
+		return v == 0
+	}
+	return false
+}
+
+// loadContainerSpec reads a YAML or JSON container spec file
+func loadContainerSpec(filename string) (*containerConfig, error) {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("spec file not found: %s", filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "invalid container spec")
+	}
+	if _, ok := doc.(map[interface{}]interface{}); !ok {
+		return nil, errors.New("invalid container spec: not an object")
+	}
+	data, err = json.Marshal(toJSONValue(doc))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid container spec")
+	}
+	spec := &containerConfig{}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, errors.Wrap(err, "invalid container spec")
+	}
+	return spec, nil
+}
+
+// toJSONValue converts a YAML document to a value that can be marshaled to
+// JSON
+func toJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			// keys of YAML documents can be numbers or booleans, like
+			// label keys
+			m[fmt.Sprint(key)] = toJSONValue(item)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, item := range v {
+			l[i] = toJSONValue(item)
+		}
+		return l
+	}
+	return value
+}
+
+// specFlagFields lists the fields of the spec that the flags of the command
+// line set, as paths in the JSON document of the spec. A `*` matches every
+// key of a map: the keys set by the flag replace the keys of the spec with
+// the same name, and the other keys of the spec are kept. The environment
+// variables, the attached streams, the image and the command are merged by
+// applyContainerSpec.
+var specFlagFields = map[string][]string{
+	"add-host":            {"HostConfig.ExtraHosts"},
+	"blkio-weight":        {"HostConfig.BlkioWeight"},
+	"blkio-weight-device": {"HostConfig.BlkioWeightDevice"},
+	"cap-add":             {"HostConfig.CapAdd"},
+	"cap-drop":            {"HostConfig.CapDrop"},
+	"cgroup-parent":       {"HostConfig.CgroupParent"},
+	"cidfile":             {"HostConfig.ContainerIDFile"},
+	"cpu-count":           {"HostConfig.CpuCount"},
+	"cpu-percent":         {"HostConfig.CpuPercent"},
+	"cpu-period":          {"HostConfig.CpuPeriod"},
+	"cpu-quota":           {"HostConfig.CpuQuota"},
+	"cpu-rt-period":       {"HostConfig.CpuRealtimePeriod"},
+	"cpu-rt-runtime":      {"HostConfig.CpuRealtimeRuntime"},
+	"cpu-shares":          {"HostConfig.CpuShares"},
+	"cpus":                {"HostConfig.NanoCpus"},
+	"cpuset-cpus":         {"HostConfig.CpusetCpus"},
+	"cpuset-mems":         {"HostConfig.CpusetMems"},
+	"device":              {"HostConfig.Devices"},
+	"device-cgroup-rule":  {"HostConfig.DeviceCgroupRules"},
+	"device-read-bps":     {"HostConfig.BlkioDeviceReadBps"},
+	"device-read-iops":    {"HostConfig.BlkioDeviceReadIOps"},
+	"device-write-bps":    {"HostConfig.BlkioDeviceWriteBps"},
+	"device-write-iops":   {"HostConfig.BlkioDeviceWriteIOps"},
+	"dns":                 {"HostConfig.Dns"},
+	"dns-opt":             {"HostConfig.DnsOptions"},
+	"dns-option":          {"HostConfig.DnsOptions"},
+	"dns-search":          {"HostConfig.DnsSearch"},
+	"entrypoint":          {"Config.Entrypoint"},
+	"expose":              {"Config.ExposedPorts.*"},
+	"group-add":           {"HostConfig.GroupAdd"},
+	"health-cmd":          {"Config.Healthcheck.Test"},
+	"health-interval":     {"Config.Healthcheck.Interval"},
+	"health-retries":      {"Config.Healthcheck.Retries"},
+	"health-start-period": {"Config.Healthcheck.StartPeriod"},
+	"health-timeout":      {"Config.Healthcheck.Timeout"},
+	"hostname":            {"Config.Hostname"},
+	"init":                {"HostConfig.Init"},
+	"interactive":         {"Config.OpenStdin"},
+	"io-maxbandwidth":     {"HostConfig.IOMaximumBandwidth"},
+	"io-maxiops":          {"HostConfig.IOMaximumIOps"},
+	"ip":                  {"NetworkingConfig.EndpointsConfig.*.IPAMConfig.IPv4Address"},
+	"ip6":                 {"NetworkingConfig.EndpointsConfig.*.IPAMConfig.IPv6Address"},
+	"ipc":                 {"HostConfig.IpcMode"},
+	"isolation":           {"HostConfig.Isolation"},
+	"kernel-memory":       {"HostConfig.KernelMemory"},
+	"label":               {"Config.Labels.*"},
+	"label-file":          {"Config.Labels.*"},
+	"link":                {"HostConfig.Links", "NetworkingConfig.EndpointsConfig.*.Links"},
+	"link-local-ip":       {"NetworkingConfig.EndpointsConfig.*.IPAMConfig.LinkLocalIPs"},
+	"log-driver":          {"HostConfig.LogConfig.Type"},
+	"log-opt":             {"HostConfig.LogConfig.Config.*"},
+	"mac-address":         {"Config.MacAddress"},
+	"memory":              {"HostConfig.Memory"},
+	"memory-reservation":  {"HostConfig.MemoryReservation"},
+	"memory-swap":         {"HostConfig.MemorySwap"},
+	"memory-swappiness":   {"HostConfig.MemorySwappiness"},
+	"mount":               {"HostConfig.Mounts"},
+	"net":                 {"HostConfig.NetworkMode"},
+	"net-alias":           {"NetworkingConfig.EndpointsConfig.*.Aliases"},
+	"network":             {"HostConfig.NetworkMode"},
+	"network-alias":       {"NetworkingConfig.EndpointsConfig.*.Aliases"},
+	"no-healthcheck":      {"Config.Healthcheck"},
+	"oom-kill-disable":    {"HostConfig.OomKillDisable"},
+	"oom-score-adj":       {"HostConfig.OomScoreAdj"},
+	"pid":                 {"HostConfig.PidMode"},
+	"pids-limit":          {"HostConfig.PidsLimit"},
+	"privileged":          {"HostConfig.Privileged"},
+	"publish":             {"HostConfig.PortBindings", "Config.ExposedPorts.*"},
+	"publish-all":         {"HostConfig.PublishAllPorts"},
+	"read-only":           {"HostConfig.ReadonlyRootfs"},
+	"restart":             {"HostConfig.RestartPolicy"},
+	"rm":                  {"HostConfig.AutoRemove"},
+	"runtime":             {"HostConfig.Runtime"},
+	"security-opt":        {"HostConfig.SecurityOpt"},
+	"shm-size":            {"HostConfig.ShmSize"},
+	"stop-signal":         {"Config.StopSignal"},
+	"stop-timeout":        {"Config.StopTimeout"},
+	"storage-opt":         {"HostConfig.StorageOpt.*"},
+	"sysctl":              {"HostConfig.Sysctls.*"},
+	"tmpfs":               {"HostConfig.Tmpfs.*"},
+	"tty":                 {"Config.Tty"},
+	"ulimit":              {"HostConfig.Ulimits"},
+	"user":                {"Config.User"},
+	"userns":              {"HostConfig.UsernsMode"},
+	"uts":                 {"HostConfig.UTSMode"},
+	"volume":              {"HostConfig.Binds", "Config.Volumes"},
+	"volume-driver":       {"HostConfig.VolumeDriver"},
+	"volumes-from":        {"HostConfig.VolumesFrom"},
+	"workdir":             {"Config.WorkingDir"},
+}
+
+// applyContainerSpec returns the config of a container run from a spec file,
+// with the flags and arguments of the command line taking precedence over the
+// spec. The settings of the spec are replaced by the flags that are set on
+// the command line, even to their default value, like `--restart=no`.
+func applyContainerSpec(filename string, flags *pflag.FlagSet, flagsConfig *containerConfig, copts *containerOptions) (*containerConfig, error) {
+	spec, err := loadContainerSpec(filename)
+	if err != nil {
+		return nil, err
+	}
+	flagsNetworking := specNetworking(spec, flags, flagsConfig)
+
+	merged, err := toGenericMap(spec)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := toGenericMap(&containerConfig{
+		Config:           flagsConfig.Config,
+		HostConfig:       flagsConfig.HostConfig,
+		NetworkingConfig: flagsNetworking,
+	})
+	if err != nil {
+		return nil, err
+	}
+	mergeMissing(merged, overrides)
+	flags.Visit(func(flag *pflag.Flag) {
+		for _, field := range specFlagFields[flag.Name] {
+			copyField(merged, overrides, strings.Split(field, "."))
+		}
+	})
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	result := &containerConfig{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	var specEnv []string
+	if spec.Config != nil {
+		specEnv = spec.Config.Env
+	}
+
+	config, flagsContainer := result.Config, flagsConfig.Config
+	if copts.Image != "" {
+		config.Image = copts.Image
+	}
+	if len(copts.Args) > 0 {
+		config.Cmd = flagsContainer.Cmd
+	}
+	if config.Image == "" {
+		return nil, errors.New("no image in the container spec: pass the image as argument")
+	}
+	config.Env = mergeEnv(specEnv, flagsContainer.Env)
+	config.AttachStdin = flagsContainer.AttachStdin
+	config.AttachStdout = flagsContainer.AttachStdout
+	config.AttachStderr = flagsContainer.AttachStderr
+	config.StdinOnce = config.OpenStdin && config.AttachStdin
+	return result, nil
+}
+
+// specNetworking returns the networking config of the command line, for the
+// network of the container. The endpoint settings of the command line apply
+// to the network of the spec, unless the network is set on the command line:
+// the endpoints of the spec for other networks are then removed, as a
+// container is created connected to a single network.
+func specNetworking(spec *containerConfig, flags *pflag.FlagSet, flagsConfig *containerConfig) *networktypes.NetworkingConfig {
+	flagsNetwork := string(flagsConfig.HostConfig.NetworkMode)
+	if flags.Changed("network") || flags.Changed("net") {
+		if spec.NetworkingConfig != nil {
+			for network := range spec.NetworkingConfig.EndpointsConfig {
+				if network != flagsNetwork {
+					delete(spec.NetworkingConfig.EndpointsConfig, network)
+				}
+			}
+		}
+		return flagsConfig.NetworkingConfig
+	}
+	if spec.HostConfig == nil || spec.HostConfig.NetworkMode == "" {
+		return flagsConfig.NetworkingConfig
+	}
+	networking := &networktypes.NetworkingConfig{EndpointsConfig: map[string]*networktypes.EndpointSettings{}}
+	if endpoint, ok := flagsConfig.NetworkingConfig.EndpointsConfig[flagsNetwork]; ok {
+		networking.EndpointsConfig[string(spec.HostConfig.NetworkMode)] = endpoint
+	}
+	return networking
+}
+
+func toGenericMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	return m, json.Unmarshal(data, &m)
+}
+
+// mergeMissing sets in dst the fields of src that are missing from dst.
+// Objects are merged field by field.
+func mergeMissing(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if dst[key] == nil {
+			dst[key] = value
+			continue
+		}
+		valueMap, ok := value.(map[string]interface{})
+		dstMap, dstOK := dst[key].(map[string]interface{})
+		if ok && dstOK {
+			mergeMissing(dstMap, valueMap)
+		}
+	}
+}
+
+// copyField sets the field at path in dst to its value in src, or removes it
+// from dst if src does not have it. A `*` in path matches every key of src.
+func copyField(dst, src map[string]interface{}, path []string) {
+	if path[0] == "*" {
+		for key := range src {
+			copyField(dst, src, append([]string{key}, path[1:]...))
+		}
+		return
+	}
+	key := path[0]
+	if len(path) == 1 {
+		if value, ok := src[key]; ok {
+			dst[key] = value
+		} else {
+			delete(dst, key)
+		}
+		return
+	}
+	srcMap, _ := src[key].(map[string]interface{})
+	dstMap, ok := dst[key].(map[string]interface{})
+	if !ok {
+		if srcMap == nil {
+			return
+		}
+		dstMap = map[string]interface{}{}
+		dst[key] = dstMap
+	}
+	copyField(dstMap, srcMap, path[1:])
+}
+
+// mergeEnv returns the environment variables of the spec, with the variables
+// of the command line replacing the variables of the same name
+func mergeEnv(spec, flags []string) []string {
+	index := map[string]int{}
+	env := make([]string, 0, len(spec)+len(flags))
+	for _, variable := range append(append([]string{}, spec...), flags...) {
+		name := strings.SplitN(variable, "=", 2)[0]
+		if i, ok := index[name]; ok {
+			env[i] = variable
+			continue
+		}
+		index[name] = len(env)
+		env = append(env, variable)
+	}
+	return env
+}
@@ -0,0 +1,194 @@
+package container
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func specContainer(id string) types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    id,
+			State: &types.ContainerState{Status: "running", Pid: 4242},
+			HostConfig: &container.HostConfig{
+				ContainerIDFile: "/tmp/cid",
+				NetworkMode:     "debug-net",
+				PortBindings: nat.PortMap{
+					"80/tcp": []nat.PortBinding{{HostPort: "8080"}},
+				},
+				RestartPolicy: container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3},
+				Resources:     container.Resources{Memory: 268435456},
+			},
+		},
+		Config: &container.Config{
+			Hostname:     id[:12],
+			AttachStdout: true,
+			AttachStderr: true,
+			Tty:          true,
+			Env:          []string{"PATH=/usr/bin", "DEBUG=1"},
+			Cmd:          []string{"sh"},
+			Image:        "busybox",
+			Labels:       map[string]string{"team": "infra"},
+		},
+		NetworkSettings: &types.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"debug-net": {
+					NetworkID: "net-id",
+					IPAddress: "10.0.0.2",
+					Aliases:   []string{id[:12], "debug"},
+				},
+			},
+		},
+	}
+}
+
+func TestInspectSpecs(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		inspectFunc: func(ref string) (types.ContainerJSON, error) {
+			return specContainer(ref + "0123456789abcdef"), nil
+		},
+	})
+	require.NoError(t, inspectSpecs(context.Background(), cli, []string{"one", "two"}))
+	golden.Assert(t, cli.OutBuffer().String(), "inspect-spec.golden")
+}
+
+func writeSpecFile(t *testing.T, spec string) (string, func()) {
+	dir, err := ioutil.TempDir("", "container-spec")
+	require.NoError(t, err)
+	filename := filepath.Join(dir, "spec.yaml")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(spec), 0644))
+	return filename, func() { os.RemoveAll(dir) }
+}
+
+func applySpecArgs(t *testing.T, filename string, args ...string) (*containerConfig, error) {
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.SetInterspersed(false)
+	copts := addFlags(flags)
+	require.NoError(t, flags.Parse(args))
+	if flags.NArg() > 0 {
+		copts.Image = flags.Arg(0)
+		copts.Args = flags.Args()[1:]
+	}
+	flagsConfig, err := parse(flags, copts)
+	require.NoError(t, err)
+	return applyContainerSpec(filename, flags, flagsConfig, copts)
+}
+
+func TestApplyContainerSpec(t *testing.T) {
+	data, err := marshalContainerSpec(specFromContainer(specContainer("0123456789abcdef")))
+	require.NoError(t, err)
+	filename, cleanup := writeSpecFile(t, string(data))
+	defer cleanup()
+
+	spec, err := applySpecArgs(t, filename)
+	require.NoError(t, err)
+	assert.Equal(t, "busybox", spec.Config.Image)
+	assert.Equal(t, []string{"sh"}, []string(spec.Config.Cmd))
+	assert.Equal(t, []string{"PATH=/usr/bin", "DEBUG=1"}, spec.Config.Env)
+	assert.True(t, spec.Config.Tty)
+	assert.True(t, spec.Config.AttachStdout)
+	assert.Equal(t, int64(268435456), spec.HostConfig.Memory)
+	assert.Equal(t, "on-failure", spec.HostConfig.RestartPolicy.Name)
+	assert.Equal(t, []string{"debug"}, spec.NetworkingConfig.EndpointsConfig["debug-net"].Aliases)
+
+	spec, err = applySpecArgs(t, filename, "-e", "DEBUG=0", "-m", "512m", "--label", "env=dev", "-a", "stderr", "busybox:musl", "sh", "-x")
+	require.NoError(t, err)
+	assert.Equal(t, "busybox:musl", spec.Config.Image)
+	assert.Equal(t, []string{"sh", "-x"}, []string(spec.Config.Cmd))
+	assert.Equal(t, []string{"PATH=/usr/bin", "DEBUG=0"}, spec.Config.Env)
+	assert.Equal(t, map[string]string{"team": "infra", "env": "dev"}, spec.Config.Labels)
+	assert.True(t, spec.Config.Tty)
+	assert.False(t, spec.Config.AttachStdout)
+	assert.True(t, spec.Config.AttachStderr)
+	assert.Equal(t, int64(536870912), spec.HostConfig.Memory)
+	assert.Equal(t, "on-failure", spec.HostConfig.RestartPolicy.Name)
+	assert.Equal(t, container.NetworkMode("debug-net"), spec.HostConfig.NetworkMode)
+}
+
+func TestApplyContainerSpecDefaultValueFlags(t *testing.T) {
+	spec := specFromContainer(specContainer("0123456789abcdef"))
+	spec.HostConfig.Privileged = true
+	data, err := marshalContainerSpec(spec)
+	require.NoError(t, err)
+	filename, cleanup := writeSpecFile(t, string(data))
+	defer cleanup()
+
+	// flags set to their default value replace the spec
+	result, err := applySpecArgs(t, filename, "--restart=no", "-t=false", "--privileged=false", "--network=default")
+	require.NoError(t, err)
+	assert.Equal(t, "no", result.HostConfig.RestartPolicy.Name)
+	assert.Equal(t, 0, result.HostConfig.RestartPolicy.MaximumRetryCount)
+	assert.False(t, result.Config.Tty)
+	assert.False(t, result.HostConfig.Privileged)
+	assert.Equal(t, container.NetworkMode("default"), result.HostConfig.NetworkMode)
+	assert.Len(t, result.NetworkingConfig.EndpointsConfig, 0)
+	assert.Equal(t, int64(268435456), result.HostConfig.Memory)
+
+	// endpoint settings apply to the network of the spec
+	result, err = applySpecArgs(t, filename, "--network-alias", "trace")
+	require.NoError(t, err)
+	assert.Equal(t, container.NetworkMode("debug-net"), result.HostConfig.NetworkMode)
+	assert.Equal(t, []string{"trace"}, result.NetworkingConfig.EndpointsConfig["debug-net"].Aliases)
+	assert.True(t, result.Config.Tty)
+	assert.True(t, result.HostConfig.Privileged)
+}
+
+func TestSpecFlagFields(t *testing.T) {
+	// flags merged by applyContainerSpec itself, or that are not part of the
+	// spec
+	skipped := map[string]bool{"attach": true, "env": true, "env-file": true, "pull": true}
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	addFlags(flags)
+	flags.VisitAll(func(flag *pflag.Flag) {
+		_, ok := specFlagFields[flag.Name]
+		assert.True(t, ok || skipped[flag.Name], "no spec field for flag %s", flag.Name)
+	})
+}
+
+func TestApplyContainerSpecErrors(t *testing.T) {
+	testCases := []struct {
+		spec          string
+		expectedError string
+	}{
+		{
+			spec:          "- busybox",
+			expectedError: "invalid container spec: not an object",
+		},
+		{
+			spec:          "Config: [",
+			expectedError: "invalid container spec",
+		},
+		{
+			spec:          "HostConfig:\n  Privileged: true\n",
+			expectedError: "no image in the container spec",
+		},
+		{
+			spec:          "Config:\n  Tty: true\nHostConfig:\n  Privileged: true\n",
+			expectedError: "no image in the container spec",
+		},
+	}
+	for _, tc := range testCases {
+		filename, cleanup := writeSpecFile(t, tc.spec)
+		_, err := applySpecArgs(t, filename)
+		testutil.ErrorContains(t, err, tc.expectedError)
+		cleanup()
+	}
+
+	_, err := applySpecArgs(t, "/nonexistent/spec.yaml")
+	assert.EqualError(t, err, "spec file not found: /nonexistent/spec.yaml")
+}
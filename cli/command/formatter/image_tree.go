@@ -0,0 +1,157 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/pkg/stringid"
+	units "github.com/docker/go-units"
+)
+
+const (
+	defaultImageTreeTableFormat = "table {{.Image}}\t{{.ID}}\t{{.Size}}\t{{.SharedSize}}\t{{.UniqueSize}}\t{{.Containers}}\t{{.Reclaimable}}"
+
+	treeBranch     = "├─ "
+	treeLastBranch = "└─ "
+	treeIndent     = "│  "
+	treeLastIndent = "   "
+)
+
+// ImageTreeNode is an image in a tree of images, where the children of an
+// image are the images built on top of its layers.
+type ImageTreeNode struct {
+	ID          string
+	RepoTags    []string
+	Size        int64
+	SharedSize  int64
+	Containers  int
+	Reclaimable int64
+	Children    []*ImageTreeNode
+}
+
+// NewImageTreeFormat returns a Format for rendering using an image tree Context
+func NewImageTreeFormat(source string, quiet bool) Format {
+	switch source {
+	case TableFormatKey:
+		if quiet {
+			return defaultQuietFormat
+		}
+		return defaultImageTreeTableFormat
+	case RawFormatKey:
+		if quiet {
+			return `image_id: {{.ID}}`
+		}
+		return `image_id: {{.ID}}
+tags: {{.Tags}}
+size: {{.Size}}
+shared_size: {{.SharedSize}}
+unique_size: {{.UniqueSize}}
+containers: {{.Containers}}
+reclaimable: {{.Reclaimable}}
+`
+	}
+	return Format(source)
+}
+
+// ImageTreeWrite writes the context, walking the trees depth first
+func ImageTreeWrite(ctx Context, roots []*ImageTreeNode) error {
+	render := func(format func(subContext subContext) error) error {
+		var walk func(nodes []*ImageTreeNode, indent string, root bool) error
+		walk = func(nodes []*ImageTreeNode, indent string, root bool) error {
+			for i, node := range nodes {
+				prefix, childIndent := "", ""
+				if !root {
+					prefix, childIndent = indent+treeBranch, indent+treeIndent
+					if i == len(nodes)-1 {
+						prefix, childIndent = indent+treeLastBranch, indent+treeLastIndent
+					}
+				}
+				if err := format(&imageTreeContext{trunc: ctx.Trunc, prefix: prefix, n: node}); err != nil {
+					return err
+				}
+				if err := walk(node.Children, childIndent, false); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return walk(roots, "", true)
+	}
+	return ctx.Write(newImageTreeContext(), render)
+}
+
+func newImageTreeContext() *imageTreeContext {
+	treeCtx := &imageTreeContext{}
+	treeCtx.header = map[string]string{
+		"Image":       imageHeader,
+		"ID":          imageIDHeader,
+		"Tags":        tagHeader,
+		"Size":        sizeHeader,
+		"SharedSize":  sharedSizeHeader,
+		"UniqueSize":  uniqueSizeHeader,
+		"Containers":  containersHeader,
+		"Reclaimable": reclaimableHeader,
+	}
+	return treeCtx
+}
+
+type imageTreeContext struct {
+	HeaderContext
+	trunc  bool
+	prefix string
+	n      *ImageTreeNode
+}
+
+func (c *imageTreeContext) MarshalJSON() ([]byte, error) {
+	return marshalJSON(c)
+}
+
+func (c *imageTreeContext) tags() []string {
+	var tags []string
+	for _, tag := range c.n.RepoTags {
+		if tag != "<none>:<none>" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Image returns the first tag of the image, or <none> if it is untagged,
+// indented to show its position in the tree.
+func (c *imageTreeContext) Image() string {
+	if tags := c.tags(); len(tags) > 0 {
+		return c.prefix + tags[0]
+	}
+	return c.prefix + "<none>"
+}
+
+func (c *imageTreeContext) ID() string {
+	if c.trunc {
+		return stringid.TruncateID(c.n.ID)
+	}
+	return c.n.ID
+}
+
+func (c *imageTreeContext) Tags() string {
+	return strings.Join(c.tags(), ", ")
+}
+
+func (c *imageTreeContext) Size() string {
+	return units.HumanSizeWithPrecision(float64(c.n.Size), 3)
+}
+
+func (c *imageTreeContext) SharedSize() string {
+	return units.HumanSizeWithPrecision(float64(c.n.SharedSize), 3)
+}
+
+func (c *imageTreeContext) UniqueSize() string {
+	return units.HumanSizeWithPrecision(float64(c.n.Size-c.n.SharedSize), 3)
+}
+
+func (c *imageTreeContext) Containers() string {
+	return fmt.Sprintf("%d", c.n.Containers)
+}
+
+func (c *imageTreeContext) Reclaimable() string {
+	return units.HumanSizeWithPrecision(float64(c.n.Reclaimable), 3)
+}
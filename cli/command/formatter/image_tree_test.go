@@ -0,0 +1,60 @@
+package formatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageTreeContextWrite(t *testing.T) {
+	cases := []struct {
+		context  Context
+		expected string
+	}{
+		{
+			Context{Format: NewImageTreeFormat("table {{.Image}}\t{{.Tags}}\t{{.UniqueSize}}", false)},
+			`IMAGE               TAG                 UNIQUE SiZE
+base:1              base:1, base:2      0B
+├─ a:1              a:1                 1kB
+│  └─ <none>                            300B
+└─ b:1              b:1                 2kB
+other:1             other:1             5kB
+`,
+		},
+		{
+			Context{Format: NewImageTreeFormat("table", true)},
+			`base
+a
+c
+b
+other
+`,
+		},
+		{
+			Context{Format: NewImageTreeFormat("{{.Image}} {{.Containers}} {{.Reclaimable}}", false)},
+			`base:1 0 0B
+├─ a:1 1 0B
+│  └─ <none> 0 300B
+└─ b:1 0 2kB
+other:1 0 5kB
+`,
+		},
+	}
+
+	roots := []*ImageTreeNode{
+		{ID: "base", RepoTags: []string{"base:1", "base:2"}, Size: 1000, SharedSize: 1000, Children: []*ImageTreeNode{
+			{ID: "a", RepoTags: []string{"a:1"}, Size: 2000, SharedSize: 1000, Containers: 1, Children: []*ImageTreeNode{
+				{ID: "c", RepoTags: []string{"<none>:<none>"}, Size: 2300, SharedSize: 2000, Reclaimable: 300},
+			}},
+			{ID: "b", RepoTags: []string{"b:1"}, Size: 3000, SharedSize: 1000, Reclaimable: 2000},
+		}},
+		{ID: "other", RepoTags: []string{"other:1"}, Size: 5000, Reclaimable: 5000},
+	}
+	for _, testcase := range cases {
+		out := bytes.NewBufferString("")
+		testcase.context.Output = out
+		assert.NoError(t, ImageTreeWrite(testcase.context, roots))
+		assert.Equal(t, testcase.expected, out.String())
+	}
+}
@@ -0,0 +1,69 @@
+package formatter
+
+import (
+	"strconv"
+
+	"github.com/docker/cli/cli/command/image/build"
+)
+
+const (
+	defaultLintTableFormat = "table {{.Line}}\t{{.Rule}}\t{{.Message}}"
+
+	lintLineHeader    = "LINE"
+	lintRuleHeader    = "RULE"
+	lintMessageHeader = "MESSAGE"
+)
+
+// NewLintFormat returns a Format for rendering using a lint Context
+func NewLintFormat(source string) Format {
+	switch source {
+	case TableFormatKey:
+		return defaultLintTableFormat
+	case RawFormatKey:
+		return `line: {{.Line}}
+rule: {{.Rule}}
+message: {{.Message}}
+`
+	}
+	return Format(source)
+}
+
+// LintWrite writes the problems found in a Dockerfile using the context
+func LintWrite(ctx Context, problems []build.LintProblem) error {
+	render := func(format func(subContext subContext) error) error {
+		for _, problem := range problems {
+			if err := format(&lintContext{p: problem}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	lintCtx := &lintContext{}
+	lintCtx.header = map[string]string{
+		"Line":    lintLineHeader,
+		"Rule":    lintRuleHeader,
+		"Message": lintMessageHeader,
+	}
+	return ctx.Write(lintCtx, render)
+}
+
+type lintContext struct {
+	HeaderContext
+	p build.LintProblem
+}
+
+func (c *lintContext) MarshalJSON() ([]byte, error) {
+	return marshalJSON(c)
+}
+
+func (c *lintContext) Line() string {
+	return strconv.Itoa(c.p.Line)
+}
+
+func (c *lintContext) Rule() string {
+	return c.p.Rule
+}
+
+func (c *lintContext) Message() string {
+	return c.p.Message
+}
@@ -0,0 +1,53 @@
+package formatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/docker/cli/cli/command/image/build"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintContextWrite(t *testing.T) {
+	cases := []struct {
+		context  Context
+		expected string
+	}{
+		{
+			Context{Format: NewLintFormat("table")},
+			`LINE                RULE                MESSAGE
+1                   unpinned-from       image "ubuntu" is not pinned
+4                   missing-user        the final stage runs as root
+`,
+		},
+		{
+			Context{Format: NewLintFormat("raw")},
+			`line: 1
+rule: unpinned-from
+message: image "ubuntu" is not pinned
+
+line: 4
+rule: missing-user
+message: the final stage runs as root
+
+`,
+		},
+		{
+			Context{Format: NewLintFormat("{{json .}}")},
+			`{"Line":"1","Message":"image \"ubuntu\" is not pinned","Rule":"unpinned-from"}
+{"Line":"4","Message":"the final stage runs as root","Rule":"missing-user"}
+`,
+		},
+	}
+
+	problems := []build.LintProblem{
+		{Rule: build.RuleUnpinnedFrom, Line: 1, Message: `image "ubuntu" is not pinned`},
+		{Rule: build.RuleMissingUser, Line: 4, Message: "the final stage runs as root"},
+	}
+	for _, testcase := range cases {
+		out := bytes.NewBufferString("")
+		testcase.context.Output = out
+		assert.NoError(t, LintWrite(testcase.context, problems))
+		assert.Equal(t, testcase.expected, out.String())
+	}
+}
@@ -0,0 +1,98 @@
+package formatter
+
+import (
+	"strings"
+)
+
+const (
+	defaultPlacementTableFormat       = "table {{.ID}}\t{{.Hostname}}\t{{.Eligible}}\t{{.Reason}}"
+	defaultPlacementSpreadTableFormat = "table {{.ID}}\t{{.Hostname}}\t{{.Eligible}}\t{{.Spread}}\t{{.Reason}}"
+
+	eligibleHeader = "ELIGIBLE"
+	spreadHeader   = "SPREAD"
+	reasonHeader   = "REASON"
+)
+
+// PlacementResult is the outcome of evaluating the placement of a service
+// against a node
+type PlacementResult struct {
+	NodeID   string
+	Hostname string
+	// Reasons holds the reasons why the node can not run tasks of the
+	// service. The node is eligible if there are none.
+	Reasons []string
+	// Spread holds the value of each spread placement preference of the
+	// service on the node, as "descriptor=value".
+	Spread []string
+}
+
+// Eligible returns true if the node can run tasks of the service
+func (r PlacementResult) Eligible() bool {
+	return len(r.Reasons) == 0
+}
+
+// NewPlacementFormat returns a Format for rendering using a placement Context.
+// The table format includes the spread values when spread is true.
+func NewPlacementFormat(source string, spread bool) Format {
+	switch source {
+	case TableFormatKey:
+		if spread {
+			return defaultPlacementSpreadTableFormat
+		}
+		return defaultPlacementTableFormat
+	}
+	return Format(source)
+}
+
+// PlacementWrite writes the context
+func PlacementWrite(ctx Context, results []PlacementResult) error {
+	render := func(format func(subContext subContext) error) error {
+		for _, result := range results {
+			if err := format(&placementContext{r: result}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	placementCtx := placementContext{}
+	placementCtx.header = map[string]string{
+		"ID":       nodeIDHeader,
+		"Hostname": hostnameHeader,
+		"Eligible": eligibleHeader,
+		"Spread":   spreadHeader,
+		"Reason":   reasonHeader,
+	}
+	return ctx.Write(&placementCtx, render)
+}
+
+type placementContext struct {
+	HeaderContext
+	r PlacementResult
+}
+
+func (c *placementContext) MarshalJSON() ([]byte, error) {
+	return marshalJSON(c)
+}
+
+func (c *placementContext) ID() string {
+	return c.r.NodeID
+}
+
+func (c *placementContext) Hostname() string {
+	return c.r.Hostname
+}
+
+func (c *placementContext) Eligible() string {
+	if c.r.Eligible() {
+		return "yes"
+	}
+	return "no"
+}
+
+func (c *placementContext) Spread() string {
+	return strings.Join(c.r.Spread, ", ")
+}
+
+func (c *placementContext) Reason() string {
+	return strings.Join(c.r.Reasons, "; ")
+}
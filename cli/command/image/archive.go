@@ -0,0 +1,225 @@
+package image
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const archiveManifestFile = "manifest.json"
+
+// newArchiveCommand returns a cobra command for `image archive` subcommands
+func newArchiveCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "archive",
+		Short: "Manage image archives created by docker save",
+		Args:  cli.NoArgs,
+		RunE:  command.ShowHelp(dockerCli.Err()),
+	}
+	cmd.AddCommand(
+		newArchiveInspectCommand(dockerCli),
+		newArchiveDiffCommand(dockerCli),
+	)
+	return cmd
+}
+
+// archiveImage is an image stored in an archive created by `docker save`
+type archiveImage struct {
+	ID       string
+	RepoTags []string
+	Layers   []archiveLayer
+	Size     int64
+
+	config []byte
+}
+
+// archiveLayer is a layer of an image stored in an archive
+type archiveLayer struct {
+	DiffID string
+	Path   string
+	Size   int64
+
+	// file is the name of the layer in the archive, once the symbolic
+	// links are resolved
+	file string
+}
+
+type archiveManifestEntry struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+type archiveImageConfig struct {
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+// openImageArchive reads the images of the archive stored in a file
+func openImageArchive(filename string) ([]archiveImage, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	images, err := readImageArchive(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid image archive %s", filename)
+	}
+	return images, nil
+}
+
+// readImageArchive reads the manifest, the image configurations and the
+// layer sizes of an archive created by `docker save`. The content of the
+// layers is skipped.
+func readImageArchive(r io.Reader) ([]archiveImage, error) {
+	return scanImageArchive(r, nil)
+}
+
+// scanImageArchive reads an archive created by `docker save` like
+// readImageArchive, passing the content of each layer to readLayer if it is
+// set.
+func scanImageArchive(r io.Reader, readLayer func(name string, r io.Reader) error) ([]archiveImage, error) {
+	files := map[string][]byte{}
+	sizes := map[string]int64{}
+	links := map[string]string{}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean(hdr.Name)
+		switch hdr.Typeflag {
+		case tar.TypeSymlink:
+			links[name] = path.Join(path.Dir(name), hdr.Linkname)
+		case tar.TypeReg, tar.TypeRegA:
+			if path.Base(name) == "layer.tar" {
+				sizes[name] = hdr.Size
+				if readLayer != nil {
+					if err := readLayer(name, tr); err != nil {
+						return nil, errors.Wrapf(err, "failed to read layer %s", name)
+					}
+				}
+				continue
+			}
+			if strings.Contains(name, "/") || !strings.HasSuffix(name, ".json") {
+				continue
+			}
+			data, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			files[name] = data
+		}
+	}
+
+	data, ok := files[archiveManifestFile]
+	if !ok {
+		return nil, errors.Errorf("%s not found", archiveManifestFile)
+	}
+	var manifest []archiveManifestEntry
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", archiveManifestFile)
+	}
+
+	images := make([]archiveImage, 0, len(manifest))
+	for _, entry := range manifest {
+		data, ok := files[path.Clean(entry.Config)]
+		if !ok {
+			return nil, errors.Errorf("image configuration %s not found", entry.Config)
+		}
+		var config archiveImageConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", entry.Config)
+		}
+		if len(config.RootFS.DiffIDs) != len(entry.Layers) {
+			return nil, errors.Errorf("image configuration %s does not match the layers of the image", entry.Config)
+		}
+
+		img := archiveImage{
+			ID:       "sha256:" + strings.TrimSuffix(path.Base(entry.Config), ".json"),
+			RepoTags: entry.RepoTags,
+			config:   data,
+		}
+		for i, layerPath := range entry.Layers {
+			layerPath = resolveArchiveLink(path.Clean(layerPath), links)
+			size, ok := sizes[layerPath]
+			if !ok {
+				return nil, errors.Errorf("layer %s not found", entry.Layers[i])
+			}
+			img.Layers = append(img.Layers, archiveLayer{
+				DiffID: config.RootFS.DiffIDs[i],
+				Path:   entry.Layers[i],
+				Size:   size,
+				file:   layerPath,
+			})
+			img.Size += size
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// resolveArchiveLink follows the symbolic links `docker save` creates for
+// layers that are stored more than once in an archive.
+func resolveArchiveLink(name string, links map[string]string) string {
+	for i := 0; i < 10; i++ {
+		target, ok := links[name]
+		if !ok {
+			break
+		}
+		name = target
+	}
+	return name
+}
+
+// filterImageArchive copies an archive created by `docker save`, leaving out
+// the directories of the layers that are in the exclude set.
+func filterImageArchive(r io.Reader, w io.Writer, images []archiveImage, exclude map[string]bool) error {
+	skip := map[string]bool{}
+	for _, img := range images {
+		for _, layer := range img.Layers {
+			if exclude[layer.DiffID] {
+				skip[path.Dir(path.Clean(layer.Path))] = true
+			}
+		}
+	}
+
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if skip[strings.SplitN(path.Clean(hdr.Name), "/", 2)[0]] {
+			continue
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
@@ -0,0 +1,139 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/pkg/stringid"
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+type archiveDiffOptions struct {
+	all     bool
+	noTrunc bool
+	from    string
+	to      string
+}
+
+// archiveDiffLayer is a layer found in one or both of the compared archives
+type archiveDiffLayer struct {
+	status string
+	diffID string
+	size   int64
+	images []string
+}
+
+func newArchiveDiffCommand(dockerCli command.Cli) *cobra.Command {
+	var opts archiveDiffOptions
+
+	cmd := &cobra.Command{
+		Use:   "diff [OPTIONS] FILE1 FILE2",
+		Short: "Show the layers added and removed between two image archives",
+		Args:  cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.from, opts.to = args[0], args[1]
+			return runArchiveDiff(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&opts.all, "all", "a", false, "Show unchanged layers too")
+	flags.BoolVar(&opts.noTrunc, "no-trunc", false, "Don't truncate output")
+	return cmd
+}
+
+func runArchiveDiff(dockerCli command.Cli, opts archiveDiffOptions) error {
+	from, err := openImageArchive(opts.from)
+	if err != nil {
+		return err
+	}
+	to, err := openImageArchive(opts.to)
+	if err != nil {
+		return err
+	}
+
+	layers := diffImageArchives(from, to)
+
+	w := tabwriter.NewWriter(dockerCli.Out(), 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tLAYER\tSIZE\tIMAGES")
+	counts := map[string]int{}
+	sizes := map[string]int64{}
+	for _, layer := range layers {
+		counts[layer.status]++
+		sizes[layer.status] += layer.size
+		if layer.status == "unchanged" && !opts.all {
+			continue
+		}
+		diffID := layer.diffID
+		if !opts.noTrunc {
+			diffID = stringid.TruncateID(diffID)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", layer.status, diffID, units.HumanSizeWithPrecision(float64(layer.size), 3), strings.Join(layer.images, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	var summary []string
+	for _, status := range []string{"added", "removed", "unchanged"} {
+		summary = append(summary, fmt.Sprintf("%d %s %s (%s)", counts[status], pluralize("layer", counts[status]), status, units.HumanSizeWithPrecision(float64(sizes[status]), 3)))
+	}
+	fmt.Fprintf(dockerCli.Out(), "\n%s\n", strings.Join(summary, ", "))
+	return nil
+}
+
+// diffImageArchives returns the layers of both archives, in the order they
+// are found in the archives: the layers of the first archive are removed or
+// unchanged, the layers only found in the second archive are added.
+func diffImageArchives(from, to []archiveImage) []*archiveDiffLayer {
+	var layers []*archiveDiffLayer
+	seen := map[string]*archiveDiffLayer{}
+	collect := func(images []archiveImage, status string) {
+		for _, img := range images {
+			name := archiveImageName(img)
+			for _, layer := range img.Layers {
+				diffLayer, ok := seen[layer.DiffID]
+				if !ok {
+					diffLayer = &archiveDiffLayer{status: status, diffID: layer.DiffID, size: layer.Size}
+					seen[layer.DiffID] = diffLayer
+					layers = append(layers, diffLayer)
+				} else if diffLayer.status != status {
+					diffLayer.status = "unchanged"
+				}
+				if !containsString(diffLayer.images, name) {
+					diffLayer.images = append(diffLayer.images, name)
+				}
+			}
+		}
+	}
+	collect(from, "removed")
+	collect(to, "added")
+	return layers
+}
+
+func archiveImageName(img archiveImage) string {
+	if len(img.RepoTags) > 0 {
+		return img.RepoTags[0]
+	}
+	return stringid.TruncateID(img.ID)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func pluralize(word string, count int) string {
+	if count == 1 {
+		return word
+	}
+	return word + "s"
+}
@@ -0,0 +1,54 @@
+package image
+
+import (
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/inspect"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type archiveInspectOptions struct {
+	format string
+	file   string
+}
+
+func newArchiveInspectCommand(dockerCli command.Cli) *cobra.Command {
+	var opts archiveInspectOptions
+
+	cmd := &cobra.Command{
+		Use:   "inspect [OPTIONS] FILE",
+		Short: "Display the images and layers stored in an image archive",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.file = args[0]
+			return runArchiveInspect(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.format, "format", "f", "", "Format the output using the given Go template")
+	return cmd
+}
+
+func runArchiveInspect(dockerCli command.Cli, opts archiveInspectOptions) error {
+	images, err := openImageArchive(opts.file)
+	if err != nil {
+		return err
+	}
+
+	byID := map[string]archiveImage{}
+	ids := make([]string, 0, len(images))
+	for _, img := range images {
+		byID[img.ID] = img
+		ids = append(ids, img.ID)
+	}
+	getRefFunc := func(ref string) (interface{}, []byte, error) {
+		img, ok := byID[ref]
+		if !ok {
+			return nil, nil, errors.Errorf("no such image in the archive: %s", ref)
+		}
+		return img, nil, nil
+	}
+	return inspect.Inspect(dockerCli.Out(), ids, opts.format, getRefFunc)
+}
@@ -0,0 +1,193 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type archiveEntry struct {
+	name    string
+	content string
+	link    string
+}
+
+func layerEntry(dir string, size int) archiveEntry {
+	return archiveEntry{name: dir + "/layer.tar", content: strings.Repeat("x", size)}
+}
+
+func configEntry(id string, diffIDs ...string) archiveEntry {
+	return archiveEntry{name: id + ".json", content: `{"rootfs":{"type":"layers","diff_ids":["` + strings.Join(diffIDs, `","`) + `"]}}`}
+}
+
+func makeImageArchive(t *testing.T, entries ...archiveEntry) []byte {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, entry := range entries {
+		hdr := &tar.Header{Name: entry.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(entry.content))}
+		if entry.link != "" {
+			hdr = &tar.Header{Name: entry.name, Mode: 0777, Typeflag: tar.TypeSymlink, Linkname: entry.link}
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func baseArchive(t *testing.T) []byte {
+	return makeImageArchive(t,
+		layerEntry("1111", 1000),
+		configEntry("aaaaaaaaaaaaaaaa", "sha256:b45e"),
+		archiveEntry{name: "manifest.json", content: `[{"Config":"aaaaaaaaaaaaaaaa.json","RepoTags":["busybox:latest"],"Layers":["1111/layer.tar"]}]`},
+	)
+}
+
+func appArchive(t *testing.T, version string) []byte {
+	return makeImageArchive(t,
+		layerEntry("1111", 1000),
+		layerEntry("2222", 2000),
+		layerEntry("3333", 3000),
+		archiveEntry{name: "4444/layer.tar", link: "../1111/layer.tar"},
+		configEntry("bbbbbbbbbbbbbbbb", "sha256:b45e", "sha256:de95", "sha256:"+version),
+		configEntry("cccccccccccccccc", "sha256:b45e"),
+		archiveEntry{name: "manifest.json", content: `[{"Config":"bbbbbbbbbbbbbbbb.json","RepoTags":["app:` + version + `"],"Layers":["1111/layer.tar","2222/layer.tar","3333/layer.tar"]},` +
+			`{"Config":"cccccccccccccccc.json","Layers":["4444/layer.tar"]}]`},
+	)
+}
+
+func writeArchiveFile(t *testing.T, dir, name string, data []byte) string {
+	filename := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(filename, data, 0644))
+	return filename
+}
+
+func TestReadImageArchive(t *testing.T) {
+	images, err := readImageArchive(bytes.NewReader(appArchive(t, "v1")))
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+
+	assert.Equal(t, "sha256:bbbbbbbbbbbbbbbb", images[0].ID)
+	assert.Equal(t, []string{"app:v1"}, images[0].RepoTags)
+	assert.Equal(t, int64(6000), images[0].Size)
+	assert.Equal(t, []archiveLayer{
+		{DiffID: "sha256:b45e", Path: "1111/layer.tar", Size: 1000, file: "1111/layer.tar"},
+		{DiffID: "sha256:de95", Path: "2222/layer.tar", Size: 2000, file: "2222/layer.tar"},
+		{DiffID: "sha256:v1", Path: "3333/layer.tar", Size: 3000, file: "3333/layer.tar"},
+	}, images[0].Layers)
+	assert.Equal(t, []archiveLayer{{DiffID: "sha256:b45e", Path: "4444/layer.tar", Size: 1000, file: "1111/layer.tar"}}, images[1].Layers)
+}
+
+func TestReadImageArchiveErrors(t *testing.T) {
+	testCases := []struct {
+		entries       []archiveEntry
+		expectedError string
+	}{
+		{
+			entries:       []archiveEntry{layerEntry("1111", 10)},
+			expectedError: "manifest.json not found",
+		},
+		{
+			entries:       []archiveEntry{{name: "manifest.json", content: `[{"Config":"aaaa.json"}]`}},
+			expectedError: "image configuration aaaa.json not found",
+		},
+		{
+			entries: []archiveEntry{
+				configEntry("aaaa", "sha256:b45e"),
+				{name: "manifest.json", content: `[{"Config":"aaaa.json","Layers":["1111/layer.tar"]}]`},
+			},
+			expectedError: "layer 1111/layer.tar not found",
+		},
+		{
+			entries: []archiveEntry{
+				configEntry("aaaa", "sha256:b45e", "sha256:de95"),
+				layerEntry("1111", 10),
+				{name: "manifest.json", content: `[{"Config":"aaaa.json","Layers":["1111/layer.tar"]}]`},
+			},
+			expectedError: "image configuration aaaa.json does not match the layers of the image",
+		},
+	}
+	for _, tc := range testCases {
+		_, err := readImageArchive(bytes.NewReader(makeImageArchive(t, tc.entries...)))
+		testutil.ErrorContains(t, err, tc.expectedError)
+	}
+}
+
+func TestArchiveInspect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "image-archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := writeArchiveFile(t, dir, "app.tar", appArchive(t, "v1"))
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cmd := newArchiveCommand(cli)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"inspect", "--format", "{{.ID}} {{.RepoTags}} {{len .Layers}} {{.Size}}", filename})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "sha256:bbbbbbbbbbbbbbbb [app:v1] 3 6000\nsha256:cccccccccccccccc [] 1 1000\n", cli.OutBuffer().String())
+}
+
+func TestArchiveDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "image-archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	v1 := writeArchiveFile(t, dir, "v1.tar", appArchive(t, "v1"))
+	v2 := writeArchiveFile(t, dir, "v2.tar", appArchive(t, "v2"))
+
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{name: "simple", args: []string{v1, v2}},
+		{name: "all", args: []string{"--all", v1, v2}},
+	}
+	for _, tc := range testCases {
+		cli := test.NewFakeCli(&fakeClient{})
+		cmd := newArchiveDiffCommand(cli)
+		cmd.SetOutput(ioutil.Discard)
+		cmd.SetArgs(tc.args)
+		require.NoError(t, cmd.Execute())
+		golden.Assert(t, cli.OutBuffer().String(), "archive-diff."+tc.name+".golden")
+	}
+}
+
+func TestSaveExcludeLayersFrom(t *testing.T) {
+	dir, err := ioutil.TempDir("", "image-archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	base := writeArchiveFile(t, dir, "base.tar", baseArchive(t))
+
+	cli := test.NewFakeCli(&fakeClient{
+		imageSaveFunc: func(images []string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(appArchive(t, "v1"))), nil
+		},
+	})
+	cmd := NewSaveCommand(cli)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"--exclude-layers-from", base, "app:v1"})
+	require.NoError(t, cmd.Execute())
+
+	var names []string
+	tr := tar.NewReader(cli.OutBuffer())
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+	assert.Equal(t, []string{"2222/layer.tar", "3333/layer.tar", "bbbbbbbbbbbbbbbb.json", "cccccccccccccccc.json", "manifest.json"}, names)
+}
@@ -64,6 +64,11 @@ type buildOptions struct {
 	target         string
 	imageIDFile    string
 	stream         bool
+	check          bool
+	format         string
+	contextReport  bool
+	fileSet        string
+	parallel       int
 }
 
 // dockerfileFromStdin returns true when the user specified that the Dockerfile
@@ -96,8 +101,22 @@ func NewBuildCommand(dockerCli command.Cli) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "build [OPTIONS] PATH | URL | -",
 		Short: "Build an image from a Dockerfile",
-		Args:  cli.ExactArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if options.fileSet != "" {
+				return nil
+			}
+			return cli.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.format != "" && !options.check {
+				return errors.New("--format can only be used with --check")
+			}
+			if options.fileSet != "" {
+				return runFileSetBuild(dockerCli, options, args)
+			}
+			if cmd.Flags().Changed("parallel") {
+				return errors.New("--parallel can only be used with --file-set")
+			}
 			options.context = args[0]
 			return runBuild(dockerCli, options)
 		},
@@ -133,6 +152,11 @@ func NewBuildCommand(dockerCli command.Cli) *cobra.Command {
 	flags.Var(&options.extraHosts, "add-host", "Add a custom host-to-IP mapping (host:ip)")
 	flags.StringVar(&options.target, "target", "", "Set the target build stage to build.")
 	flags.StringVar(&options.imageIDFile, "iidfile", "", "Write the image ID to the file")
+	flags.BoolVar(&options.check, "check", false, "Check the Dockerfile for common problems instead of building")
+	flags.StringVar(&options.format, "format", "", "Pretty-print the problems found by --check using a Go template")
+	flags.BoolVar(&options.contextReport, "context-report", false, "Print a report of the files sent in the build context before sending it")
+	flags.StringVar(&options.fileSet, "file-set", "", "Build the targets of a build definition file")
+	flags.IntVar(&options.parallel, "parallel", 4, "Maximum number of targets of --file-set built at the same time")
 
 	command.AddTrustVerificationFlags(flags)
 
@@ -177,6 +201,15 @@ func runBuild(dockerCli command.Cli, options buildOptions) error {
 		remote        string
 	)
 
+	if options.check {
+		return runLint(dockerCli, lintOptions{
+			context:        options.context,
+			dockerfileName: options.dockerfileName,
+			target:         options.target,
+			format:         options.format,
+		})
+	}
+
 	if options.dockerfileFromStdin() {
 		if options.contextFromStdin() {
 			return errors.New("invalid argument: can't use stdin for both build context and dockerfile")
@@ -224,6 +257,19 @@ func runBuild(dockerCli command.Cli, options buildOptions) error {
 		contextDir = tempDir
 	}
 
+	if options.contextReport {
+		if contextDir == "" {
+			return errors.New("--context-report requires a build context directory or Git repository")
+		}
+		var reportOut io.Writer = dockerCli.Out()
+		if options.quiet {
+			reportOut = dockerCli.Err()
+		}
+		if err := printContextReport(reportOut, contextDir, relDockerfile, options.dockerfileFromStdin()); err != nil {
+			return errors.Wrap(err, "failed to report on the build context")
+		}
+	}
+
 	// read from a directory into tar archive
 	if buildCtx == nil && !options.stream {
 		excludes, err := build.ReadDockerignore(contextDir)
@@ -343,38 +389,8 @@ func runBuild(dockerCli command.Cli, options buildOptions) error {
 		body = buildCtx
 	}
 
-	configFile := dockerCli.ConfigFile()
-	authConfigs, _ := configFile.GetAllCredentials()
-	buildOptions := types.ImageBuildOptions{
-		Memory:         options.memory.Value(),
-		MemorySwap:     options.memorySwap.Value(),
-		Tags:           options.tags.GetAll(),
-		SuppressOutput: options.quiet,
-		NoCache:        options.noCache,
-		Remove:         options.rm,
-		ForceRemove:    options.forceRm,
-		PullParent:     options.pull,
-		Isolation:      container.Isolation(options.isolation),
-		CPUSetCPUs:     options.cpuSetCpus,
-		CPUSetMems:     options.cpuSetMems,
-		CPUShares:      options.cpuShares,
-		CPUQuota:       options.cpuQuota,
-		CPUPeriod:      options.cpuPeriod,
-		CgroupParent:   options.cgroupParent,
-		Dockerfile:     relDockerfile,
-		ShmSize:        options.shmSize.Value(),
-		Ulimits:        options.ulimits.GetList(),
-		BuildArgs:      configFile.ParseProxyConfig(dockerCli.Client().DaemonHost(), options.buildArgs.GetAll()),
-		AuthConfigs:    authConfigs,
-		Labels:         opts.ConvertKVStringsToMap(options.labels.GetAll()),
-		CacheFrom:      options.cacheFrom,
-		SecurityOpt:    options.securityOpt,
-		NetworkMode:    options.networkMode,
-		Squash:         options.squash,
-		ExtraHosts:     options.extraHosts.GetAll(),
-		Target:         options.target,
-		RemoteContext:  remote,
-	}
+	buildOptions := newImageBuildOptions(dockerCli, options, relDockerfile)
+	buildOptions.RemoteContext = remote
 
 	if s != nil {
 		go func() {
@@ -460,6 +476,42 @@ func runBuild(dockerCli command.Cli, options buildOptions) error {
 	return nil
 }
 
+// newImageBuildOptions returns the options of the build request sent to the
+// daemon for the options of the command line
+func newImageBuildOptions(dockerCli command.Cli, options buildOptions, dockerfile string) types.ImageBuildOptions {
+	configFile := dockerCli.ConfigFile()
+	authConfigs, _ := configFile.GetAllCredentials()
+	return types.ImageBuildOptions{
+		Memory:         options.memory.Value(),
+		MemorySwap:     options.memorySwap.Value(),
+		Tags:           options.tags.GetAll(),
+		SuppressOutput: options.quiet,
+		NoCache:        options.noCache,
+		Remove:         options.rm,
+		ForceRemove:    options.forceRm,
+		PullParent:     options.pull,
+		Isolation:      container.Isolation(options.isolation),
+		CPUSetCPUs:     options.cpuSetCpus,
+		CPUSetMems:     options.cpuSetMems,
+		CPUShares:      options.cpuShares,
+		CPUQuota:       options.cpuQuota,
+		CPUPeriod:      options.cpuPeriod,
+		CgroupParent:   options.cgroupParent,
+		Dockerfile:     dockerfile,
+		ShmSize:        options.shmSize.Value(),
+		Ulimits:        options.ulimits.GetList(),
+		BuildArgs:      configFile.ParseProxyConfig(dockerCli.Client().DaemonHost(), options.buildArgs.GetAll()),
+		AuthConfigs:    authConfigs,
+		Labels:         opts.ConvertKVStringsToMap(options.labels.GetAll()),
+		CacheFrom:      options.cacheFrom,
+		SecurityOpt:    options.securityOpt,
+		NetworkMode:    options.networkMode,
+		Squash:         options.squash,
+		ExtraHosts:     options.extraHosts.GetAll(),
+		Target:         options.target,
+	}
+}
+
 func isLocalDir(c string) bool {
 	_, err := os.Stat(c)
 	return err == nil
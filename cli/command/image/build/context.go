@@ -10,11 +10,13 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
 
+	"github.com/docker/docker/builder/dockerignore"
 	"github.com/docker/docker/builder/remotecontext/git"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/fileutils"
@@ -136,6 +138,68 @@ func GetContextFromReader(r io.ReadCloser, dockerfileName string) (out io.ReadCl
 
 }
 
+// GetDockerfileFromReader reads the contents of the given reader as either a
+// Dockerfile or a tar archive, like GetContextFromReader, and returns the
+// Dockerfile and the patterns of the .dockerignore file of the archive.
+// dockerfileName is the path of the Dockerfile inside the archive, and can
+// only be set if the reader is an archive.
+func GetDockerfileFromReader(r io.Reader, dockerfileName string) (io.Reader, []string, error) {
+	buf := bufio.NewReader(r)
+
+	magic, err := buf.Peek(archiveHeaderSize)
+	if err != nil && err != io.EOF {
+		return nil, nil, errors.Errorf("failed to peek context header from STDIN: %v", err)
+	}
+	if !IsArchive(magic) {
+		if dockerfileName != "" {
+			return nil, nil, errors.New("invalid argument: can't use a Dockerfile with a build context read from stdin")
+		}
+		return buf, nil, nil
+	}
+
+	if dockerfileName == "" {
+		dockerfileName = DefaultDockerfileName
+	}
+	dockerfileName = path.Clean(filepath.ToSlash(dockerfileName))
+
+	decompressed, err := archive.DecompressStream(buf)
+	if err != nil {
+		return nil, nil, errors.Errorf("failed to read the build context: %v", err)
+	}
+	defer decompressed.Close()
+
+	var (
+		dockerfile []byte
+		excludes   []string
+		found      bool
+	)
+	tr := tar.NewReader(decompressed)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.Errorf("failed to read the build context: %v", err)
+		}
+		switch path.Clean(strings.TrimPrefix(hdr.Name, "/")) {
+		case dockerfileName:
+			if dockerfile, err = ioutil.ReadAll(tr); err != nil {
+				return nil, nil, err
+			}
+			found = true
+		case ".dockerignore":
+			if excludes, err = dockerignore.ReadAll(tr); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if !found {
+		return nil, nil, errors.Errorf("Cannot locate specified Dockerfile: %s", dockerfileName)
+	}
+	return bytes.NewReader(dockerfile), excludes, nil
+}
+
 // IsArchive checks for the magic bytes of a tar or any supported compression
 // algorithm.
 func IsArchive(header []byte) bool {
@@ -214,6 +214,41 @@ func TestGetContextFromReaderTar(t *testing.T) {
 	}
 }
 
+func TestGetDockerfileFromReaderTar(t *testing.T) {
+	contextDir, cleanup := createTestTempDir(t, "", "builder-context-test")
+	defer cleanup()
+
+	createTestTempFile(t, contextDir, "Dockerfile.dev", dockerfileContents, 0777)
+	createTestTempFile(t, contextDir, ".dockerignore", "*.local\n", 0777)
+
+	tarStream, err := archive.Tar(contextDir, archive.Gzip)
+	require.NoError(t, err)
+
+	dockerfile, excludes, err := GetDockerfileFromReader(tarStream, "./Dockerfile.dev")
+	require.NoError(t, err)
+	contents, err := ioutil.ReadAll(dockerfile)
+	require.NoError(t, err)
+	assert.Equal(t, dockerfileContents, string(contents))
+	assert.Equal(t, []string{"*.local"}, excludes)
+
+	tarStream, err = archive.Tar(contextDir, archive.Uncompressed)
+	require.NoError(t, err)
+	_, _, err = GetDockerfileFromReader(tarStream, "")
+	assert.EqualError(t, err, "Cannot locate specified Dockerfile: Dockerfile")
+}
+
+func TestGetDockerfileFromReaderString(t *testing.T) {
+	dockerfile, excludes, err := GetDockerfileFromReader(strings.NewReader(dockerfileContents), "")
+	require.NoError(t, err)
+	contents, err := ioutil.ReadAll(dockerfile)
+	require.NoError(t, err)
+	assert.Equal(t, dockerfileContents, string(contents))
+	assert.Empty(t, excludes)
+
+	_, _, err = GetDockerfileFromReader(strings.NewReader(dockerfileContents), "Dockerfile")
+	assert.EqualError(t, err, "invalid argument: can't use a Dockerfile with a build context read from stdin")
+}
+
 func TestValidateContextDirectoryEmptyContext(t *testing.T) {
 	// This isn't a valid test on Windows. See https://play.golang.org/p/RR6z6jxR81.
 	// The test will ultimately end up calling filepath.Abs(""). On Windows,
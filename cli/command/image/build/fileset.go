@@ -0,0 +1,129 @@
+package build
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// FileSet is a build definition file, which lists the targets to build with
+// a single `docker build --file-set` command.
+type FileSet struct {
+	Targets map[string]FileSetTarget `yaml:"targets"`
+}
+
+// FileSetTarget is a target of a build definition file. Context is the build
+// context directory, and Dockerfile the path of the Dockerfile in the build
+// context.
+type FileSetTarget struct {
+	Context    string            `yaml:"context"`
+	Dockerfile string            `yaml:"dockerfile"`
+	Target     string            `yaml:"target"`
+	Args       map[string]string `yaml:"args"`
+	Tags       []string          `yaml:"tags"`
+}
+
+// unsupportedTargetKeys are the keys of targets that this version of the CLI
+// can't build, with the reason. They are rejected instead of being ignored,
+// so that a target is not built differently than what it asks for.
+var unsupportedTargetKeys = map[string]string{
+	"platforms": "the Engine API version used by this client can't build images for another platform",
+}
+
+// LoadFileSet reads a build definition file. The build contexts of its
+// targets are relative to the directory of the file.
+func LoadFileSet(filename string) (*FileSet, error) {
+	source, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	workingDir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	fileSet, err := ParseFileSet(source, workingDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid build definition file %s", filename)
+	}
+	return fileSet, nil
+}
+
+// ParseFileSet parses a build definition file, and resolves the build
+// contexts of its targets relative to workingDir, and their Dockerfiles
+// relative to their build context.
+func ParseFileSet(source []byte, workingDir string) (*FileSet, error) {
+	fileSet := &FileSet{}
+	if err := yaml.Unmarshal(source, fileSet); err != nil {
+		return nil, err
+	}
+	if len(fileSet.Targets) == 0 {
+		return nil, errors.New("no targets defined")
+	}
+	if err := checkUnsupportedKeys(source); err != nil {
+		return nil, err
+	}
+	for name, target := range fileSet.Targets {
+		if name == "" {
+			return nil, errors.New("a target has an empty name")
+		}
+		if target.Context == "" {
+			target.Context = "."
+		}
+		if !filepath.IsAbs(target.Context) {
+			target.Context = filepath.Join(workingDir, target.Context)
+		}
+		if target.Dockerfile != "" && !filepath.IsAbs(target.Dockerfile) {
+			target.Dockerfile = filepath.Join(target.Context, target.Dockerfile)
+		}
+		fileSet.Targets[name] = target
+	}
+	return fileSet, nil
+}
+
+func checkUnsupportedKeys(source []byte) error {
+	var raw struct {
+		Targets map[string]map[string]interface{} `yaml:"targets"`
+	}
+	if err := yaml.Unmarshal(source, &raw); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(raw.Targets))
+	for name := range raw.Targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for key := range raw.Targets[name] {
+			if reason, ok := unsupportedTargetKeys[key]; ok {
+				return errors.Errorf("target %s: %s is not supported: %s", name, key, reason)
+			}
+		}
+	}
+	return nil
+}
+
+// Select returns the names of the targets to build, sorted: all the targets
+// of the file if names is empty.
+func (s *FileSet) Select(names []string) ([]string, error) {
+	if len(names) == 0 {
+		for name := range s.Targets {
+			names = append(names, name)
+		}
+	}
+	selected := []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if _, ok := s.Targets[name]; !ok {
+			return nil, errors.Errorf("no such target: %s", name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			selected = append(selected, name)
+		}
+	}
+	sort.Strings(selected)
+	return selected, nil
+}
@@ -0,0 +1,98 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFileSet(t *testing.T) {
+	source := `
+targets:
+  api:
+    context: services/api
+    dockerfile: build/Dockerfile
+    target: release
+    args:
+      VERSION: "1.0"
+    tags:
+      - example/api:latest
+  root:
+    tags: [example/root]
+`
+	fileSet, err := ParseFileSet([]byte(source), "/src")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]FileSetTarget{
+		"api": {
+			Context:    filepath.FromSlash("/src/services/api"),
+			Dockerfile: filepath.FromSlash("/src/services/api/build/Dockerfile"),
+			Target:     "release",
+			Args:       map[string]string{"VERSION": "1.0"},
+			Tags:       []string{"example/api:latest"},
+		},
+		"root": {
+			Context: filepath.FromSlash("/src"),
+			Tags:    []string{"example/root"},
+		},
+	}, fileSet.Targets)
+}
+
+func TestParseFileSetErrors(t *testing.T) {
+	testCases := []struct {
+		source        string
+		expectedError string
+	}{
+		{
+			source:        "targets: {}",
+			expectedError: "no targets defined",
+		},
+		{
+			source:        "targets: [api]",
+			expectedError: "cannot unmarshal",
+		},
+		{
+			source:        "targets:\n  api:\n    platforms: [linux/arm64]\n",
+			expectedError: "target api: platforms is not supported",
+		},
+	}
+	for _, tc := range testCases {
+		_, err := ParseFileSet([]byte(tc.source), "/src")
+		testutil.ErrorContains(t, err, tc.expectedError)
+	}
+}
+
+func TestLoadFileSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "build-file-set")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "build.yaml")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("targets:\n  app:\n    context: app\n"), 0644))
+
+	fileSet, err := LoadFileSet(filename)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "app"), fileSet.Targets["app"].Context)
+
+	require.NoError(t, ioutil.WriteFile(filename, []byte("targets:\n"), 0644))
+	_, err = LoadFileSet(filename)
+	testutil.ErrorContains(t, err, "invalid build definition file "+filename+": no targets defined")
+}
+
+func TestFileSetSelect(t *testing.T) {
+	fileSet := &FileSet{Targets: map[string]FileSetTarget{"web": {}, "api": {}, "worker": {}}}
+
+	names, err := fileSet.Select(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "web", "worker"}, names)
+
+	names, err = fileSet.Select([]string{"worker", "api", "worker"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "worker"}, names)
+
+	_, err = fileSet.Select([]string{"api", "db"})
+	assert.EqualError(t, err, "no such target: db")
+}
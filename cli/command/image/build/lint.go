@@ -0,0 +1,347 @@
+package build
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/pkg/fileutils"
+	"github.com/docker/docker/pkg/urlutil"
+	"github.com/pkg/errors"
+)
+
+// Rules checked by LintDockerfile
+const (
+	RuleUnpinnedFrom   = "unpinned-from"
+	RuleAptGetCleanup  = "apt-get-cleanup"
+	RuleAddRemoteURL   = "add-remote-url"
+	RuleCopyIgnored    = "copy-ignored"
+	RuleUnusedStage    = "unused-stage"
+	RuleMissingUser    = "missing-user"
+	RuleSecretArgOrEnv = "secret-arg-env"
+)
+
+var (
+	escapeDirectivePattern = regexp.MustCompile(`^#\s*escape\s*=\s*(\S)\s*$`)
+	aptGetInstallPattern   = regexp.MustCompile(`\bapt-get\s+(-\S+\s+)*install\b`)
+	aptListsCleanupPattern = regexp.MustCompile(`\brm\s+(-\S+\s+)*/var/lib/apt/lists`)
+	secretNamePattern      = regexp.MustCompile(`(?i)(passw(or)?d|secret|token|api_?key|private_?key|access_?key|credential)`)
+)
+
+// LintProblem is a problem found in a Dockerfile
+type LintProblem struct {
+	Rule    string
+	Line    int
+	Message string
+}
+
+// instruction is an instruction of a Dockerfile, with its line continuations
+// joined
+type instruction struct {
+	cmd  string
+	args string
+	line int
+}
+
+// stage is a build stage of a Dockerfile, started by a FROM instruction
+type stage struct {
+	name     string
+	line     int
+	parent   int
+	deps     []int
+	user     string
+	userLine int
+}
+
+// LintDockerfile parses a Dockerfile and returns the problems found in it,
+// sorted by line. excludes are the patterns of the .dockerignore file of the
+// build context, used to find the sources of COPY and ADD instructions that
+// are not sent to the daemon. target is the name of the stage to build, the
+// last stage is built if it is empty.
+// nolint: gocyclo
+func LintDockerfile(dockerfile io.Reader, excludes []string, target string) ([]LintProblem, error) {
+	instructions, err := parseDockerfile(dockerfile)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		problems []LintProblem
+		stages   []*stage
+	)
+	report := func(rule string, line int, format string, args ...interface{}) {
+		problems = append(problems, LintProblem{Rule: rule, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+	findStage := func(name string) int {
+		if index, err := strconv.Atoi(name); err == nil && index >= 0 && index < len(stages) {
+			return index
+		}
+		return findStageByName(stages, name)
+	}
+
+	for _, inst := range instructions {
+		flags, args := splitFlags(inst.args)
+		current := &stage{}
+		if len(stages) > 0 {
+			current = stages[len(stages)-1]
+		}
+
+		switch inst.cmd {
+		case "from":
+			if len(args) == 0 {
+				continue
+			}
+			s := &stage{line: inst.line, parent: findStage(args[0])}
+			if len(args) == 3 && strings.EqualFold(args[1], "as") {
+				s.name = strings.ToLower(args[2])
+			}
+			if s.parent >= 0 {
+				s.deps = append(s.deps, s.parent)
+			} else if !isPinned(args[0]) {
+				report(RuleUnpinnedFrom, inst.line, "image %q is not pinned: use a version tag or a digest", args[0])
+			}
+			stages = append(stages, s)
+		case "run":
+			if aptGetInstallPattern.MatchString(inst.args) && !aptListsCleanupPattern.MatchString(inst.args) {
+				report(RuleAptGetCleanup, inst.line, "apt-get install without removing /var/lib/apt/lists/* in the same RUN instruction")
+			}
+		case "add", "copy":
+			if from, ok := flags["from"]; ok {
+				if index := findStage(from); index >= 0 {
+					current.deps = append(current.deps, index)
+				}
+				continue
+			}
+			sources := copySources(inst.args, args)
+			for _, src := range sources {
+				if urlutil.IsURL(src) {
+					if inst.cmd == "add" {
+						report(RuleAddRemoteURL, inst.line, "ADD of the remote URL %s: download it with curl or wget in a RUN instruction instead", src)
+					}
+					continue
+				}
+				if strings.ContainsAny(src, "$*?[") {
+					continue
+				}
+				src = strings.TrimPrefix(path.Clean("/"+src), "/")
+				if src == "" {
+					continue
+				}
+				if excluded, _ := fileutils.Matches(src, excludes); excluded {
+					report(RuleCopyIgnored, inst.line, "the source %s of %s is excluded by .dockerignore", src, strings.ToUpper(inst.cmd))
+				}
+			}
+		case "user":
+			current.user, current.userLine = strings.TrimSpace(inst.args), inst.line
+		case "arg":
+			for _, arg := range args {
+				name := strings.SplitN(arg, "=", 2)[0]
+				if secretNamePattern.MatchString(name) {
+					report(RuleSecretArgOrEnv, inst.line, "ARG %s may contain a secret, which is stored in the image history", name)
+				}
+			}
+		case "env":
+			for _, name := range envNames(args) {
+				if secretNamePattern.MatchString(name) {
+					report(RuleSecretArgOrEnv, inst.line, "ENV %s may contain a secret, which is stored in the image", name)
+				}
+			}
+		}
+	}
+
+	if len(stages) > 0 {
+		// the stages after the target are not built
+		final, description := len(stages)-1, "the final stage"
+		if target != "" {
+			final, description = findStageByName(stages, target), "the target stage "+target
+			if final < 0 {
+				return nil, errors.Errorf("failed to reach build target %s in Dockerfile", target)
+			}
+		}
+		lintStages(stages[:final+1], description, report)
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems, nil
+}
+
+// findStageByName returns the index of the stage with the given name, or -1
+func findStageByName(stages []*stage, name string) int {
+	for i, s := range stages {
+		if s.name != "" && s.name == strings.ToLower(name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lintStages reports the stages that are not used to build the last stage,
+// and whether the last stage runs as root. description describes the last
+// stage in the problems reported.
+func lintStages(stages []*stage, description string, report func(rule string, line int, format string, args ...interface{})) {
+	final := len(stages) - 1
+	used := map[int]bool{}
+	var visit func(int)
+	visit = func(index int) {
+		if used[index] {
+			return
+		}
+		used[index] = true
+		for _, dep := range stages[index].deps {
+			visit(dep)
+		}
+	}
+	visit(final)
+	for i, s := range stages {
+		if used[i] {
+			continue
+		}
+		name := s.name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		report(RuleUnusedStage, s.line, "the stage %s is not used to build %s", name, description)
+	}
+
+	user, userLine := "", 0
+	for index := final; index >= 0 && user == ""; index = stages[index].parent {
+		user, userLine = stages[index].user, stages[index].userLine
+	}
+	switch {
+	case user == "":
+		report(RuleMissingUser, stages[final].line, "%s does not set a USER: the container runs as root", description)
+	case isRootUser(user):
+		report(RuleMissingUser, userLine, "%s runs as root", description)
+	}
+}
+
+// parseDockerfile splits a Dockerfile into instructions, handling comments,
+// line continuations and the escape parser directive.
+func parseDockerfile(r io.Reader) ([]instruction, error) {
+	var (
+		instructions []instruction
+		current      *instruction
+		escape       = `\`
+		directives   = true
+		lineno       = 0
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if directives {
+			if matches := escapeDirectivePattern.FindStringSubmatch(line); matches != nil {
+				escape = matches[1]
+				continue
+			}
+			if !strings.HasPrefix(line, "#") || line == "" {
+				directives = false
+			}
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		continued := strings.HasSuffix(line, escape)
+		if continued {
+			line = strings.TrimSpace(strings.TrimSuffix(line, escape))
+		}
+		if current == nil {
+			current = &instruction{cmd: strings.ToLower(line), line: lineno}
+			if i := strings.IndexAny(line, " \t"); i >= 0 {
+				current.cmd = strings.ToLower(line[:i])
+				current.args = strings.TrimSpace(line[i:])
+			}
+		} else {
+			current.args += " " + line
+		}
+		if !continued {
+			instructions = append(instructions, *current)
+			current = nil
+		}
+	}
+	if current != nil {
+		instructions = append(instructions, *current)
+	}
+	return instructions, scanner.Err()
+}
+
+// splitFlags returns the --name=value flags of an instruction, and its other
+// arguments
+func splitFlags(args string) (map[string]string, []string) {
+	flags := map[string]string{}
+	fields := strings.Fields(args)
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		kv := strings.SplitN(strings.TrimPrefix(fields[0], "--"), "=", 2)
+		if len(kv) == 2 {
+			flags[strings.ToLower(kv[0])] = kv[1]
+		} else {
+			flags[strings.ToLower(kv[0])] = ""
+		}
+		fields = fields[1:]
+	}
+	return flags, fields
+}
+
+// copySources returns the sources of a COPY or ADD instruction, in the
+// shell or the JSON form.
+func copySources(raw string, args []string) []string {
+	if i := strings.Index(raw, "["); i >= 0 {
+		var values []string
+		if err := json.Unmarshal([]byte(raw[i:]), &values); err == nil {
+			args = values
+		}
+	}
+	if len(args) < 2 {
+		return nil
+	}
+	return args[:len(args)-1]
+}
+
+// envNames returns the names of the variables set by an ENV instruction
+func envNames(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	if !strings.Contains(args[0], "=") {
+		return args[:1]
+	}
+	var names []string
+	for _, arg := range args {
+		if kv := strings.SplitN(arg, "=", 2); len(kv) == 2 && kv[0] != "" {
+			names = append(names, kv[0])
+		}
+	}
+	return names
+}
+
+// isPinned returns whether the image of a FROM instruction is pinned to a
+// digest or a tag other than latest. Images using build arguments can not
+// be checked.
+func isPinned(image string) bool {
+	if image == "scratch" || strings.Contains(image, "$") {
+		return true
+	}
+	ref, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		// invalid references are reported by the daemon
+		return true
+	}
+	if _, ok := ref.(reference.Canonical); ok {
+		return true
+	}
+	tagged, ok := ref.(reference.Tagged)
+	return ok && tagged.Tag() != "latest"
+}
+
+func isRootUser(user string) bool {
+	name := strings.SplitN(user, ":", 2)[0]
+	return name == "root" || name == "0"
+}
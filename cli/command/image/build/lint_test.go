@@ -0,0 +1,147 @@
+package build
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintDockerfile(t *testing.T) {
+	testCases := []struct {
+		name       string
+		dockerfile string
+		excludes   []string
+		target     string
+		expected   []LintProblem
+	}{
+		{
+			name: "clean",
+			dockerfile: `FROM golang:1.9 AS build
+COPY . /go/src/app
+RUN go build -o /app app
+
+FROM alpine:3.6
+COPY --from=build /app /app
+USER nobody
+`,
+		},
+		{
+			name: "unpinned-from",
+			dockerfile: `FROM ubuntu
+FROM debian:latest
+FROM alpine@sha256:1072e499f3f655a032e88542330cf75b02e7bdf673278f701d7ba61629ee3ebe
+ARG VERSION=3.6
+FROM alpine:${VERSION}
+USER app
+`,
+			expected: []LintProblem{
+				{Rule: RuleUnpinnedFrom, Line: 1, Message: `image "ubuntu" is not pinned: use a version tag or a digest`},
+				{Rule: RuleUnusedStage, Line: 1, Message: "the stage 0 is not used to build the final stage"},
+				{Rule: RuleUnpinnedFrom, Line: 2, Message: `image "debian:latest" is not pinned: use a version tag or a digest`},
+				{Rule: RuleUnusedStage, Line: 2, Message: "the stage 1 is not used to build the final stage"},
+				{Rule: RuleUnusedStage, Line: 3, Message: "the stage 2 is not used to build the final stage"},
+			},
+		},
+		{
+			name: "apt-get",
+			dockerfile: `FROM debian:stretch
+RUN apt-get update && \
+    apt-get install -y --no-install-recommends curl
+RUN apt-get update \
+    && apt-get -y install git \
+    && rm -rf /var/lib/apt/lists/*
+USER app
+`,
+			expected: []LintProblem{
+				{Rule: RuleAptGetCleanup, Line: 2, Message: "apt-get install without removing /var/lib/apt/lists/* in the same RUN instruction"},
+			},
+		},
+		{
+			name: "add-and-copy",
+			dockerfile: `FROM busybox:1.27
+ADD https://example.com/app.tar.gz /tmp/
+ADD ["vendor.tar", "/src/"]
+COPY secrets/key.pem node_modules /src/
+COPY *.go /src/
+USER 1000
+`,
+			excludes: []string{"*.tar", "secrets", "!secrets/key.pem", "node_modules"},
+			expected: []LintProblem{
+				{Rule: RuleAddRemoteURL, Line: 2, Message: "ADD of the remote URL https://example.com/app.tar.gz: download it with curl or wget in a RUN instruction instead"},
+				{Rule: RuleCopyIgnored, Line: 3, Message: "the source vendor.tar of ADD is excluded by .dockerignore"},
+				{Rule: RuleCopyIgnored, Line: 4, Message: "the source node_modules of COPY is excluded by .dockerignore"},
+			},
+		},
+		{
+			name: "stages",
+			dockerfile: `FROM golang:1.9 AS deps
+FROM deps AS build
+FROM node:8 AS assets
+FROM alpine:3.6 AS test
+COPY --from=build /app /app
+FROM test
+COPY --from=0 /go /go
+`,
+			expected: []LintProblem{
+				{Rule: RuleUnusedStage, Line: 3, Message: "the stage assets is not used to build the final stage"},
+				{Rule: RuleMissingUser, Line: 6, Message: "the final stage does not set a USER: the container runs as root"},
+			},
+		},
+		{
+			name: "target",
+			dockerfile: `FROM golang:1.9 AS deps
+FROM node:8 AS assets
+FROM deps AS dev
+FROM alpine:3.6
+COPY --from=assets /app /app
+USER nobody
+`,
+			target: "DEV",
+			expected: []LintProblem{
+				{Rule: RuleUnusedStage, Line: 2, Message: "the stage assets is not used to build the target stage DEV"},
+				{Rule: RuleMissingUser, Line: 3, Message: "the target stage DEV does not set a USER: the container runs as root"},
+			},
+		},
+		{
+			name: "root-user",
+			dockerfile: `FROM alpine:3.6 AS base
+USER app
+FROM base
+USER root:root
+`,
+			expected: []LintProblem{
+				{Rule: RuleMissingUser, Line: 4, Message: "the final stage runs as root"},
+			},
+		},
+		{
+			name: "secrets",
+			dockerfile: `# escape=` + "`" + `
+ARG NPM_TOKEN
+FROM node:8
+ARG VERSION=1 DB_PASSWORD
+ENV API_KEY abcd
+ENV HOME=/home/app ` + "`" + `
+    AWS_SECRET_ACCESS_KEY=xyz
+USER node
+`,
+			expected: []LintProblem{
+				{Rule: RuleSecretArgOrEnv, Line: 2, Message: "ARG NPM_TOKEN may contain a secret, which is stored in the image history"},
+				{Rule: RuleSecretArgOrEnv, Line: 4, Message: "ARG DB_PASSWORD may contain a secret, which is stored in the image history"},
+				{Rule: RuleSecretArgOrEnv, Line: 5, Message: "ENV API_KEY may contain a secret, which is stored in the image"},
+				{Rule: RuleSecretArgOrEnv, Line: 6, Message: "ENV AWS_SECRET_ACCESS_KEY may contain a secret, which is stored in the image"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		problems, err := LintDockerfile(strings.NewReader(tc.dockerfile), tc.excludes, tc.target)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, problems, tc.name)
+	}
+}
+
+func TestLintDockerfileUnknownTarget(t *testing.T) {
+	_, err := LintDockerfile(strings.NewReader("FROM alpine:3.6 AS base\n"), nil, "dev")
+	assert.EqualError(t, err, "failed to reach build target dev in Dockerfile")
+}
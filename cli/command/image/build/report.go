@@ -0,0 +1,177 @@
+package build
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/fileutils"
+)
+
+// ContextReport describes the files of a build context that are sent to the
+// daemon, once the .dockerignore file is applied.
+type ContextReport struct {
+	Files          int
+	Size           int64
+	Excluded       int
+	CompressedSize int64
+	LargestDirs    []ContextEntry
+	LargestFiles   []ContextEntry
+	Matches        []ContextMatch
+}
+
+// ContextEntry is a file or a directory of a build context, with the size of
+// the files it contains that are sent to the daemon.
+type ContextEntry struct {
+	Path string
+	Size int64
+}
+
+// ContextMatch is a path of a build context matched by a .dockerignore
+// pattern: it is excluded from the context, or re-included by a pattern
+// starting with !.
+type ContextMatch struct {
+	Path     string
+	Pattern  string
+	Excluded bool
+}
+
+type ignorePattern struct {
+	pattern string
+	matcher *fileutils.PatternMatcher
+	include bool
+}
+
+// NewContextReport walks a build context directory and reports the largest
+// directories and files sent to the daemon, and the paths matched by the
+// patterns of the .dockerignore file. limit is the number of directories
+// and files reported.
+// nolint: gocyclo
+func NewContextReport(contextDir string, excludes []string, limit int) (*ContextReport, error) {
+	var patterns []ignorePattern
+	hasIncludes := false
+	for _, pattern := range excludes {
+		include := strings.HasPrefix(pattern, "!")
+		matcher, err := fileutils.NewPatternMatcher([]string{strings.TrimPrefix(pattern, "!")})
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, ignorePattern{pattern: pattern, matcher: matcher, include: include})
+		hasIncludes = hasIncludes || include
+	}
+	// match returns the last pattern matching a path, as it decides whether
+	// the path is excluded
+	match := func(rel string) (*ignorePattern, error) {
+		var last *ignorePattern
+		for i := range patterns {
+			matched, err := patterns[i].matcher.Matches(rel)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				last = &patterns[i]
+			}
+		}
+		return last, nil
+	}
+
+	report := &ContextReport{}
+	dirSizes := map[string]int64{}
+	excludedDirs := map[string]bool{}
+	var files []ContextEntry
+
+	err := filepath.Walk(contextDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(contextDir, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		pattern, err := match(rel)
+		if err != nil {
+			return err
+		}
+		excluded := pattern != nil && !pattern.include
+		inExcludedDir := excludedDirs[path.Dir(rel)]
+
+		if pattern != nil && (!inExcludedDir || pattern.include) {
+			report.Matches = append(report.Matches, ContextMatch{Path: rel, Pattern: pattern.pattern, Excluded: excluded})
+		}
+		if excluded {
+			if !inExcludedDir {
+				report.Excluded++
+			}
+			if info.IsDir() {
+				if !hasIncludes {
+					return filepath.SkipDir
+				}
+				excludedDirs[rel] = true
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		report.Files++
+		report.Size += info.Size()
+		files = append(files, ContextEntry{Path: rel, Size: info.Size()})
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			dirSizes[dir] += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []ContextEntry
+	for dir, size := range dirSizes {
+		dirs = append(dirs, ContextEntry{Path: dir, Size: size})
+	}
+	report.LargestDirs = largestEntries(dirs, limit)
+	report.LargestFiles = largestEntries(files, limit)
+
+	report.CompressedSize, err = compressedContextSize(contextDir, excludes)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func largestEntries(entries []ContextEntry, limit int) []ContextEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// compressedContextSize returns the size of the build context once archived
+// and compressed with gzip.
+func compressedContextSize(contextDir string, excludes []string) (int64, error) {
+	tarball, err := archive.TarWithOptions(contextDir, &archive.TarOptions{
+		ExcludePatterns: excludes,
+		Compression:     archive.Gzip,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer tarball.Close()
+	return io.Copy(ioutil.Discard, tarball)
+}
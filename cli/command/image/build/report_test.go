@@ -0,0 +1,57 @@
+package build
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gotestyourself/gotestyourself/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewContextReport(t *testing.T) {
+	dir := fs.NewDir(t, "test-context-report",
+		fs.WithFile("Dockerfile", "FROM busybox\n"),
+		fs.WithFile("main.go", strings.Repeat("x", 300)),
+		fs.WithDir("vendor",
+			fs.WithFile("lib.go", strings.Repeat("x", 1000)),
+			fs.WithDir("sub", fs.WithFile("sub.go", strings.Repeat("x", 500))),
+		),
+		fs.WithDir("node_modules",
+			fs.WithFile("big.js", strings.Repeat("x", 5000)),
+			fs.WithFile("keep.js", strings.Repeat("x", 50)),
+		),
+		fs.WithFile("debug.log", strings.Repeat("x", 2000)),
+	)
+	defer dir.Remove()
+
+	report, err := NewContextReport(dir.Path(), []string{"*.log", "node_modules", "!node_modules/keep.js"}, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, report.Files)
+	assert.Equal(t, int64(13+300+1000+500+50), report.Size)
+	assert.Equal(t, 2, report.Excluded)
+	assert.True(t, report.CompressedSize > 0)
+	assert.Equal(t, []ContextEntry{{Path: "vendor", Size: 1500}, {Path: "vendor/sub", Size: 500}}, report.LargestDirs)
+	assert.Equal(t, []ContextEntry{{Path: "vendor/lib.go", Size: 1000}, {Path: "vendor/sub/sub.go", Size: 500}}, report.LargestFiles)
+	assert.Equal(t, []ContextMatch{
+		{Path: "debug.log", Pattern: "*.log", Excluded: true},
+		{Path: "node_modules", Pattern: "node_modules", Excluded: true},
+		{Path: "node_modules/keep.js", Pattern: "!node_modules/keep.js", Excluded: false},
+	}, report.Matches)
+}
+
+func TestNewContextReportSkipsExcludedDirectories(t *testing.T) {
+	dir := fs.NewDir(t, "test-context-report",
+		fs.WithFile("Dockerfile", "FROM busybox\n"),
+		fs.WithDir(".git", fs.WithFile("HEAD", "ref: refs/heads/master\n")),
+	)
+	defer dir.Remove()
+
+	report, err := NewContextReport(dir.Path(), []string{".git"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Files)
+	assert.Equal(t, 1, report.Excluded)
+	assert.Equal(t, []ContextMatch{{Path: ".git", Pattern: ".git", Excluded: true}}, report.Matches)
+	assert.Empty(t, report.LargestDirs)
+}
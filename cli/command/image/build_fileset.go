@@ -0,0 +1,416 @@
+package image
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/image/build"
+	"github.com/docker/cli/opts"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/progress"
+	"github.com/docker/docker/pkg/streamformatter"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// fileSetTarget is a target of a build definition file, ready to be built
+type fileSetTarget struct {
+	name       string
+	options    buildOptions
+	dockerfile string
+	context    *sharedContext
+}
+
+// sharedContext is a build context directory used by one or more targets of
+// a build definition file. When several targets use the same directory, with
+// the same excluded files, the context is archived once to a temporary file,
+// and that archive is sent for each of the targets.
+type sharedContext struct {
+	contextDir string
+	excludes   []string
+	targets    int
+
+	once sync.Once
+	path string
+	err  error
+}
+
+// open returns the archive of the build context
+func (c *sharedContext) open() (io.ReadCloser, error) {
+	if c.targets < 2 {
+		return archiveBuildContext(c.contextDir, c.excludes)
+	}
+	c.once.Do(func() {
+		c.path, c.err = writeContextArchive(c.contextDir, c.excludes)
+	})
+	if c.err != nil {
+		return nil, c.err
+	}
+	return os.Open(c.path)
+}
+
+// remove removes the temporary archive of the build context, if any
+func (c *sharedContext) remove() {
+	if c.path != "" {
+		os.Remove(c.path)
+	}
+}
+
+func archiveBuildContext(contextDir string, excludes []string) (io.ReadCloser, error) {
+	return archive.TarWithOptions(contextDir, &archive.TarOptions{
+		ExcludePatterns: excludes,
+		ChownOpts:       &idtools.IDPair{UID: 0, GID: 0},
+	})
+}
+
+func writeContextArchive(contextDir string, excludes []string) (string, error) {
+	tarball, err := archiveBuildContext(contextDir, excludes)
+	if err != nil {
+		return "", err
+	}
+	defer tarball.Close()
+
+	f, err := ioutil.TempFile("", "docker-build-context-")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, tarball); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// validateFileSetOptions checks that the options of the command line only
+// set options that apply to all the targets of a build definition file.
+func validateFileSetOptions(options buildOptions) error {
+	if options.parallel < 1 {
+		return errors.Errorf("invalid --parallel value %d: must be at least 1", options.parallel)
+	}
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{name: "tag", set: options.tags.Len() > 0},
+		{name: "file", set: options.dockerfileName != ""},
+		{name: "target", set: options.target != ""},
+		{name: "iidfile", set: options.imageIDFile != ""},
+		{name: "stream", set: options.stream},
+		{name: "check", set: options.check},
+		{name: "context-report", set: options.contextReport},
+	} {
+		if flag.set {
+			return errors.Errorf("--%s can't be used with --file-set: set it for each target in the build definition file", flag.name)
+		}
+	}
+	return nil
+}
+
+// runFileSetBuild builds the targets of a build definition file, or the
+// targets named on the command line, with at most options.parallel builds
+// running at the same time. The other options of the command line apply to
+// all the targets.
+func runFileSetBuild(dockerCli command.Cli, options buildOptions, names []string) error {
+	if err := validateFileSetOptions(options); err != nil {
+		return err
+	}
+	fileSet, err := build.LoadFileSet(options.fileSet)
+	if err != nil {
+		return err
+	}
+	if names, err = fileSet.Select(names); err != nil {
+		return err
+	}
+	targets, contexts, err := prepareFileSetTargets(dockerCli, options, fileSet, names)
+	for _, c := range contexts {
+		defer c.remove()
+	}
+	if err != nil {
+		return err
+	}
+
+	width := 0
+	for _, target := range targets {
+		if len(target.name) > width {
+			width = len(target.name)
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make([]string, len(targets))
+		errs    = make([]error, len(targets))
+		sem     = make(chan struct{}, options.parallel)
+	)
+	for i := range targets {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// The output of the builds is merged, each line prefixed with the
+			// name of its target. With --quiet, the output of a target is
+			// only printed if it fails to build.
+			var (
+				output   io.Writer = dockerCli.Out()
+				buffered *bytes.Buffer
+			)
+			if options.quiet {
+				buffered = new(bytes.Buffer)
+				output = buffered
+			}
+			out := &prefixWriter{mu: &mu, out: output, prefix: fmt.Sprintf("%-*s | ", width, targets[i].name)}
+			results[i], errs[i] = buildFileSetTarget(dockerCli, targets[i], out)
+			if errs[i] != nil {
+				fmt.Fprintf(out, "ERROR: %s\n", errs[i])
+			}
+			out.Flush()
+			if errs[i] != nil && buffered != nil {
+				mu.Lock()
+				dockerCli.Err().Write(buffered.Bytes())
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var failed []string
+	w := tabwriter.NewWriter(dockerCli.Out(), 0, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "TARGET\tSTATUS\tIMAGE ID\tTAGS")
+	for i, target := range targets {
+		status, imageID, tags := "built", stringid.TruncateID(results[i]), strings.Join(target.options.tags.GetAll(), ", ")
+		if errs[i] != nil {
+			status, imageID = "failed", "-"
+			failed = append(failed, target.name)
+		}
+		if imageID == "" {
+			imageID = "-"
+		}
+		if tags == "" {
+			tags = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", target.name, status, imageID, tags)
+	}
+	w.Flush()
+
+	if len(failed) > 0 {
+		return cli.StatusError{
+			StatusCode: 1,
+			Status:     fmt.Sprintf("%d of %d targets failed to build: %s", len(failed), len(targets), strings.Join(failed, ", ")),
+		}
+	}
+	return nil
+}
+
+// prepareFileSetTargets resolves the build context, Dockerfile and options of
+// the targets of a build definition file. It returns the build contexts of
+// the targets, grouped by directory and excluded files, so that the targets
+// using the same context share its archive.
+func prepareFileSetTargets(dockerCli command.Cli, options buildOptions, fileSet *build.FileSet, names []string) ([]*fileSetTarget, []*sharedContext, error) {
+	var (
+		targets  []*fileSetTarget
+		contexts []*sharedContext
+		byKey    = map[string]*sharedContext{}
+	)
+	for _, name := range names {
+		target := fileSet.Targets[name]
+
+		contextDir, relDockerfile, err := build.GetContextFromLocalDir(target.Context, target.Dockerfile)
+		if err != nil {
+			return nil, contexts, errors.Errorf("target %s: unable to prepare context: %s", name, err)
+		}
+		excludes, err := build.ReadDockerignore(contextDir)
+		if err != nil {
+			return nil, contexts, errors.Wrapf(err, "target %s", name)
+		}
+		relDockerfile, err = archive.CanonicalTarNameForPath(relDockerfile)
+		if err != nil {
+			return nil, contexts, errors.Errorf("target %s: cannot canonicalize dockerfile path %s: %v", name, relDockerfile, err)
+		}
+		excludes = build.TrimBuildFilesFromExcludes(excludes, relDockerfile, false)
+
+		key := contextDir + "\x00" + strings.Join(excludes, "\x00")
+		shared, ok := byKey[key]
+		if !ok {
+			if err := build.ValidateContextDirectory(contextDir, excludes); err != nil {
+				return nil, contexts, errors.Errorf("target %s: error checking context: '%s'.", name, err)
+			}
+			shared = &sharedContext{contextDir: contextDir, excludes: excludes}
+			byKey[key] = shared
+			contexts = append(contexts, shared)
+		}
+		shared.targets++
+
+		targetOptions, err := newFileSetTargetOptions(options, target)
+		if err != nil {
+			return nil, contexts, errors.Wrapf(err, "target %s", name)
+		}
+
+		targets = append(targets, &fileSetTarget{
+			name:       name,
+			options:    targetOptions,
+			dockerfile: relDockerfile,
+			context:    shared,
+		})
+	}
+	return targets, contexts, nil
+}
+
+// newFileSetTargetOptions returns the options of the command line, with the
+// target stage, tags and build-time variables of a target. The build-time
+// variables of the command line override the ones of the target.
+func newFileSetTargetOptions(options buildOptions, target build.FileSetTarget) (buildOptions, error) {
+	targetOptions := options
+	targetOptions.quiet = false
+	targetOptions.target = target.Target
+
+	targetOptions.tags = opts.NewListOpts(validateTag)
+	for _, tag := range target.Tags {
+		if err := targetOptions.tags.Set(tag); err != nil {
+			return buildOptions{}, err
+		}
+	}
+
+	var args []string
+	for name, value := range target.Args {
+		args = append(args, name+"="+value)
+	}
+	sort.Strings(args)
+	targetOptions.buildArgs = opts.NewListOpts(opts.ValidateEnv)
+	for _, arg := range append(args, options.buildArgs.GetAll()...) {
+		if err := targetOptions.buildArgs.Set(arg); err != nil {
+			return buildOptions{}, err
+		}
+	}
+	return targetOptions, nil
+}
+
+// buildFileSetTarget builds a target of a build definition file, writing the
+// output of the build to out, and returns the ID of the image built.
+func buildFileSetTarget(dockerCli command.Cli, target *fileSetTarget, out io.Writer) (string, error) {
+	tarball, err := target.context.open()
+	if err != nil {
+		return "", errors.Errorf("unable to prepare context: %s", err)
+	}
+	defer tarball.Close()
+	buildCtx := tarball
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var resolvedTags []*resolvedTag
+	if command.IsTrusted() {
+		translator := func(ctx context.Context, ref reference.NamedTagged) (reference.Canonical, error) {
+			return TrustedReference(ctx, dockerCli, ref, nil)
+		}
+		buildCtx = replaceDockerfileTarWrapper(ctx, buildCtx, target.dockerfile, translator, &resolvedTags)
+	}
+
+	if target.options.compress {
+		if buildCtx, err = build.Compress(buildCtx); err != nil {
+			return "", err
+		}
+	}
+
+	// Progress bars of concurrent builds can't be displayed, so only the
+	// last update of the upload is written.
+	progressOutput := &lastProgressOutput{output: streamformatter.NewProgressOutput(out)}
+	body := progress.NewProgressReader(buildCtx, progressOutput, 0, "", "Sending build context to Docker daemon")
+
+	buildOptions := newImageBuildOptions(dockerCli, target.options, target.dockerfile)
+	response, err := dockerCli.Client().ImageBuild(ctx, body, buildOptions)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	imageID := ""
+	aux := func(auxJSON *json.RawMessage) {
+		var result types.BuildResult
+		if err := json.Unmarshal(*auxJSON, &result); err != nil {
+			fmt.Fprintf(out, "Failed to parse aux message: %s\n", err)
+		} else {
+			imageID = result.ID
+		}
+	}
+	if err := jsonmessage.DisplayJSONMessagesStream(response.Body, out, 0, false, aux); err != nil {
+		return "", err
+	}
+
+	if command.IsTrusted() {
+		for _, resolved := range resolvedTags {
+			if err := TagTrusted(ctx, dockerCli, resolved.digestRef, resolved.tagRef); err != nil {
+				return imageID, err
+			}
+		}
+	}
+	return imageID, nil
+}
+
+// prefixWriter writes complete lines to out, each line prefixed with prefix,
+// so that the output of builds running at the same time can be merged.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    bytes.Buffer
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := w.writeLine(w.buf.Next(i + 1)); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Flush writes the last line, if it is not terminated by a newline
+func (w *prefixWriter) Flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	return w.writeLine(w.buf.Next(w.buf.Len()))
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	// carriage returns are used to update progress in place, and would
+	// overwrite the prefix
+	line = bytes.Trim(line, "\r\n")
+	if i := bytes.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+	if len(line) == 0 {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := fmt.Fprintf(w.out, "%s%s\n", w.prefix, line)
+	return err
+}
@@ -0,0 +1,211 @@
+package image
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/cli/cli/command/image/build"
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/docker/api/types"
+	"github.com/gotestyourself/gotestyourself/fs"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+const fileSetDefinition = `
+targets:
+  api:
+    context: services
+    dockerfile: api.Dockerfile
+    target: release
+    args:
+      VERSION: "1.0"
+      COMMIT: abcdef
+    tags:
+      - example/api:1.0
+      - example/api:latest
+  web:
+    context: services
+    dockerfile: web.Dockerfile
+    tags: [example/web]
+  docs:
+    context: docs
+`
+
+func newFileSetDir(t *testing.T) *fs.Dir {
+	return fs.NewDir(t, "test-build-file-set",
+		fs.WithFile("build.yaml", fileSetDefinition),
+		fs.WithDir("services",
+			fs.WithFile("api.Dockerfile", "FROM golang:1.9\n"),
+			fs.WithFile("web.Dockerfile", "FROM nginx:1.13\n"),
+			fs.WithFile("main.go", "package main\n"),
+			fs.WithFile("README.md", "# services\n"),
+			fs.WithFile(".dockerignore", "*.md\n"),
+		),
+		fs.WithDir("docs",
+			fs.WithFile("Dockerfile", "FROM nginx:1.13\n"),
+		),
+	)
+}
+
+func TestRunFileSetBuild(t *testing.T) {
+	dir := newFileSetDir(t)
+	defer dir.Remove()
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]types.ImageBuildOptions{}
+		contexts = map[string][]string{}
+	)
+	fakeImageBuild := func(_ context.Context, buildContext io.Reader, options types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+		var files []string
+		tr := tar.NewReader(buildContext)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			files = append(files, hdr.Name)
+		}
+		sort.Strings(files)
+		io.Copy(ioutil.Discard, buildContext)
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests[options.Dockerfile] = options
+		contexts[options.Dockerfile] = files
+		id := fmt.Sprintf("sha256:%d%063d", len(requests), 0)
+		body := fmt.Sprintf(`{"stream":"Step 1/1 : FROM base\n"}{"aux":{"ID":"%s"}}{"stream":"Successfully built %s\n"}`, id, id[7:19])
+		return types.ImageBuildResponse{Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+	}
+	cli := test.NewFakeCli(&fakeClient{imageBuildFunc: fakeImageBuild})
+	cmd := NewBuildCommand(cli)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"--file-set", dir.Join("build.yaml"), "--parallel", "1", "--build-arg", "VERSION=2.0", "--no-cache"})
+	require.NoError(t, cmd.Execute())
+
+	assert.Len(t, requests, 3)
+	api := requests["api.Dockerfile"]
+	assert.Equal(t, []string{"example/api:1.0", "example/api:latest"}, api.Tags)
+	assert.Equal(t, "release", api.Target)
+	assert.Equal(t, "2.0", *api.BuildArgs["VERSION"])
+	assert.Equal(t, "abcdef", *api.BuildArgs["COMMIT"])
+	assert.True(t, api.NoCache)
+
+	assert.Equal(t, []string{".dockerignore", "api.Dockerfile", "main.go", "web.Dockerfile"}, contexts["api.Dockerfile"])
+	assert.Equal(t, contexts["api.Dockerfile"], contexts["web.Dockerfile"])
+	assert.Equal(t, []string{"Dockerfile"}, contexts["Dockerfile"])
+
+	golden.Assert(t, cli.OutBuffer().String(), "build-file-set.golden")
+}
+
+func TestPrepareFileSetTargetsSharesContexts(t *testing.T) {
+	dir := newFileSetDir(t)
+	defer dir.Remove()
+
+	fileSet, err := build.LoadFileSet(dir.Join("build.yaml"))
+	require.NoError(t, err)
+	options := newBuildOptions()
+	targets, contexts, err := prepareFileSetTargets(test.NewFakeCli(&fakeClient{}), options, fileSet, []string{"api", "docs", "web"})
+	require.NoError(t, err)
+
+	require.Len(t, contexts, 2)
+	assert.Equal(t, dir.Join("services"), contexts[0].contextDir)
+	assert.Equal(t, 2, contexts[0].targets)
+	assert.Equal(t, 1, contexts[1].targets)
+	assert.True(t, targets[0].context == targets[2].context)
+
+	for _, c := range contexts {
+		tarball, err := c.open()
+		require.NoError(t, err)
+		tarball.Close()
+	}
+	assert.NotEqual(t, "", contexts[0].path)
+	assert.Equal(t, "", contexts[1].path)
+	for _, c := range contexts {
+		c.remove()
+	}
+}
+
+func TestRunFileSetBuildFailure(t *testing.T) {
+	dir := newFileSetDir(t)
+	defer dir.Remove()
+
+	fakeImageBuild := func(_ context.Context, buildContext io.Reader, options types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+		io.Copy(ioutil.Discard, buildContext)
+		if options.Dockerfile == "web.Dockerfile" {
+			return types.ImageBuildResponse{}, errors.New("daemon is busy")
+		}
+		body := `{"errorDetail":{"message":"unknown instruction"},"error":"unknown instruction"}`
+		if options.Dockerfile == "Dockerfile" {
+			body = `{"aux":{"ID":"sha256:1234567890abcdef"}}`
+		}
+		return types.ImageBuildResponse{Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+	}
+	cli := test.NewFakeCli(&fakeClient{imageBuildFunc: fakeImageBuild})
+	cmd := NewBuildCommand(cli)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"--file-set", dir.Join("build.yaml"), "--quiet"})
+	assert.EqualError(t, cmd.Execute(), "Status: 2 of 3 targets failed to build: api, web, Code: 1")
+
+	assert.Contains(t, cli.ErrBuffer().String(), "api  | ERROR: unknown instruction\n")
+	assert.Contains(t, cli.ErrBuffer().String(), "web  | ERROR: daemon is busy\n")
+	assert.NotContains(t, cli.ErrBuffer().String(), "docs")
+	assert.Contains(t, cli.OutBuffer().String(), "docs     built    1234567890ab   -\n")
+}
+
+func TestRunFileSetBuildErrors(t *testing.T) {
+	dir := newFileSetDir(t)
+	defer dir.Remove()
+
+	testCases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          []string{},
+			expectedError: "requires exactly 1 argument.",
+		},
+		{
+			args:          []string{"--parallel", "2", "."},
+			expectedError: "--parallel can only be used with --file-set",
+		},
+		{
+			args:          []string{"--file-set", dir.Join("build.yaml"), "--parallel", "0"},
+			expectedError: "invalid --parallel value 0: must be at least 1",
+		},
+		{
+			args:          []string{"--file-set", dir.Join("build.yaml"), "-t", "example/app"},
+			expectedError: "--tag can't be used with --file-set",
+		},
+		{
+			args:          []string{"--file-set", dir.Join("build.yaml"), "--iidfile", "id.txt"},
+			expectedError: "--iidfile can't be used with --file-set",
+		},
+		{
+			args:          []string{"--file-set", dir.Join("build.yaml"), "api", "db"},
+			expectedError: "no such target: db",
+		},
+		{
+			args:          []string{"--file-set", dir.Join("missing.yaml")},
+			expectedError: "missing.yaml",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := NewBuildCommand(test.NewFakeCli(&fakeClient{}))
+		cmd.SetOutput(ioutil.Discard)
+		cmd.SetArgs(tc.args)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
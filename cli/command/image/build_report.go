@@ -0,0 +1,64 @@
+package image
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli/command/image/build"
+	"github.com/docker/docker/pkg/archive"
+	units "github.com/docker/go-units"
+)
+
+// contextReportLimit is the number of directories and files listed in the
+// build context report
+const contextReportLimit = 10
+
+// printContextReport prints the size of the build context, its largest
+// directories and files, and the paths matched by the .dockerignore file.
+func printContextReport(out io.Writer, contextDir, relDockerfile string, dockerfileFromStdin bool) error {
+	excludes, err := build.ReadDockerignore(contextDir)
+	if err != nil {
+		return err
+	}
+	relDockerfile, err = archive.CanonicalTarNameForPath(relDockerfile)
+	if err != nil {
+		return err
+	}
+	excludes = build.TrimBuildFilesFromExcludes(excludes, relDockerfile, dockerfileFromStdin)
+
+	report, err := build.NewContextReport(contextDir, excludes, contextReportLimit)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Build context:\t%s\n", contextDir)
+	fmt.Fprintf(w, "Files:\t%d (%s)\n", report.Files, units.HumanSizeWithPrecision(float64(report.Size), 3))
+	fmt.Fprintf(w, "Excluded paths:\t%d\n", report.Excluded)
+	fmt.Fprintf(w, "Compressed size:\t%s\n", units.HumanSizeWithPrecision(float64(report.CompressedSize), 3))
+	printContextEntries(w, "Largest directories:", report.LargestDirs)
+	printContextEntries(w, "Largest files:", report.LargestFiles)
+	if len(report.Matches) > 0 {
+		fmt.Fprintln(w, "\n.dockerignore matches:")
+		for _, match := range report.Matches {
+			action := "re-included"
+			if match.Excluded {
+				action = "excluded"
+			}
+			fmt.Fprintf(w, "  %s\t%s\tby %s\n", action, match.Path, match.Pattern)
+		}
+	}
+	fmt.Fprintln(w)
+	return w.Flush()
+}
+
+func printContextEntries(w io.Writer, title string, entries []build.ContextEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s\n", title)
+	for _, entry := range entries {
+		fmt.Fprintf(w, "  %s\t%s\n", units.HumanSizeWithPrecision(float64(entry.Size), 3), entry.Path)
+	}
+}
@@ -141,3 +141,42 @@ func TestRunBuildFromLocalGitHubDir(t *testing.T) {
 	err = cmd.Execute()
 	require.NoError(t, err)
 }
+
+func TestRunBuildContextReport(t *testing.T) {
+	dir := fs.NewDir(t, "test-build-context-report",
+		fs.WithFile("Dockerfile", "FROM busybox\n"),
+		fs.WithFile(".dockerignore", "*.log\n"),
+		fs.WithFile("app.bin", "binary content"),
+		fs.WithFile("debug.log", "log content"),
+	)
+	defer dir.Remove()
+
+	built := false
+	cli := test.NewFakeCli(&fakeClient{
+		imageBuildFunc: func(_ context.Context, _ io.Reader, _ types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+			built = true
+			return types.ImageBuildResponse{Body: ioutil.NopCloser(new(bytes.Buffer))}, nil
+		},
+	})
+	options := newBuildOptions()
+	options.context = dir.Path()
+	options.contextReport = true
+	require.NoError(t, runBuild(cli, options))
+	assert.True(t, built)
+
+	out := cli.OutBuffer().String()
+	assert.Contains(t, out, "Build context:    "+dir.Path()+"\n")
+	assert.Contains(t, out, "Files:            3 (33B)\n")
+	assert.Contains(t, out, "Excluded paths:   1\n")
+	assert.Contains(t, out, "Largest files:\n  14B  app.bin\n  13B  Dockerfile\n  6B   .dockerignore\n")
+	assert.Contains(t, out, ".dockerignore matches:\n  excluded  debug.log  by *.log\n")
+}
+
+func TestRunBuildContextReportRequiresDirectory(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetIn(command.NewInStream(ioutil.NopCloser(new(bytes.Buffer))))
+	options := newBuildOptions()
+	options.context = "-"
+	options.contextReport = true
+	assert.EqualError(t, runBuild(cli, options), "--context-report requires a build context directory or Git repository")
+}
@@ -15,19 +15,20 @@ import (
 
 type fakeClient struct {
 	client.Client
-	imageTagFunc     func(string, string) error
-	imageSaveFunc    func(images []string) (io.ReadCloser, error)
-	imageRemoveFunc  func(image string, options types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error)
-	imagePushFunc    func(ref string, options types.ImagePushOptions) (io.ReadCloser, error)
-	infoFunc         func() (types.Info, error)
-	imagePullFunc    func(ref string, options types.ImagePullOptions) (io.ReadCloser, error)
-	imagesPruneFunc  func(pruneFilter filters.Args) (types.ImagesPruneReport, error)
-	imageLoadFunc    func(input io.Reader, quiet bool) (types.ImageLoadResponse, error)
-	imageListFunc    func(options types.ImageListOptions) ([]types.ImageSummary, error)
-	imageInspectFunc func(image string) (types.ImageInspect, []byte, error)
-	imageImportFunc  func(source types.ImageImportSource, ref string, options types.ImageImportOptions) (io.ReadCloser, error)
-	imageHistoryFunc func(image string) ([]image.HistoryResponseItem, error)
-	imageBuildFunc   func(context.Context, io.Reader, types.ImageBuildOptions) (types.ImageBuildResponse, error)
+	imageTagFunc      func(string, string) error
+	imageSaveFunc     func(images []string) (io.ReadCloser, error)
+	imageRemoveFunc   func(image string, options types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error)
+	imagePushFunc     func(ref string, options types.ImagePushOptions) (io.ReadCloser, error)
+	infoFunc          func() (types.Info, error)
+	imagePullFunc     func(ref string, options types.ImagePullOptions) (io.ReadCloser, error)
+	imagesPruneFunc   func(pruneFilter filters.Args) (types.ImagesPruneReport, error)
+	imageLoadFunc     func(input io.Reader, quiet bool) (types.ImageLoadResponse, error)
+	imageListFunc     func(options types.ImageListOptions) ([]types.ImageSummary, error)
+	imageInspectFunc  func(image string) (types.ImageInspect, []byte, error)
+	imageImportFunc   func(source types.ImageImportSource, ref string, options types.ImageImportOptions) (io.ReadCloser, error)
+	imageHistoryFunc  func(image string) ([]image.HistoryResponseItem, error)
+	imageBuildFunc    func(context.Context, io.Reader, types.ImageBuildOptions) (types.ImageBuildResponse, error)
+	containerListFunc func(options types.ContainerListOptions) ([]types.Container, error)
 }
 
 func (cli *fakeClient) ImageTag(_ context.Context, image, ref string) error {
@@ -68,7 +69,7 @@ func (cli *fakeClient) Info(_ context.Context) (types.Info, error) {
 
 func (cli *fakeClient) ImagePull(_ context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error) {
 	if cli.imagePullFunc != nil {
-		cli.imagePullFunc(ref, options)
+		return cli.imagePullFunc(ref, options)
 	}
 	return ioutil.NopCloser(strings.NewReader("")), nil
 }
@@ -122,3 +123,10 @@ func (cli *fakeClient) ImageBuild(ctx context.Context, context io.Reader, option
 	}
 	return types.ImageBuildResponse{Body: ioutil.NopCloser(strings.NewReader(""))}, nil
 }
+
+func (cli *fakeClient) ContainerList(_ context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+	if cli.containerListFunc != nil {
+		return cli.containerListFunc(options)
+	}
+	return []types.Container{}, nil
+}
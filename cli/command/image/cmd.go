@@ -28,6 +28,9 @@ func NewImageCommand(dockerCli command.Cli) *cobra.Command {
 		newRemoveCommand(dockerCli),
 		newInspectCommand(dockerCli),
 		NewPruneCommand(dockerCli),
+		newTreeCommand(dockerCli),
+		newArchiveCommand(dockerCli),
+		newDiffCommand(dockerCli),
 	)
 	return cmd
 }
@@ -0,0 +1,362 @@
+package image
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types/container"
+	units "github.com/docker/go-units"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+type diffOptions struct {
+	from string
+	to   string
+}
+
+// fsEntry is a file of an image filesystem
+type fsEntry struct {
+	typeflag byte
+	mode     int64
+	size     int64
+	linkname string
+	digest   string
+}
+
+// layerChanges are the changes a layer makes to the filesystem of the
+// layers below it
+type layerChanges struct {
+	entries   map[string]fsEntry
+	whiteouts []string
+	opaque    []string
+}
+
+// imageContent is the filesystem and the configuration of an image
+type imageContent struct {
+	files  map[string]fsEntry
+	config *container.Config
+}
+
+func newDiffCommand(dockerCli command.Cli) *cobra.Command {
+	var opts diffOptions
+
+	cmd := &cobra.Command{
+		Use:   "diff IMAGE1 IMAGE2",
+		Short: "Show the changes to the filesystem and configuration between two images",
+		Args:  cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.from, opts.to = args[0], args[1]
+			return runDiff(dockerCli, opts)
+		},
+	}
+	return cmd
+}
+
+func runDiff(dockerCli command.Cli, opts diffOptions) error {
+	ctx := context.Background()
+
+	from, err := loadImageContent(ctx, dockerCli, opts.from)
+	if err != nil {
+		return err
+	}
+	to, err := loadImageContent(ctx, dockerCli, opts.to)
+	if err != nil {
+		return err
+	}
+
+	out := dockerCli.Out()
+	fmt.Fprintln(out, "FILES")
+	if err := printFileChanges(out, from.files, to.files); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "\nCONFIG")
+	changes := diffImageConfig(from.config, to.config)
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "No changes")
+	}
+	for _, change := range changes {
+		fmt.Fprintln(out, change)
+	}
+	return nil
+}
+
+// loadImageContent reads the filesystem and the configuration of an image
+// from the archive created by saving it.
+func loadImageContent(ctx context.Context, dockerCli command.Cli, ref string) (*imageContent, error) {
+	client := dockerCli.Client()
+
+	inspect, _, err := client.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	body, err := client.ImageSave(ctx, []string{inspect.ID})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	layers := map[string]*layerChanges{}
+	images, err := scanImageArchive(body, func(name string, r io.Reader) error {
+		changes, err := readLayerChanges(r)
+		layers[name] = changes
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read image %s", ref)
+	}
+	if len(images) != 1 {
+		return nil, errors.Errorf("failed to read image %s: expected 1 image in the archive, found %d", ref, len(images))
+	}
+
+	content := &imageContent{files: map[string]fsEntry{}}
+	for _, layer := range images[0].Layers {
+		applyLayerChanges(content.files, layers[layer.file])
+	}
+
+	var config struct {
+		Config *container.Config `json:"config"`
+	}
+	if err := json.Unmarshal(images[0].config, &config); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the configuration of image %s", ref)
+	}
+	content.config = config.Config
+	if content.config == nil {
+		content.config = &container.Config{}
+	}
+	return content, nil
+}
+
+// readLayerChanges reads the files and the whiteouts of a layer
+func readLayerChanges(r io.Reader) (*layerChanges, error) {
+	changes := &layerChanges{entries: map[string]fsEntry{}}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean("/" + hdr.Name)
+		if name == "/" {
+			continue
+		}
+		dir, base := path.Split(name)
+		switch {
+		case base == whiteoutOpaque:
+			changes.opaque = append(changes.opaque, path.Clean(dir))
+			continue
+		case strings.HasPrefix(base, whiteoutPrefix):
+			changes.whiteouts = append(changes.whiteouts, path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+			continue
+		}
+
+		entry := fsEntry{
+			typeflag: hdr.Typeflag,
+			mode:     hdr.Mode,
+			linkname: hdr.Linkname,
+		}
+		if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA {
+			h := sha256.New()
+			size, err := io.Copy(h, tr)
+			if err != nil {
+				return nil, err
+			}
+			entry.typeflag = tar.TypeReg
+			entry.size = size
+			entry.digest = hex.EncodeToString(h.Sum(nil))
+		}
+		changes.entries[name] = entry
+	}
+	return changes, nil
+}
+
+// applyLayerChanges applies the changes of a layer to a filesystem
+func applyLayerChanges(files map[string]fsEntry, changes *layerChanges) {
+	if changes == nil {
+		return
+	}
+	removeTree := func(name string, keepRoot bool) {
+		if !keepRoot {
+			delete(files, name)
+		}
+		prefix := strings.TrimSuffix(name, "/") + "/"
+		for file := range files {
+			if strings.HasPrefix(file, prefix) {
+				delete(files, file)
+			}
+		}
+	}
+	for _, dir := range changes.opaque {
+		removeTree(dir, true)
+	}
+	for _, name := range changes.whiteouts {
+		removeTree(name, false)
+	}
+	for name, entry := range changes.entries {
+		files[name] = entry
+	}
+}
+
+// printFileChanges prints the files added (A), changed (C) and deleted (D)
+// between two filesystems, in the same way as `docker diff`.
+func printFileChanges(out io.Writer, from, to map[string]fsEntry) error {
+	var names []string
+	for name := range from {
+		names = append(names, name)
+	}
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var added, changed, deleted int
+	var addedSize, deletedSize int64
+	w := tabwriter.NewWriter(out, 0, 1, 3, ' ', 0)
+	for _, name := range names {
+		before, inFrom := from[name]
+		after, inTo := to[name]
+		switch {
+		case !inFrom:
+			added++
+			addedSize += after.size
+			fmt.Fprintf(w, "A\t%s\t%s\n", name, fileSize(after))
+		case !inTo:
+			deleted++
+			deletedSize += before.size
+			fmt.Fprintf(w, "D\t%s\t%s\n", name, fileSize(before))
+		case before != after:
+			changed++
+			size := fileSize(after)
+			if before.size != after.size {
+				size = fileSize(before) + " -> " + size
+			}
+			fmt.Fprintf(w, "C\t%s\t%s\n", name, size)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\n%d added (%s), %d changed, %d deleted (%s)\n",
+		added, units.HumanSizeWithPrecision(float64(addedSize), 3), changed,
+		deleted, units.HumanSizeWithPrecision(float64(deletedSize), 3))
+	return nil
+}
+
+func fileSize(entry fsEntry) string {
+	if entry.typeflag != tar.TypeReg {
+		return ""
+	}
+	return units.HumanSizeWithPrecision(float64(entry.size), 3)
+}
+
+// diffImageConfig returns the changes to the settings of an image that
+// matter when running it, as lines prefixed with - or +.
+func diffImageConfig(from, to *container.Config) []string {
+	var changes []string
+	diffValue := func(field, before, after string) {
+		if before == after {
+			return
+		}
+		if before != "" {
+			changes = append(changes, fmt.Sprintf("- %s: %s", field, before))
+		}
+		if after != "" {
+			changes = append(changes, fmt.Sprintf("+ %s: %s", field, after))
+		}
+	}
+	diffMap := func(field string, before, after map[string]string, withValues bool) {
+		var keys []string
+		for key := range before {
+			keys = append(keys, key)
+		}
+		for key := range after {
+			if _, ok := before[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		render := func(values map[string]string, key string) string {
+			value, ok := values[key]
+			switch {
+			case !ok:
+				return ""
+			case withValues:
+				return key + "=" + value
+			default:
+				return key
+			}
+		}
+		for _, key := range keys {
+			diffValue(field, render(before, key), render(after, key))
+		}
+	}
+
+	diffValue("User", from.User, to.User)
+	diffValue("WorkingDir", from.WorkingDir, to.WorkingDir)
+	diffValue("Entrypoint", jsonList(from.Entrypoint), jsonList(to.Entrypoint))
+	diffValue("Cmd", jsonList(from.Cmd), jsonList(to.Cmd))
+	diffMap("Env", envMap(from.Env), envMap(to.Env), true)
+	diffMap("Label", from.Labels, to.Labels, true)
+
+	fromPorts, toPorts := map[string]string{}, map[string]string{}
+	for port := range from.ExposedPorts {
+		fromPorts[string(port)] = ""
+	}
+	for port := range to.ExposedPorts {
+		toPorts[string(port)] = ""
+	}
+	diffMap("ExposedPort", fromPorts, toPorts, false)
+
+	fromVolumes, toVolumes := map[string]string{}, map[string]string{}
+	for volume := range from.Volumes {
+		fromVolumes[volume] = ""
+	}
+	for volume := range to.Volumes {
+		toVolumes[volume] = ""
+	}
+	diffMap("Volume", fromVolumes, toVolumes, false)
+	return changes
+}
+
+func jsonList(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+func envMap(env []string) map[string]string {
+	values := map[string]string{}
+	for _, value := range env {
+		kv := strings.SplitN(value, "=", 2)
+		if len(kv) == 2 {
+			values[kv[0]] = kv[1]
+		} else {
+			values[kv[0]] = ""
+		}
+	}
+	return values
+}
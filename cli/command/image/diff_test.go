@@ -0,0 +1,129 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func diffImageArchive(t *testing.T, config string, layers ...[]byte) []byte {
+	var entries []archiveEntry
+	var diffIDs, paths []string
+	for i, layer := range layers {
+		dir := fmt.Sprintf("%d000", i+1)
+		entries = append(entries, archiveEntry{name: dir + "/layer.tar", content: string(layer)})
+		diffIDs = append(diffIDs, `"sha256:`+dir+`"`)
+		paths = append(paths, `"`+dir+`/layer.tar"`)
+	}
+	entries = append(entries,
+		archiveEntry{name: "abcd.json", content: `{"config":` + config + `,"rootfs":{"type":"layers","diff_ids":[` + strings.Join(diffIDs, ",") + `]}}`},
+		archiveEntry{name: "manifest.json", content: `[{"Config":"abcd.json","Layers":[` + strings.Join(paths, ",") + `]}]`},
+	)
+	return makeImageArchive(t, entries...)
+}
+
+func TestNewDiffCommand(t *testing.T) {
+	base := makeImageArchive(t,
+		archiveEntry{name: "bin/sh", content: "shell"},
+		archiveEntry{name: "etc/os-release", content: "v1"},
+		archiveEntry{name: "usr/lib/old/libold.so", content: "oldlib"},
+		archiveEntry{name: "usr/lib/keep.so", content: "keep"},
+	)
+	archives := map[string][]byte{
+		"old": diffImageArchive(t, `{"User":"root","Env":["PATH=/bin","FOO=1"],"Cmd":["sh"],"ExposedPorts":{"80/tcp":{}}}`, base),
+		"new": diffImageArchive(t, `{"User":"app","Env":["PATH=/bin","FOO=2","BAR=1"],"Cmd":["bash"],"ExposedPorts":{"80/tcp":{},"443/tcp":{}},"Labels":{"team":"infra"}}`,
+			base,
+			makeImageArchive(t,
+				archiveEntry{name: "bin/.wh..wh..opq"},
+				archiveEntry{name: "bin/bash", content: "bourne again shell"},
+				archiveEntry{name: "etc/os-release", content: "v2-release"},
+				archiveEntry{name: "usr/lib/.wh.old"},
+				archiveEntry{name: "usr/bin/new", content: "new binary"},
+			),
+		),
+	}
+
+	cli := test.NewFakeCli(&fakeClient{
+		imageInspectFunc: func(image string) (types.ImageInspect, []byte, error) {
+			return types.ImageInspect{ID: image}, nil, nil
+		},
+		imageSaveFunc: func(images []string) (io.ReadCloser, error) {
+			require.Len(t, images, 1)
+			return ioutil.NopCloser(bytes.NewReader(archives[images[0]])), nil
+		},
+	})
+	cmd := newDiffCommand(cli)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"old", "new"})
+	require.NoError(t, cmd.Execute())
+	golden.Assert(t, cli.OutBuffer().String(), "diff-command-success.golden")
+}
+
+func TestNewDiffCommandErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		args          []string
+		expectedError string
+		client        *fakeClient
+	}{
+		{
+			name:          "wrong-args",
+			args:          []string{"image"},
+			expectedError: "requires exactly 2 arguments.",
+			client:        &fakeClient{},
+		},
+		{
+			name:          "inspect-error",
+			args:          []string{"old", "new"},
+			expectedError: "no such image",
+			client: &fakeClient{
+				imageInspectFunc: func(image string) (types.ImageInspect, []byte, error) {
+					return types.ImageInspect{}, nil, errors.Errorf("no such image")
+				},
+			},
+		},
+		{
+			name:          "invalid-archive",
+			args:          []string{"old", "new"},
+			expectedError: "failed to read image old: manifest.json not found",
+			client:        &fakeClient{},
+		},
+	}
+	for _, tc := range testCases {
+		cmd := newDiffCommand(test.NewFakeCli(tc.client))
+		cmd.SetOutput(ioutil.Discard)
+		cmd.SetArgs(tc.args)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
+
+func TestDiffImageConfig(t *testing.T) {
+	from := &container.Config{
+		Entrypoint: []string{"/entrypoint.sh"},
+		Volumes:    map[string]struct{}{"/data": {}},
+	}
+	to := &container.Config{
+		WorkingDir:   "/app",
+		ExposedPorts: nat.PortSet{"8080/tcp": {}},
+	}
+	assert.Equal(t, []string{
+		"+ WorkingDir: /app",
+		`- Entrypoint: ["/entrypoint.sh"]`,
+		"+ ExposedPort: 8080/tcp",
+		"- Volume: /data",
+	}, diffImageConfig(from, to))
+	assert.Empty(t, diffImageConfig(to, to))
+}
@@ -0,0 +1,100 @@
+package image
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/formatter"
+	"github.com/docker/cli/cli/command/image/build"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type lintOptions struct {
+	context        string
+	dockerfileName string
+	target         string
+	format         string
+}
+
+// NewLintCommand creates a new `docker builder lint` command
+func NewLintCommand(dockerCli command.Cli) *cobra.Command {
+	var opts lintOptions
+
+	cmd := &cobra.Command{
+		Use:   "lint [OPTIONS] PATH | -",
+		Short: "Check a Dockerfile for common problems without building it",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.context = args[0]
+			return runLint(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.dockerfileName, "file", "f", "", "Name of the Dockerfile (Default is 'PATH/Dockerfile')")
+	flags.StringVar(&opts.target, "target", "", "Check the Dockerfile for building the given stage")
+	flags.StringVar(&opts.format, "format", "", "Pretty-print problems using a Go template")
+	return cmd
+}
+
+// runLint checks the Dockerfile of a local build context, or of a build
+// context read from stdin, and prints the problems found. It fails if
+// problems are found, so that it can be used in scripts.
+func runLint(dockerCli command.Cli, opts lintOptions) error {
+	var (
+		dockerfile io.Reader
+		excludes   []string
+	)
+	switch {
+	case opts.context == "-":
+		var err error
+		if dockerfile, excludes, err = build.GetDockerfileFromReader(dockerCli.In(), opts.dockerfileName); err != nil {
+			return err
+		}
+	case isLocalDir(opts.context):
+		contextDir, relDockerfile, err := build.GetContextFromLocalDir(opts.context, opts.dockerfileName)
+		if err != nil {
+			return errors.Errorf("unable to prepare context: %s", err)
+		}
+		if excludes, err = build.ReadDockerignore(contextDir); err != nil {
+			return err
+		}
+		if !filepath.IsAbs(relDockerfile) {
+			relDockerfile = filepath.Join(contextDir, relDockerfile)
+		}
+		f, err := os.Open(relDockerfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		dockerfile = f
+	default:
+		return errors.Errorf("unable to check %q: the build context must be a local directory, or - to read the Dockerfile from stdin", opts.context)
+	}
+
+	problems, err := build.LintDockerfile(dockerfile, excludes, opts.target)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the Dockerfile")
+	}
+
+	format := opts.format
+	if len(format) == 0 {
+		format = formatter.TableFormatKey
+	}
+	lintCtx := formatter.Context{
+		Output: dockerCli.Out(),
+		Format: formatter.NewLintFormat(format),
+	}
+	if err := formatter.LintWrite(lintCtx, problems); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return cli.StatusError{StatusCode: 1, Status: fmt.Sprintf("%d problem(s) found in the Dockerfile", len(problems))}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package image
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const lintDockerfile = `FROM golang:1.9 AS build
+COPY . /go/src/app
+RUN go build -o /app app
+
+FROM node AS assets
+
+FROM debian:stretch
+RUN apt-get update && apt-get install -y ca-certificates
+COPY --from=build /app /app
+COPY config.local.yml /etc/app/
+`
+
+func TestNewLintCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "builder-lint")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(lintDockerfile), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".dockerignore"), []byte("*.local.yml\n"), 0644))
+
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{name: "table", args: []string{dir}},
+		{name: "format", args: []string{"--format", "{{.Line}}:{{.Rule}}", dir}},
+	}
+	for _, tc := range testCases {
+		cli := test.NewFakeCli(&fakeClient{})
+		cmd := NewLintCommand(cli)
+		cmd.SetOutput(ioutil.Discard)
+		cmd.SetArgs(tc.args)
+		assert.EqualError(t, cmd.Execute(), "Status: 5 problem(s) found in the Dockerfile, Code: 1")
+		golden.Assert(t, cli.OutBuffer().String(), "lint-command."+tc.name+".golden")
+	}
+}
+
+func TestBuildCheck(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetIn(command.NewInStream(ioutil.NopCloser(strings.NewReader("FROM alpine:3.6\nUSER nobody\n"))))
+	cmd := NewBuildCommand(cli)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"--check", "-"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "LINE                RULE                MESSAGE\n", cli.OutBuffer().String())
+}
+
+func TestBuildCheckTargetAndFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "build-check")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dockerfile := "FROM alpine:3.6 AS dev\nUSER nobody\nFROM dev AS prod\nUSER root\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644))
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cmd := NewBuildCommand(cli)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"--check", "--target", "dev", "--format", "{{.Line}}:{{.Rule}}", dir})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "", cli.OutBuffer().String())
+
+	cli = test.NewFakeCli(&fakeClient{})
+	cmd = NewBuildCommand(cli)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"--check", "--format", "{{.Line}}:{{.Rule}}", dir})
+	assert.EqualError(t, cmd.Execute(), "Status: 1 problem(s) found in the Dockerfile, Code: 1")
+	assert.Equal(t, "4:missing-user\n", cli.OutBuffer().String())
+}
+
+func TestBuildCheckStdinArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "build-check")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(lintDockerfile), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".dockerignore"), []byte("*.local.yml\n"), 0644))
+	buildCtx, err := archive.Tar(dir, archive.Uncompressed)
+	require.NoError(t, err)
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetIn(command.NewInStream(buildCtx))
+	cmd := NewBuildCommand(cli)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"--check", "-"})
+	assert.EqualError(t, cmd.Execute(), "Status: 5 problem(s) found in the Dockerfile, Code: 1")
+	golden.Assert(t, cli.OutBuffer().String(), "lint-command.table.golden")
+}
+
+func TestBuildFormatWithoutCheck(t *testing.T) {
+	cmd := NewBuildCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"--format", "{{.Rule}}", "."})
+	assert.EqualError(t, cmd.Execute(), "--format can only be used with --check")
+}
+
+func TestNewLintCommandErrors(t *testing.T) {
+	testCases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          []string{},
+			expectedError: "requires exactly 1 argument.",
+		},
+		{
+			args:          []string{"https://github.com/docker/cli.git"},
+			expectedError: "the build context must be a local directory",
+		},
+		{
+			args:          []string{"-f", "Dockerfile", "-"},
+			expectedError: "can't use a Dockerfile with a build context read from stdin",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := NewLintCommand(test.NewFakeCli(&fakeClient{}))
+		cmd.SetOutput(ioutil.Discard)
+		cmd.SetArgs(tc.args)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
@@ -1,12 +1,21 @@
 package image
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/registry"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -14,27 +23,44 @@ import (
 )
 
 type pullOptions struct {
-	remote string
-	all    bool
+	remote      string
+	all         bool
+	file        string
+	parallelism int
 }
 
+// defaultPullParallelism is the number of images pulled at the same time,
+// which matches the default number of concurrent downloads of the daemon
+const defaultPullParallelism = 3
+
 // NewPullCommand creates a new `docker pull` command
 func NewPullCommand(dockerCli command.Cli) *cobra.Command {
 	var opts pullOptions
 
 	cmd := &cobra.Command{
-		Use:   "pull [OPTIONS] NAME[:TAG|@DIGEST]",
-		Short: "Pull an image or a repository from a registry",
-		Args:  cli.ExactArgs(1),
+		Use:   "pull [OPTIONS] NAME[:TAG|@DIGEST] [NAME[:TAG|@DIGEST]...]",
+		Short: "Pull one or more images or repositories from a registry",
+		Args: func(cmd *cobra.Command, args []string) error {
+			// the images can be read from a file
+			if opts.file != "" {
+				return nil
+			}
+			return cli.RequiresMinArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			opts.remote = args[0]
-			return runPull(dockerCli, opts)
+			if opts.file == "" && len(args) == 1 {
+				opts.remote = args[0]
+				return runPull(dockerCli, opts)
+			}
+			return runPullMultiple(dockerCli, opts, args)
 		},
 	}
 
 	flags := cmd.Flags()
 
 	flags.BoolVarP(&opts.all, "all-tags", "a", false, "Download all tagged images in the repository")
+	flags.StringVarP(&opts.file, "file", "f", "", "Read the images to pull from a file, one per line ('-' to read from stdin)")
+	flags.IntVar(&opts.parallelism, "parallel", defaultPullParallelism, "Number of images to pull at the same time")
 	command.AddTrustVerificationFlags(flags)
 
 	return cmd
@@ -83,3 +109,157 @@ func runPull(dockerCli command.Cli, opts pullOptions) error {
 
 	return nil
 }
+
+// runPullMultiple pulls several images, parallelism at a time. The output of
+// the pull of each image is displayed on a single line, that shows its last
+// message. The images that fail to pull are listed at the end.
+func runPullMultiple(dockerCli command.Cli, opts pullOptions, remotes []string) error {
+	if opts.parallelism < 1 {
+		return errors.New("--parallel must be at least 1")
+	}
+	if opts.file != "" {
+		fromFile, err := readImageList(dockerCli, opts.file)
+		if err != nil {
+			return err
+		}
+		remotes = append(remotes, fromFile...)
+	}
+	remotes = uniqueRemotes(remotes)
+	if len(remotes) == 0 {
+		return errors.New("no image to pull")
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	progress := &pullProgress{enc: json.NewEncoder(pipeWriter)}
+	errChan := make(chan error, 1)
+	var failed int32
+
+	go func() {
+		err := command.RunBatchWithParallelism(context.Background(), remotes, opts.parallelism, func(ctx context.Context, remote string) error {
+			out := progress.writer(remote)
+			imageOpts := opts
+			imageOpts.remote = remote
+			err := runPull(&pullCli{Cli: dockerCli, out: command.NewOutStream(out)}, imageOpts)
+			out.Flush()
+			if err != nil {
+				atomic.AddInt32(&failed, 1)
+				progress.send(remote, "Error: "+err.Error())
+				return err
+			}
+			progress.send(remote, "Done")
+			return nil
+		})
+		pipeWriter.Close()
+		errChan <- err
+	}()
+
+	if err := jsonmessage.DisplayJSONMessagesToStream(pipeReader, dockerCli.Out(), nil); err != nil {
+		// keep the pulls going if the output fails
+		go io.Copy(ioutil.Discard, pipeReader)
+		return err
+	}
+	if err := <-errChan; err != nil {
+		return errors.Errorf("failed to pull %d of %d images:\n%s", atomic.LoadInt32(&failed), len(remotes), err)
+	}
+	return nil
+}
+
+// readImageList reads the images of a file, one per line. Empty lines and
+// lines starting with # are ignored.
+func readImageList(dockerCli command.Cli, filename string) ([]string, error) {
+	var in io.Reader = dockerCli.In()
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+	var remotes []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		remotes = append(remotes, line)
+	}
+	return remotes, scanner.Err()
+}
+
+func uniqueRemotes(remotes []string) []string {
+	seen := map[string]struct{}{}
+	var unique []string
+	for _, remote := range remotes {
+		if _, ok := seen[remote]; ok {
+			continue
+		}
+		seen[remote] = struct{}{}
+		unique = append(unique, remote)
+	}
+	return unique
+}
+
+// pullCli is a command.Cli with the output of the pull of a single image
+type pullCli struct {
+	command.Cli
+	out *command.OutStream
+}
+
+func (c *pullCli) Out() *command.OutStream {
+	return c.out
+}
+
+// pullProgress is a stream of JSON messages, with a progress line per image
+type pullProgress struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// send sends a message on the progress line of an image. The progress of the
+// message is empty, so that terminals update the line of the image instead
+// of adding a new line.
+func (p *pullProgress) send(remote, status string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.enc.Encode(jsonmessage.JSONMessage{
+		ID:       remote,
+		Status:   status,
+		Progress: &jsonmessage.JSONProgress{},
+	})
+}
+
+// writer returns a writer sending each line written to it on the progress
+// line of an image
+func (p *pullProgress) writer(remote string) *pullLineWriter {
+	return &pullLineWriter{progress: p, remote: remote}
+}
+
+type pullLineWriter struct {
+	progress *pullProgress
+	remote   string
+	buf      bytes.Buffer
+}
+
+func (w *pullLineWriter) Write(data []byte) (int, error) {
+	w.buf.Write(data)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(data), nil
+		}
+		line := string(w.buf.Next(i + 1))
+		if line = strings.TrimSpace(line); line != "" {
+			w.progress.send(w.remote, line)
+		}
+	}
+}
+
+// Flush sends the last line written, if it does not end with a newline
+func (w *pullLineWriter) Flush() {
+	if line := strings.TrimSpace(w.buf.String()); line != "" {
+		w.progress.send(w.remote, line)
+	}
+	w.buf.Reset()
+}
@@ -2,12 +2,17 @@ package image
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
+	"strings"
 	"testing"
 
+	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/internal/test"
 	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/docker/api/types"
 	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,7 +24,7 @@ func TestNewPullCommandErrors(t *testing.T) {
 	}{
 		{
 			name:          "wrong-args",
-			expectedError: "requires exactly 1 argument.",
+			expectedError: "requires at least 1 argument.",
 			args:          []string{},
 		},
 		{
@@ -71,3 +76,46 @@ func TestNewPullCommandSuccess(t *testing.T) {
 		golden.Assert(t, cli.OutBuffer().String(), fmt.Sprintf("pull-command-success.%s.golden", tc.name))
 	}
 }
+
+func TestNewPullCommandMultiple(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		imagePullFunc: func(ref string, options types.ImagePullOptions) (io.ReadCloser, error) {
+			if ref == "broken:latest" {
+				return nil, errors.New("manifest for broken:latest not found")
+			}
+			return ioutil.NopCloser(strings.NewReader(`{"status":"Pulling from library/` + ref + `"}
+{"status":"Pull complete","id":"a1b2c3"}
+`)), nil
+		},
+	})
+	cli.SetIn(command.NewInStream(ioutil.NopCloser(strings.NewReader("# base images\nbusybox\n\nalpine:3.6\nbroken\n"))))
+	cmd := NewPullCommand(cli)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"--parallel", "1", "-f", "-", "alpine:3.6"})
+	err := cmd.Execute()
+	assert.EqualError(t, err, "failed to pull 1 of 3 images:\nbroken: manifest for broken:latest not found")
+	golden.Assert(t, cli.OutBuffer().String(), "pull-command-multiple.golden")
+}
+
+func TestNewPullCommandMultipleErrors(t *testing.T) {
+	testCases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          []string{"--parallel", "0", "alpine", "busybox"},
+			expectedError: "--parallel must be at least 1",
+		},
+		{
+			args:          []string{"-f", "/nonexistent/images.txt"},
+			expectedError: "no such file or directory",
+		},
+	}
+	for _, tc := range testCases {
+		cli := test.NewFakeCli(&fakeClient{})
+		cmd := NewPullCommand(cli)
+		cmd.SetOutput(ioutil.Discard)
+		cmd.SetArgs(tc.args)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
@@ -2,19 +2,22 @@ package image
 
 import (
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/pkg/ioutils"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/context"
 )
 
 type saveOptions struct {
-	images []string
-	output string
+	images      []string
+	output      string
+	excludeFrom string
 }
 
 // NewSaveCommand creates a new `docker save` command
@@ -34,6 +37,7 @@ func NewSaveCommand(dockerCli command.Cli) *cobra.Command {
 	flags := cmd.Flags()
 
 	flags.StringVarP(&opts.output, "output", "o", "", "Write to a file, instead of STDOUT")
+	flags.StringVar(&opts.excludeFrom, "exclude-layers-from", "", "Leave out the layers stored in this image archive")
 
 	return cmd
 }
@@ -47,18 +51,79 @@ func runSave(dockerCli command.Cli, opts saveOptions) error {
 		return errors.Wrap(err, "failed to save image")
 	}
 
+	var exclude map[string]bool
+	if opts.excludeFrom != "" {
+		base, err := openImageArchive(opts.excludeFrom)
+		if err != nil {
+			return errors.Wrap(err, "failed to save image")
+		}
+		exclude = map[string]bool{}
+		for _, img := range base {
+			for _, layer := range img.Layers {
+				exclude[layer.DiffID] = true
+			}
+		}
+	}
+
 	responseBody, err := dockerCli.Client().ImageSave(context.Background(), opts.images)
 	if err != nil {
 		return err
 	}
 	defer responseBody.Close()
 
+	var archive io.Reader = responseBody
+	if exclude != nil {
+		delta, err := excludeLayers(responseBody, exclude)
+		if err != nil {
+			return errors.Wrap(err, "failed to save image")
+		}
+		defer delta.Close()
+		archive = delta
+	}
+
 	if opts.output == "" {
-		_, err := io.Copy(dockerCli.Out(), responseBody)
+		_, err := io.Copy(dockerCli.Out(), archive)
 		return err
 	}
 
-	return command.CopyToFile(opts.output, responseBody)
+	return command.CopyToFile(opts.output, archive)
+}
+
+// excludeLayers returns the archive created by `docker save` without the
+// layers in the exclude set. The archive is stored in a temporary file, as
+// the manifest that maps the layers to their directories comes last.
+func excludeLayers(archive io.Reader, exclude map[string]bool) (io.ReadCloser, error) {
+	tmpFile, err := ioutil.TempFile("", "docker-save-")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+	}
+
+	images, err := readImageArchive(io.TeeReader(archive, tmpFile))
+	if err == nil {
+		// read the padding after the end of the archive
+		_, err = io.Copy(tmpFile, archive)
+	}
+	if err == nil {
+		_, err = tmpFile.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(filterImageArchive(tmpFile, pw, images, exclude))
+	}()
+	return ioutils.NewReadCloserWrapper(pr, func() error {
+		pr.Close()
+		cleanup()
+		return nil
+	}), nil
 }
 
 func validateOutputPath(path string) error {
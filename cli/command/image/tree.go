@@ -0,0 +1,248 @@
+package image
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/formatter"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+type treeOptions struct {
+	all     bool
+	quiet   bool
+	noTrunc bool
+	format  string
+}
+
+// treeImage is an image with the layers it is made of
+type treeImage struct {
+	node   *formatter.ImageTreeNode
+	parent string
+	layers []string
+	sizes  []int64
+}
+
+func newTreeCommand(dockerCli command.Cli) *cobra.Command {
+	var opts treeOptions
+
+	cmd := &cobra.Command{
+		Use:   "tree [OPTIONS]",
+		Short: "Show how layers are shared between images",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTree(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&opts.all, "all", "a", false, "Show all images (default hides intermediate images)")
+	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Only show numeric IDs")
+	flags.BoolVar(&opts.noTrunc, "no-trunc", false, "Don't truncate output")
+	flags.StringVar(&opts.format, "format", "", "Pretty-print images using a Go template")
+
+	return cmd
+}
+
+func runTree(dockerCli command.Cli, opts treeOptions) error {
+	ctx := context.Background()
+
+	images, err := loadTreeImages(ctx, dockerCli, opts.all)
+	if err != nil {
+		return err
+	}
+
+	format := opts.format
+	if len(format) == 0 {
+		format = formatter.TableFormatKey
+	}
+	treeCtx := formatter.Context{
+		Output: dockerCli.Out(),
+		Format: formatter.NewImageTreeFormat(format, opts.quiet),
+		Trunc:  !opts.noTrunc,
+	}
+	return formatter.ImageTreeWrite(treeCtx, buildImageTree(images))
+}
+
+// loadTreeImages lists the images along with their layers, and counts the
+// containers using each of them.
+func loadTreeImages(ctx context.Context, dockerCli command.Cli, all bool) ([]*treeImage, error) {
+	client := dockerCli.Client()
+
+	summaries, err := client.ImageList(ctx, types.ImageListOptions{All: all})
+	if err != nil {
+		return nil, err
+	}
+	containers, err := client.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	inUse := map[string]int{}
+	for _, c := range containers {
+		inUse[c.ImageID]++
+	}
+
+	images := make([]*treeImage, 0, len(summaries))
+	for _, summary := range summaries {
+		inspect, _, err := client.ImageInspectWithRaw(ctx, summary.ID)
+		if err != nil {
+			return nil, err
+		}
+		history, err := client.ImageHistory(ctx, summary.ID)
+		if err != nil {
+			return nil, err
+		}
+		img := &treeImage{
+			node: &formatter.ImageTreeNode{
+				ID:         summary.ID,
+				RepoTags:   summary.RepoTags,
+				Size:       summary.Size,
+				Containers: inUse[summary.ID],
+			},
+			parent: summary.ParentID,
+		}
+		if inspect.RootFS.Type == "layers" {
+			img.layers = inspect.RootFS.Layers
+		}
+		img.sizes = layerSizes(img.layers, history)
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// layerSizes matches the history of an image, which is ordered newest first,
+// with its layers. Only the history entries with a size are known to have
+// created a layer: empty layers are matched with the entries without a size
+// as long as there are more layers left than entries with a size.
+func layerSizes(layers []string, history []image.HistoryResponseItem) []int64 {
+	var entries []int64
+	for i := len(history) - 1; i >= 0; i-- {
+		entries = append(entries, history[i].Size)
+	}
+	nonEmpty := 0
+	for _, size := range entries {
+		if size > 0 {
+			nonEmpty++
+		}
+	}
+
+	sizes := make([]int64, len(layers))
+	layer := 0
+	for _, size := range entries {
+		if layer == len(layers) {
+			break
+		}
+		if size > 0 {
+			sizes[layer] = size
+			nonEmpty--
+			layer++
+		} else if len(layers)-layer > nonEmpty {
+			layer++
+		}
+	}
+	return sizes
+}
+
+// buildImageTree computes the shared and reclaimable size of each image and
+// returns the images that have no parent, with the other images attached
+// to the image they are built from.
+func buildImageTree(images []*treeImage) []*formatter.ImageTreeNode {
+	users := map[string]int{}
+	for _, img := range images {
+		for _, chain := range layerChains(img.layers) {
+			users[chain]++
+		}
+	}
+
+	byID := map[string]*treeImage{}
+	for _, img := range images {
+		byID[img.node.ID] = img
+		var shared int64
+		for i, chain := range layerChains(img.layers) {
+			if users[chain] > 1 {
+				shared += img.sizes[i]
+			}
+		}
+		if shared > img.node.Size {
+			shared = img.node.Size
+		}
+		img.node.SharedSize = shared
+		if img.node.Containers == 0 {
+			img.node.Reclaimable = img.node.Size - shared
+		}
+	}
+
+	var roots []*formatter.ImageTreeNode
+	for _, img := range images {
+		if parent := findParent(img, images, byID); parent != nil {
+			parent.node.Children = append(parent.node.Children, img.node)
+		} else {
+			roots = append(roots, img.node)
+		}
+	}
+	sortImageTree(roots)
+	return roots
+}
+
+// findParent returns the image an image is built from: its parent image if
+// it is listed, or else the image with the longest chain of layers that the
+// image starts with.
+func findParent(img *treeImage, images []*treeImage, byID map[string]*treeImage) *treeImage {
+	if parent, ok := byID[img.parent]; ok && parent != img {
+		return parent
+	}
+	var found *treeImage
+	for _, other := range images {
+		if len(other.layers) == 0 || len(other.layers) >= len(img.layers) || !hasLayers(img.layers, other.layers) {
+			continue
+		}
+		if found == nil || len(other.layers) > len(found.layers) ||
+			(len(other.layers) == len(found.layers) && other.node.ID < found.node.ID) {
+			found = other
+		}
+	}
+	return found
+}
+
+func hasLayers(layers, prefix []string) bool {
+	for i, layer := range prefix {
+		if layers[i] != layer {
+			return false
+		}
+	}
+	return true
+}
+
+// layerChains returns an identifier for each layer that includes the layers
+// below it, as the same layer can be shared only if it has the same parents.
+func layerChains(layers []string) []string {
+	chains := make([]string, len(layers))
+	for i := range layers {
+		chains[i] = strings.Join(layers[:i+1], " ")
+	}
+	return chains
+}
+
+func sortImageTree(nodes []*formatter.ImageTreeNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return treeSortKey(nodes[i]) < treeSortKey(nodes[j])
+	})
+	for _, node := range nodes {
+		sortImageTree(node.Children)
+	}
+}
+
+func treeSortKey(node *formatter.ImageTreeNode) string {
+	for _, tag := range node.RepoTags {
+		if tag != "<none>:<none>" {
+			return tag
+		}
+	}
+	// sort untagged images after the tagged ones
+	return "\xff" + node.ID
+}
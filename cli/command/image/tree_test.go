@@ -0,0 +1,103 @@
+package image
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func treeClient() *fakeClient {
+	images := []types.ImageSummary{
+		{ID: "sha256:1111111111111111", RepoTags: []string{"busybox:latest"}, Size: 1000000},
+		{ID: "sha256:2222222222222222", RepoTags: []string{"app:v1"}, Size: 3000000},
+		{ID: "sha256:3333333333333333", RepoTags: []string{"app:v2", "app:latest"}, Size: 3500000},
+		{ID: "sha256:4444444444444444", RepoTags: []string{"<none>:<none>"}, Size: 1200000},
+		{ID: "sha256:5555555555555555", RepoTags: []string{"alpine:latest"}, Size: 4000000},
+	}
+	layers := map[string][]string{
+		"sha256:1111111111111111": {"base"},
+		"sha256:2222222222222222": {"base", "deps", "v1"},
+		"sha256:3333333333333333": {"base", "deps", "v2"},
+		"sha256:4444444444444444": {"base", "scratch"},
+		"sha256:5555555555555555": {"alpine"},
+	}
+	history := map[string][]image.HistoryResponseItem{
+		"sha256:1111111111111111": {{Size: 0}, {Size: 1000000}},
+		"sha256:2222222222222222": {{Size: 500000}, {Size: 0}, {Size: 1500000}, {Size: 0}, {Size: 1000000}},
+		"sha256:3333333333333333": {{Size: 1000000}, {Size: 1500000}, {Size: 1000000}},
+		"sha256:4444444444444444": {{Size: 200000}, {Size: 1000000}},
+		"sha256:5555555555555555": {{Size: 4000000}},
+	}
+	return &fakeClient{
+		imageListFunc: func(options types.ImageListOptions) ([]types.ImageSummary, error) {
+			return images, nil
+		},
+		imageInspectFunc: func(img string) (types.ImageInspect, []byte, error) {
+			return types.ImageInspect{ID: img, RootFS: types.RootFS{Type: "layers", Layers: layers[img]}}, nil, nil
+		},
+		imageHistoryFunc: func(img string) ([]image.HistoryResponseItem, error) {
+			return history[img], nil
+		},
+		containerListFunc: func(options types.ContainerListOptions) ([]types.Container, error) {
+			return []types.Container{
+				{ID: "c1", ImageID: "sha256:2222222222222222"},
+				{ID: "c2", ImageID: "sha256:2222222222222222"},
+				{ID: "c3", ImageID: "sha256:5555555555555555"},
+			}, nil
+		},
+	}
+}
+
+func TestNewTreeCommand(t *testing.T) {
+	cli := test.NewFakeCli(treeClient())
+	cmd := newTreeCommand(cli)
+	cmd.SetOutput(ioutil.Discard)
+	require.NoError(t, cmd.Execute())
+	golden.Assert(t, cli.OutBuffer().String(), "tree-command-success.golden")
+}
+
+func TestNewTreeCommandErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		args          []string
+		expectedError string
+		client        *fakeClient
+	}{
+		{
+			name:          "wrong-args",
+			args:          []string{"foo"},
+			expectedError: "accepts no arguments.",
+			client:        &fakeClient{},
+		},
+		{
+			name:          "history-error",
+			expectedError: "something went wrong",
+			client: &fakeClient{
+				imageHistoryFunc: func(img string) ([]image.HistoryResponseItem, error) {
+					return nil, errors.Errorf("something went wrong")
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		cmd := newTreeCommand(test.NewFakeCli(tc.client))
+		cmd.SetOutput(ioutil.Discard)
+		cmd.SetArgs(tc.args)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
+
+func TestLayerSizes(t *testing.T) {
+	history := []image.HistoryResponseItem{{Size: 30}, {Size: 0}, {Size: 20}, {Size: 0}, {Size: 10}}
+	assert.Equal(t, []int64{10, 20, 30}, layerSizes([]string{"a", "b", "c"}, history))
+	assert.Equal(t, []int64{10, 0, 20, 30}, layerSizes([]string{"a", "b", "c", "d"}, history))
+	assert.Equal(t, []int64{}, layerSizes([]string{}, history))
+}
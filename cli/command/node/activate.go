@@ -0,0 +1,36 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/spf13/cobra"
+)
+
+func newActivateCommand(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "activate NODE [NODE...]",
+		Short: "Activate one or more nodes",
+		Args:  cli.RequiresMinArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runActivate(dockerCli, args)
+		},
+	}
+}
+
+func runActivate(dockerCli command.Cli, nodes []string) error {
+	activate := func(node *swarm.Node) error {
+		if node.Spec.Availability == swarm.NodeAvailabilityActive {
+			fmt.Fprintf(dockerCli.Out(), "Node %s is already active.\n", node.ID)
+			return errNoAvailabilityChange
+		}
+		node.Spec.Availability = swarm.NodeAvailabilityActive
+		return nil
+	}
+	success := func(nodeID string) {
+		fmt.Fprintf(dockerCli.Out(), "Node %s availability set to active.\n", nodeID)
+	}
+	return updateNodes(dockerCli, nodes, activate, success)
+}
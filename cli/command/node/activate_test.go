@@ -0,0 +1,89 @@
+package node
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/pkg/errors"
+	// Import builders to get the builder function as package function
+	. "github.com/docker/cli/internal/test/builders"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeActivateErrors(t *testing.T) {
+	testCases := []struct {
+		args            []string
+		nodeInspectFunc func() (swarm.Node, []byte, error)
+		nodeUpdateFunc  func(nodeID string, version swarm.Version, node swarm.NodeSpec) error
+		expectedError   string
+	}{
+		{
+			expectedError: "requires at least 1 argument",
+		},
+		{
+			args: []string{"nodeID"},
+			nodeInspectFunc: func() (swarm.Node, []byte, error) {
+				return swarm.Node{}, []byte{}, errors.Errorf("error inspecting the node")
+			},
+			expectedError: "error inspecting the node",
+		},
+		{
+			args: []string{"nodeID"},
+			nodeUpdateFunc: func(nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+				return errors.Errorf("error updating the node")
+			},
+			expectedError: "error updating the node",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := newActivateCommand(
+			test.NewFakeCli(&fakeClient{
+				nodeInspectFunc: tc.nodeInspectFunc,
+				nodeUpdateFunc:  tc.nodeUpdateFunc,
+			}))
+		cmd.SetArgs(tc.args)
+		cmd.SetOutput(ioutil.Discard)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
+
+func TestNodeActivate(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			node := Node()
+			node.Spec.Availability = swarm.NodeAvailabilityDrain
+			return *node, []byte{}, nil
+		},
+		nodeUpdateFunc: func(nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+			if node.Availability != swarm.NodeAvailabilityActive {
+				return errors.Errorf("expected active availability, got %s", node.Availability)
+			}
+			return nil
+		},
+	})
+	cmd := newActivateCommand(cli)
+	cmd.SetArgs([]string{"nodeID"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "Node nodeID availability set to active.\n", cli.OutBuffer().String())
+}
+
+func TestNodeActivateAlreadyActive(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			node := Node()
+			node.Spec.Availability = swarm.NodeAvailabilityActive
+			return *node, []byte{}, nil
+		},
+		nodeUpdateFunc: func(nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+			return errors.New("node should not be updated")
+		},
+	})
+	cmd := newActivateCommand(cli)
+	cmd.SetArgs([]string{"nodeID"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "Node nodeID is already active.\n", cli.OutBuffer().String())
+}
@@ -16,6 +16,7 @@ type fakeClient struct {
 	nodeUpdateFunc  func(nodeID string, version swarm.Version, node swarm.NodeSpec) error
 	taskInspectFunc func(taskID string) (swarm.Task, []byte, error)
 	taskListFunc    func(options types.TaskListOptions) ([]swarm.Task, error)
+	serviceListFunc func(options types.ServiceListOptions) ([]swarm.Service, error)
 }
 
 func (cli *fakeClient) NodeInspectWithRaw(ctx context.Context, ref string) (swarm.Node, []byte, error) {
@@ -66,3 +67,10 @@ func (cli *fakeClient) TaskList(ctx context.Context, options types.TaskListOptio
 	}
 	return []swarm.Task{}, nil
 }
+
+func (cli *fakeClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	if cli.serviceListFunc != nil {
+		return cli.serviceListFunc(options)
+	}
+	return []swarm.Service{}, nil
+}
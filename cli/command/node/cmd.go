@@ -21,7 +21,9 @@ func NewNodeCommand(dockerCli command.Cli) *cobra.Command {
 		Tags:  map[string]string{"version": "1.24"},
 	}
 	cmd.AddCommand(
+		newActivateCommand(dockerCli),
 		newDemoteCommand(dockerCli),
+		newDrainCommand(dockerCli),
 		newInspectCommand(dockerCli),
 		newListCommand(dockerCli),
 		newPromoteCommand(dockerCli),
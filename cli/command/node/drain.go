@@ -0,0 +1,310 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/progress"
+	"github.com/docker/docker/pkg/streamformatter"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+// drainPollInterval is the interval between checks of the tasks of drained
+// nodes
+var drainPollInterval = time.Second
+
+type drainOptions struct {
+	wait    bool
+	timeout time.Duration
+	quiet   bool
+}
+
+func newDrainCommand(dockerCli command.Cli) *cobra.Command {
+	var options drainOptions
+
+	cmd := &cobra.Command{
+		Use:   "drain [OPTIONS] NODE [NODE...]",
+		Short: "Drain one or more nodes",
+		Args:  cli.RequiresMinArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("timeout") && !options.wait {
+				return errors.New("--timeout can only be used with --wait")
+			}
+			return runDrain(dockerCli, options, args)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&options.wait, "wait", false, "Wait for the tasks of the nodes to be running on other nodes")
+	flags.DurationVar(&options.timeout, "timeout", 0, "Maximum duration to wait for (ns|us|ms|s|m|h) (default no timeout)")
+	flags.BoolVarP(&options.quiet, "quiet", "q", false, "Suppress progress output")
+	return cmd
+}
+
+func runDrain(dockerCli command.Cli, options drainOptions, nodes []string) error {
+	var nodeIDs []string
+	slots := map[taskSlot]struct{}{}
+	drain := func(node *swarm.Node) error {
+		nodeIDs = append(nodeIDs, node.ID)
+		if node.Spec.Availability == swarm.NodeAvailabilityDrain {
+			fmt.Fprintf(dockerCli.Out(), "Node %s is already drained.\n", node.ID)
+			return errNoAvailabilityChange
+		}
+		if options.wait {
+			// the tasks may be shut down before the first check of the
+			// progress, so they are listed before the node is drained
+			if err := nodeSlots(context.Background(), dockerCli.Client(), node.ID, slots); err != nil {
+				return err
+			}
+		}
+		node.Spec.Availability = swarm.NodeAvailabilityDrain
+		return nil
+	}
+	success := func(nodeID string) {
+		fmt.Fprintf(dockerCli.Out(), "Node %s availability set to drain.\n", nodeID)
+	}
+	if err := updateNodes(dockerCli, nodes, drain, success); err != nil {
+		return err
+	}
+	if !options.wait {
+		return nil
+	}
+
+	ctx := context.Background()
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+	return waitOnDrain(ctx, dockerCli, nodeIDs, slots, options.quiet)
+}
+
+// nodeSlots adds the slots of the tasks of replicated services running on a
+// node to slots
+func nodeSlots(ctx context.Context, client client.APIClient, nodeID string, slots map[taskSlot]struct{}) error {
+	nodeFilter := filters.NewArgs()
+	nodeFilter.Add("node", nodeID)
+	tasks, err := client.TaskList(ctx, types.TaskListOptions{Filters: nodeFilter})
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		// only the tasks of global services have no slot
+		if task.Slot == 0 || stoppedState(task.Status.State) {
+			continue
+		}
+		slots[taskSlot{serviceID: task.ServiceID, slot: task.Slot}] = struct{}{}
+	}
+	return nil
+}
+
+// waitOnDrain waits for the tasks of drained nodes, and the slots of the tasks
+// that ran on them before they were drained, to be running on other nodes. It
+// outputs a progress display, unless quiet is set.
+func waitOnDrain(ctx context.Context, dockerCli command.Cli, nodeIDs []string, slots map[taskSlot]struct{}, quiet bool) error {
+	errChan := make(chan error, 1)
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		errChan <- drainProgress(ctx, dockerCli.Client(), nodeIDs, slots, pipeWriter)
+	}()
+
+	if quiet {
+		go io.Copy(ioutil.Discard, pipeReader)
+		return <-errChan
+	}
+
+	err := jsonmessage.DisplayJSONMessagesToStream(pipeReader, dockerCli.Out(), nil)
+	if err == nil {
+		err = <-errChan
+	}
+	return err
+}
+
+// taskSlot identifies a task of a replicated service, and its replacements
+type taskSlot struct {
+	serviceID string
+	slot      int
+}
+
+// drainProgress outputs the progress of the tasks moving out of drained
+// nodes, until every task of a replicated service running on the nodes is
+// shut down, and a replacement task is running on another node for each of
+// these tasks and of slots. Tasks of global services are not moved, and are
+// ignored, as are the tasks and slots of services removed or scaled down
+// during the drain.
+func drainProgress(ctx context.Context, client client.APIClient, nodeIDs []string, slots map[taskSlot]struct{}, progressWriter io.WriteCloser) error {
+	defer progressWriter.Close()
+
+	progressOut := streamformatter.NewJSONProgressOutput(progressWriter, false)
+
+	drained := map[string]struct{}{}
+	nodeFilter := filters.NewArgs()
+	for _, nodeID := range nodeIDs {
+		drained[nodeID] = struct{}{}
+		nodeFilter.Add("node", nodeID)
+	}
+	moved := map[taskSlot]struct{}{}
+	for slot := range slots {
+		moved[slot] = struct{}{}
+	}
+
+	for {
+		tasks, err := client.TaskList(ctx, types.TaskListOptions{Filters: nodeFilter})
+		if err != nil {
+			return drainError(ctx, err)
+		}
+		services, err := listServices(ctx, client, tasks, moved)
+		if err != nil {
+			return drainError(ctx, err)
+		}
+		left := map[string]int{}
+		remaining := 0
+		for _, task := range tasks {
+			slot := taskSlot{serviceID: task.ServiceID, slot: task.Slot}
+			// only the tasks of global services have no slot
+			if task.Slot == 0 || stoppedState(task.Status.State) || !slotNeeded(services, slot) {
+				continue
+			}
+			left[task.NodeID]++
+			remaining++
+			moved[slot] = struct{}{}
+		}
+		replaced, err := replacedSlots(ctx, client, drained, services, moved)
+		if err != nil {
+			return drainError(ctx, err)
+		}
+
+		for _, nodeID := range nodeIDs {
+			progressOut.WriteProgress(progress.Progress{
+				ID:     stringid.TruncateID(nodeID),
+				Action: fmt.Sprintf("%d tasks left", left[nodeID]),
+			})
+		}
+		progressOut.WriteProgress(progress.Progress{
+			ID:     "replaced",
+			Action: fmt.Sprintf("%d/%d tasks running on other nodes", replaced, len(moved)),
+		})
+		if remaining == 0 && replaced == len(moved) {
+			progressOut.WriteProgress(progress.Progress{
+				ID:     "verify",
+				Action: "Nodes drained",
+			})
+			return nil
+		}
+
+		select {
+		case <-time.After(drainPollInterval):
+		case <-ctx.Done():
+			return drainError(ctx, ctx.Err())
+		}
+	}
+}
+
+// listServices returns the services of the tasks and slots, by ID. The
+// services that were removed are missing.
+func listServices(ctx context.Context, client client.APIClient, tasks []swarm.Task, slots map[taskSlot]struct{}) (map[string]swarm.Service, error) {
+	services := map[string]swarm.Service{}
+	serviceFilter := filters.NewArgs()
+	for _, task := range tasks {
+		if task.Slot != 0 {
+			serviceFilter.Add("id", task.ServiceID)
+		}
+	}
+	for slot := range slots {
+		serviceFilter.Add("id", slot.serviceID)
+	}
+	if serviceFilter.Len() == 0 {
+		return services, nil
+	}
+	list, err := client.ServiceList(ctx, types.ServiceListOptions{Filters: serviceFilter})
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range list {
+		services[service.ID] = service
+	}
+	return services, nil
+}
+
+// slotNeeded returns whether a slot still needs a running task, that is
+// whether its service exists and was not scaled down below the slot
+func slotNeeded(services map[string]swarm.Service, slot taskSlot) bool {
+	service, ok := services[slot.serviceID]
+	if !ok {
+		return false
+	}
+	if replicated := service.Spec.Mode.Replicated; replicated != nil && replicated.Replicas != nil {
+		return uint64(slot.slot) <= *replicated.Replicas
+	}
+	return true
+}
+
+// replacedSlots returns the number of slots that have a running task on a
+// node that is not drained, or that no longer need one
+func replacedSlots(ctx context.Context, client client.APIClient, drained map[string]struct{}, services map[string]swarm.Service, slots map[taskSlot]struct{}) (int, error) {
+	replaced := map[taskSlot]struct{}{}
+	taskFilter := filters.NewArgs()
+	taskFilter.Add("desired-state", string(swarm.TaskStateRunning))
+	for slot := range slots {
+		if !slotNeeded(services, slot) {
+			replaced[slot] = struct{}{}
+			continue
+		}
+		taskFilter.Add("service", slot.serviceID)
+	}
+	if len(replaced) == len(slots) {
+		return len(replaced), nil
+	}
+	tasks, err := client.TaskList(ctx, types.TaskListOptions{Filters: taskFilter})
+	if err != nil {
+		return 0, err
+	}
+	for _, task := range tasks {
+		slot := taskSlot{serviceID: task.ServiceID, slot: task.Slot}
+		if _, ok := slots[slot]; !ok || task.Status.State != swarm.TaskStateRunning {
+			continue
+		}
+		if _, ok := drained[task.NodeID]; !ok {
+			replaced[slot] = struct{}{}
+		}
+	}
+	return len(replaced), nil
+}
+
+// The states of tasks that are not defined by the vendored API types
+const (
+	// taskStateRemove is the state of the tasks of services that were
+	// removed or scaled down
+	taskStateRemove swarm.TaskState = "remove"
+	// taskStateOrphaned is the state of the tasks of nodes that have been
+	// down for too long
+	taskStateOrphaned swarm.TaskState = "orphaned"
+)
+
+func stoppedState(state swarm.TaskState) bool {
+	switch state {
+	case swarm.TaskStateComplete, swarm.TaskStateShutdown, swarm.TaskStateFailed, swarm.TaskStateRejected, taskStateRemove, taskStateOrphaned:
+		return true
+	}
+	return false
+}
+
+func drainError(ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.New("timed out waiting for the nodes to drain")
+	}
+	return err
+}
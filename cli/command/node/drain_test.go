@@ -0,0 +1,232 @@
+package node
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/pkg/errors"
+	// Import builders to get the builder function as package function
+	. "github.com/docker/cli/internal/test/builders"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeDrainErrors(t *testing.T) {
+	testCases := []struct {
+		args            []string
+		nodeInspectFunc func() (swarm.Node, []byte, error)
+		nodeUpdateFunc  func(nodeID string, version swarm.Version, node swarm.NodeSpec) error
+		taskListFunc    func(options types.TaskListOptions) ([]swarm.Task, error)
+		expectedError   string
+	}{
+		{
+			expectedError: "requires at least 1 argument",
+		},
+		{
+			args:          []string{"--timeout", "10m", "nodeID"},
+			expectedError: "--timeout can only be used with --wait",
+		},
+		{
+			args: []string{"nodeID"},
+			nodeInspectFunc: func() (swarm.Node, []byte, error) {
+				return swarm.Node{}, []byte{}, errors.Errorf("error inspecting the node")
+			},
+			expectedError: "error inspecting the node",
+		},
+		{
+			args: []string{"nodeID"},
+			nodeUpdateFunc: func(nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+				return errors.Errorf("error updating the node")
+			},
+			expectedError: "error updating the node",
+		},
+		{
+			args: []string{"--wait", "--quiet", "--timeout", "10ms", "nodeID"},
+			taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
+				// the task never moves
+				return []swarm.Task{*Task(TaskServiceID("web"), TaskSlot(1), TaskNodeID("nodeID"))}, nil
+			},
+			expectedError: "timed out waiting for the nodes to drain",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := newDrainCommand(
+			test.NewFakeCli(&fakeClient{
+				nodeInspectFunc: func() (swarm.Node, []byte, error) {
+					if tc.nodeInspectFunc != nil {
+						return tc.nodeInspectFunc()
+					}
+					return *Node(), []byte{}, nil
+				},
+				nodeUpdateFunc:  tc.nodeUpdateFunc,
+				taskListFunc:    tc.taskListFunc,
+				serviceListFunc: webService,
+			}))
+		cmd.SetArgs(tc.args)
+		cmd.SetOutput(ioutil.Discard)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
+
+func TestNodeDrain(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *Node(), []byte{}, nil
+		},
+		nodeUpdateFunc: func(nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+			if node.Availability != swarm.NodeAvailabilityDrain {
+				return errors.Errorf("expected drain availability, got %s", node.Availability)
+			}
+			return nil
+		},
+	})
+	cmd := newDrainCommand(cli)
+	cmd.SetArgs([]string{"nodeID"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "Node nodeID availability set to drain.\n", cli.OutBuffer().String())
+}
+
+func TestNodeDrainWait(t *testing.T) {
+	defer func(interval time.Duration) { drainPollInterval = interval }(drainPollInterval)
+	drainPollInterval = time.Millisecond
+
+	checks := 0
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *Node(), []byte{}, nil
+		},
+		serviceListFunc: webService,
+		taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
+			if options.Filters.Include("node") {
+				checks++
+				assert.True(t, options.Filters.ExactMatch("node", "nodeID"))
+				state := swarm.TaskStateRunning
+				if checks > 3 {
+					state = swarm.TaskStateShutdown
+				}
+				return []swarm.Task{
+					*Task(TaskServiceID("web"), TaskSlot(1), TaskNodeID("nodeID"), WithStatus(TaskState(state))),
+					// tasks of global services are not moved
+					*Task(TaskServiceID("agent"), TaskSlot(0), TaskNodeID("nodeID"), WithStatus(TaskState(swarm.TaskStateRunning))),
+				}, nil
+			}
+			assert.True(t, options.Filters.ExactMatch("service", "web"))
+			tasks := []swarm.Task{*Task(TaskServiceID("web"), TaskSlot(1), TaskNodeID("nodeID"))}
+			if checks > 2 {
+				tasks = append(tasks, *Task(TaskServiceID("web"), TaskSlot(1), TaskNodeID("node2"), WithStatus(TaskState(swarm.TaskStateRunning))))
+			}
+			return tasks, nil
+		},
+	})
+	cmd := newDrainCommand(cli)
+	cmd.SetArgs([]string{"--wait", "--quiet", "nodeID"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, 4, checks)
+}
+
+func TestNodeDrainWaitTasksShutDownBeforeCheck(t *testing.T) {
+	defer func(interval time.Duration) { drainPollInterval = interval }(drainPollInterval)
+	drainPollInterval = time.Millisecond
+
+	drained := false
+	replacementChecks := 0
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *Node(), []byte{}, nil
+		},
+		nodeUpdateFunc: func(nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+			drained = true
+			return nil
+		},
+		serviceListFunc: webService,
+		taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
+			if options.Filters.Include("node") {
+				// the task is shut down as soon as the node is drained
+				if drained {
+					return []swarm.Task{}, nil
+				}
+				return []swarm.Task{*Task(TaskServiceID("web"), TaskSlot(1), TaskNodeID("nodeID"), WithStatus(TaskState(swarm.TaskStateRunning)))}, nil
+			}
+			replacementChecks++
+			assert.True(t, options.Filters.ExactMatch("service", "web"))
+			if replacementChecks < 3 {
+				return []swarm.Task{}, nil
+			}
+			return []swarm.Task{*Task(TaskServiceID("web"), TaskSlot(1), TaskNodeID("node2"), WithStatus(TaskState(swarm.TaskStateRunning)))}, nil
+		},
+	})
+	cmd := newDrainCommand(cli)
+	cmd.SetArgs([]string{"--wait", "--quiet", "nodeID"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, 3, replacementChecks)
+}
+
+func webService(options types.ServiceListOptions) ([]swarm.Service, error) {
+	return []swarm.Service{*Service(ServiceID("web"))}, nil
+}
+
+func TestNodeDrainWaitServiceRemoved(t *testing.T) {
+	defer func(interval time.Duration) { drainPollInterval = interval }(drainPollInterval)
+	drainPollInterval = time.Millisecond
+
+	checks := 0
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *Node(), []byte{}, nil
+		},
+		serviceListFunc: func(options types.ServiceListOptions) ([]swarm.Service, error) {
+			checks++
+			assert.True(t, options.Filters.ExactMatch("id", "web"))
+			if checks > 2 {
+				return []swarm.Service{}, nil
+			}
+			return webService(options)
+		},
+		taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
+			// the node is down: its task is never shut down, nor replaced
+			return []swarm.Task{*Task(TaskServiceID("web"), TaskSlot(1), TaskNodeID("nodeID"), WithStatus(TaskState(swarm.TaskStateRunning)))}, nil
+		},
+	})
+	cmd := newDrainCommand(cli)
+	cmd.SetArgs([]string{"--wait", "--quiet", "--timeout", "5s", "nodeID"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, 3, checks)
+}
+
+func TestNodeDrainWaitServiceScaledDown(t *testing.T) {
+	defer func(interval time.Duration) { drainPollInterval = interval }(drainPollInterval)
+	drainPollInterval = time.Millisecond
+
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *Node(), []byte{}, nil
+		},
+		serviceListFunc: func(options types.ServiceListOptions) ([]swarm.Service, error) {
+			return []swarm.Service{*Service(ServiceID("web"), ReplicatedService(1))}, nil
+		},
+		taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
+			if options.Filters.Include("node") {
+				return []swarm.Task{*Task(TaskServiceID("web"), TaskSlot(2), TaskNodeID("nodeID"), WithStatus(TaskState(swarm.TaskStateRunning)))}, nil
+			}
+			t.Fatal("unexpected task list of the replacements of a slot scaled down")
+			return nil, nil
+		},
+	})
+	cmd := newDrainCommand(cli)
+	cmd.SetArgs([]string{"--wait", "--quiet", "--timeout", "5s", "nodeID"})
+	require.NoError(t, cmd.Execute())
+}
+
+func TestStoppedState(t *testing.T) {
+	for _, state := range []swarm.TaskState{swarm.TaskStateComplete, swarm.TaskStateShutdown, swarm.TaskStateFailed, swarm.TaskStateRejected, "remove", "orphaned"} {
+		assert.True(t, stoppedState(state), string(state))
+	}
+	for _, state := range []swarm.TaskState{swarm.TaskStatePending, swarm.TaskStateRunning} {
+		assert.False(t, stoppedState(state), string(state))
+	}
+}
@@ -2,10 +2,12 @@ package node
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/opts"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -14,17 +16,22 @@ import (
 )
 
 var (
-	errNoRoleChange = errors.New("role was already set to the requested value")
+	errNoRoleChange         = errors.New("role was already set to the requested value")
+	errNoAvailabilityChange = errors.New("availability was already set to the requested value")
 )
 
 func newUpdateCommand(dockerCli command.Cli) *cobra.Command {
 	options := newNodeOptions()
+	batch := command.NewBatchOptions()
 
 	cmd := &cobra.Command{
 		Use:   "update [OPTIONS] NODE",
 		Short: "Update a node",
-		Args:  cli.ExactArgs(1),
+		Args:  command.BatchArgs(&batch, cli.ExactArgs(1), cli.NoArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if batch.Enabled() {
+				return runBatchUpdate(dockerCli, cmd.Flags(), batch)
+			}
 			return runUpdate(dockerCli, cmd.Flags(), args[0])
 		},
 	}
@@ -35,6 +42,7 @@ func newUpdateCommand(dockerCli command.Cli) *cobra.Command {
 	flags.Var(&options.annotations.labels, flagLabelAdd, "Add or update a node label (key=value)")
 	labelKeys := opts.NewListOpts(nil)
 	flags.Var(&labelKeys, flagLabelRemove, "Remove a node label if exists")
+	command.AddBatchFlags(flags, &batch, "nodes")
 	return cmd
 }
 
@@ -45,6 +53,42 @@ func runUpdate(dockerCli command.Cli, flags *pflag.FlagSet, nodeID string) error
 	return updateNodes(dockerCli, []string{nodeID}, mergeNodeUpdate(flags), success)
 }
 
+// runBatchUpdate updates the nodes selected by --filter or --all
+func runBatchUpdate(dockerCli command.Cli, flags *pflag.FlagSet, batch command.BatchOptions) error {
+	ctx := context.Background()
+
+	nodes, err := dockerCli.Client().NodeList(ctx, types.NodeListOptions{Filters: batch.Filter.Value()})
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		if batch.All {
+			return nil
+		}
+		return errors.New("no node matches the filter")
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Description.Hostname < nodes[j].Description.Hostname
+	})
+	ids := make([]string, len(nodes))
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.ID
+		names[i] = fmt.Sprintf("%s (%s)", node.Description.Hostname, node.ID)
+	}
+	if !command.ConfirmBatch(dockerCli, "updated", names, batch.Yes) {
+		return nil
+	}
+
+	mergeNode := mergeNodeUpdate(flags)
+	return command.RunBatch(ctx, dockerCli, ids, func(ctx context.Context, dockerCli command.Cli, nodeID string) error {
+		success := func(nodeID string) {
+			fmt.Fprintln(dockerCli.Out(), nodeID)
+		}
+		return updateNodes(dockerCli, []string{nodeID}, mergeNode, success)
+	})
+}
+
 func updateNodes(dockerCli command.Cli, nodes []string, mergeNode func(node *swarm.Node) error, success func(nodeID string)) error {
 	client := dockerCli.Client()
 	ctx := context.Background()
@@ -57,7 +101,7 @@ func updateNodes(dockerCli command.Cli, nodes []string, mergeNode func(node *swa
 
 		err = mergeNode(&node)
 		if err != nil {
-			if err == errNoRoleChange {
+			if err == errNoRoleChange || err == errNoAvailabilityChange {
 				continue
 			}
 			return err
@@ -2,8 +2,11 @@ package node
 
 import (
 	"io/ioutil"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/internal/test"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/pkg/errors"
@@ -168,3 +171,83 @@ func TestNodeUpdate(t *testing.T) {
 		assert.NoError(t, cmd.Execute())
 	}
 }
+
+func TestNodeUpdateBatch(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		updated int
+	)
+	cli := test.NewFakeCli(&fakeClient{
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return []swarm.Node{
+				*Node(NodeID("node-2"), Hostname("rack1-node2")),
+				*Node(NodeID("node-1"), Hostname("rack1-node1")),
+			}, nil
+		},
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *Node(), []byte{}, nil
+		},
+		nodeUpdateFunc: func(nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if node.Availability != swarm.NodeAvailabilityDrain {
+				return errors.Errorf("expected drain availability, got %s", node.Availability)
+			}
+			updated++
+			return nil
+		},
+	})
+	cli.SetIn(command.NewInStream(ioutil.NopCloser(strings.NewReader("y\n"))))
+	cmd := newUpdateCommand(cli)
+	cmd.SetArgs([]string{"--filter", "label=rack=1", "--availability", "drain"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, 2, updated)
+	assert.Contains(t, cli.OutBuffer().String(), "The following will be updated:\n  rack1-node1 (node-1)\n  rack1-node2 (node-2)\n")
+}
+
+func TestNodeUpdateBatchYes(t *testing.T) {
+	updated := 0
+	cli := test.NewFakeCli(&fakeClient{
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return []swarm.Node{*Node(NodeID("node-1"), Hostname("rack1-node1"))}, nil
+		},
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *Node(), []byte{}, nil
+		},
+		nodeUpdateFunc: func(nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+			updated++
+			return nil
+		},
+	})
+	cmd := newUpdateCommand(cli)
+	cmd.SetArgs([]string{"--all", "--yes", "--availability", "pause"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, 1, updated)
+	assert.NotContains(t, cli.OutBuffer().String(), "The following will be updated")
+}
+
+func TestNodeUpdateBatchErrors(t *testing.T) {
+	testCases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          []string{"--all", "node1"},
+			expectedError: "accepts no argument",
+		},
+		{
+			args:          []string{"--all", "--filter", "label=rack=1"},
+			expectedError: "--filter and --all can not be used together",
+		},
+		{
+			args:          []string{"--filter", "label=rack=1", "--availability", "drain"},
+			expectedError: "no node matches the filter",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := newUpdateCommand(test.NewFakeCli(&fakeClient{}))
+		cmd.SetArgs(tc.args)
+		cmd.SetOutput(ioutil.Discard)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
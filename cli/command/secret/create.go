@@ -7,6 +7,7 @@ import (
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/templating"
 	"github.com/docker/cli/opts"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/pkg/system"
@@ -16,10 +17,12 @@ import (
 )
 
 type createOptions struct {
-	name   string
-	driver string
-	file   string
-	labels opts.ListOpts
+	name           string
+	driver         string
+	templateDriver string
+	secretProvider string
+	file           string
+	labels         opts.ListOpts
 }
 
 func newSecretCreateCommand(dockerCli command.Cli) *cobra.Command {
@@ -43,6 +46,8 @@ func newSecretCreateCommand(dockerCli command.Cli) *cobra.Command {
 	flags.VarP(&options.labels, "label", "l", "Secret labels")
 	flags.StringVarP(&options.driver, "driver", "d", "", "Secret driver")
 	flags.SetAnnotation("driver", "version", []string{"1.31"})
+	flags.StringVar(&options.templateDriver, "template-driver", "", `Render the secret content as a template ("`+templating.DriverGolang+`")`)
+	flags.StringVar(&options.secretProvider, "secret-provider", "", "Executable providing the values of secrets used in the template")
 
 	return cmd
 }
@@ -54,11 +59,30 @@ func runSecretCreate(dockerCli command.Cli, options createOptions) error {
 	if options.driver != "" && options.file != "" {
 		return errors.Errorf("When using secret driver secret data must be empty")
 	}
+	if err := templating.ValidateDriver(options.templateDriver); err != nil {
+		return err
+	}
+	if options.templateDriver != "" && options.file == "" {
+		return errors.Errorf("When using a template driver secret data must be provided")
+	}
+	if options.secretProvider != "" && options.templateDriver == "" {
+		return errors.Errorf("--secret-provider can only be used with --template-driver")
+	}
 
 	secretData, err := readSecretData(dockerCli.In(), options.file)
 	if err != nil {
 		return errors.Errorf("Error reading content from %q: %v", options.file, err)
 	}
+	if options.templateDriver != "" {
+		secretData, err = templating.Render(secretData, templating.Options{
+			Driver:         options.templateDriver,
+			BaseDir:        templating.BaseDir(options.file),
+			SecretProvider: options.secretProvider,
+		})
+		if err != nil {
+			return err
+		}
+	}
 	spec := swarm.SecretSpec{
 		Annotations: swarm.Annotations{
 			Name:   options.name,
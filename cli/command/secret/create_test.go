@@ -2,6 +2,7 @@ package secret
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -30,6 +31,14 @@ func TestSecretCreateErrors(t *testing.T) {
 		{args: []string{"create", "--driver", "driver", "-"},
 			expectedError: "secret data must be empty",
 		},
+		{
+			args:          []string{"name", filepath.Join("testdata", secretDataFile), "--template-driver", "jinja"},
+			expectedError: `invalid template driver "jinja"`,
+		},
+		{
+			args:          []string{"name", filepath.Join("testdata", secretDataFile), "--secret-provider", "provider"},
+			expectedError: "--secret-provider can only be used with --template-driver",
+		},
 		{
 			args: []string{"name", filepath.Join("testdata", secretDataFile)},
 			secretCreateFunc: func(secretSpec swarm.SecretSpec) (types.SecretCreateResponse, error) {
@@ -133,3 +142,22 @@ func TestSecretCreateWithLabels(t *testing.T) {
 	assert.NoError(t, cmd.Execute())
 	assert.Equal(t, "ID-"+name, strings.TrimSpace(cli.OutBuffer().String()))
 }
+
+func TestSecretCreateWithTemplate(t *testing.T) {
+	os.Setenv("SECRET_CREATE_TEST_USER", "admin")
+	defer os.Unsetenv("SECRET_CREATE_TEST_USER")
+
+	var actual []byte
+	cli := test.NewFakeCli(&fakeClient{
+		secretCreateFunc: func(spec swarm.SecretSpec) (types.SecretCreateResponse, error) {
+			actual = spec.Data
+			return types.SecretCreateResponse{ID: "ID-" + spec.Name}, nil
+		},
+	})
+
+	cmd := newSecretCreateCommand(cli)
+	cmd.SetArgs([]string{"foo", filepath.Join("testdata", "secret-create-template.txt")})
+	cmd.Flags().Set("template-driver", "golang")
+	assert.NoError(t, cmd.Execute())
+	golden.Assert(t, string(actual), "secret-create-with-template.golden")
+}
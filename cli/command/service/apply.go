@@ -0,0 +1,388 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type applyOptions struct {
+	file         string
+	dryRun       bool
+	registryAuth bool
+	detach       bool
+	quiet        bool
+}
+
+func newApplyCommand(dockerCli command.Cli) *cobra.Command {
+	var opts applyOptions
+
+	cmd := &cobra.Command{
+		Use:   "apply [OPTIONS]",
+		Short: "Create or update a service from a spec file",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runApply(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.file, "file", "f", "", "Path to a YAML or JSON service spec, or \"-\" to read from stdin")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "Show the changes without applying them")
+	flags.BoolVar(&opts.registryAuth, flagRegistryAuth, false, "Send registry authentication details to swarm agents")
+	flags.BoolVarP(&opts.quiet, flagQuiet, "q", false, "Suppress progress output")
+	addDetachFlag(flags, &opts.detach)
+	return cmd
+}
+
+func runApply(dockerCli command.Cli, opts applyOptions) error {
+	if opts.file == "" {
+		return errors.New("please specify a service spec file (--file/-f)")
+	}
+	data, err := readSpecFile(dockerCli, opts.file)
+	if err != nil {
+		return err
+	}
+	desired, err := parseSpec(data)
+	if err != nil {
+		return err
+	}
+	var spec swarm.ServiceSpec
+	if err := convertSpec(desired, &spec); err != nil {
+		return err
+	}
+	if spec.Name == "" {
+		return errors.New("the service spec must have a name")
+	}
+	if _, ok := spec.Labels[labelLastApplied]; ok {
+		return errors.Errorf("the %s label is reserved", labelLastApplied)
+	}
+	lastApplied, err := json.Marshal(desired)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	apiClient := dockerCli.Client()
+	service, _, err := apiClient.ServiceInspectWithRaw(ctx, spec.Name, types.ServiceInspectOptions{})
+	switch {
+	case client.IsErrNotFound(err):
+		return applyCreate(ctx, dockerCli, opts, spec, string(lastApplied))
+	case err != nil:
+		return err
+	}
+
+	// the fields of the live spec that were set by the previous apply, but
+	// are not in the file anymore, are removed. Other fields of the live spec
+	// are kept, unless the file sets them.
+	live, err := toGeneric(withoutLastApplied(service.Spec))
+	if err != nil {
+		return err
+	}
+	last := map[string]interface{}{}
+	if value, ok := service.Spec.Labels[labelLastApplied]; ok {
+		if err := json.Unmarshal([]byte(value), &last); err != nil {
+			return errors.Wrapf(err, "invalid %s label", labelLastApplied)
+		}
+	}
+	merged := mergeSpec(live, last, desired)
+	var newSpec swarm.ServiceSpec
+	if err := convertSpec(merged, &newSpec); err != nil {
+		return err
+	}
+
+	diff, err := specDiff(withoutLastApplied(service.Spec), newSpec)
+	if err != nil {
+		return err
+	}
+	if diff == "" && service.Spec.Labels[labelLastApplied] == string(lastApplied) {
+		fmt.Fprintf(dockerCli.Out(), "Service %s is up to date\n", spec.Name)
+		return nil
+	}
+	fmt.Fprint(dockerCli.Out(), diff)
+	if opts.dryRun {
+		return nil
+	}
+
+	if newSpec.Labels == nil {
+		newSpec.Labels = map[string]string{}
+	}
+	newSpec.Labels[labelLastApplied] = string(lastApplied)
+
+	updateOpts := types.ServiceUpdateOptions{RegistryAuthFrom: types.RegistryAuthFromSpec}
+	if opts.registryAuth {
+		updateOpts.EncodedRegistryAuth, err = encodedAuth(ctx, dockerCli, newSpec)
+		if err != nil {
+			return err
+		}
+	}
+	response, err := apiClient.ServiceUpdate(ctx, service.ID, service.Version, newSpec, updateOpts)
+	if err != nil {
+		return err
+	}
+	for _, warning := range response.Warnings {
+		fmt.Fprintln(dockerCli.Err(), warning)
+	}
+	fmt.Fprintf(dockerCli.Out(), "%s\n", service.ID)
+	return waitOnApply(ctx, dockerCli, opts, service.ID)
+}
+
+// applyCreate creates the service of a spec file
+func applyCreate(ctx context.Context, dockerCli command.Cli, opts applyOptions, spec swarm.ServiceSpec, lastApplied string) error {
+	diff, err := specDiff(swarm.ServiceSpec{}, spec)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(dockerCli.Out(), diff)
+	if opts.dryRun {
+		return nil
+	}
+
+	if spec.Labels == nil {
+		spec.Labels = map[string]string{}
+	}
+	spec.Labels[labelLastApplied] = lastApplied
+
+	createOpts := types.ServiceCreateOptions{}
+	if opts.registryAuth {
+		createOpts.EncodedRegistryAuth, err = encodedAuth(ctx, dockerCli, spec)
+		if err != nil {
+			return err
+		}
+	}
+	response, err := dockerCli.Client().ServiceCreate(ctx, spec, createOpts)
+	if err != nil {
+		return err
+	}
+	for _, warning := range response.Warnings {
+		fmt.Fprintln(dockerCli.Err(), warning)
+	}
+	fmt.Fprintf(dockerCli.Out(), "%s\n", response.ID)
+	return waitOnApply(ctx, dockerCli, opts, response.ID)
+}
+
+func waitOnApply(ctx context.Context, dockerCli command.Cli, opts applyOptions, serviceID string) error {
+	if opts.detach || versions.LessThan(dockerCli.Client().ClientVersion(), "1.29") {
+		return nil
+	}
+	return waitOnService(ctx, dockerCli, serviceID, opts.quiet)
+}
+
+func encodedAuth(ctx context.Context, dockerCli command.Cli, spec swarm.ServiceSpec) (string, error) {
+	if spec.TaskTemplate.ContainerSpec == nil {
+		return "", nil
+	}
+	return command.RetrieveAuthTokenFromImage(ctx, dockerCli, spec.TaskTemplate.ContainerSpec.Image)
+}
+
+func readSpecFile(dockerCli command.Cli, filename string) ([]byte, error) {
+	if filename == "-" {
+		return ioutil.ReadAll(dockerCli.In())
+	}
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("spec file not found: %s", filename)
+	}
+	return data, err
+}
+
+// parseSpec parses a YAML or JSON service spec into a generic document, that
+// has the structure of the JSON encoding of the spec
+func parseSpec(data []byte) (map[string]interface{}, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "invalid service spec")
+	}
+	spec, ok := toJSONValue(doc).(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid service spec: not an object")
+	}
+	var typed swarm.ServiceSpec
+	if err := convertSpec(spec, &typed); err != nil {
+		return nil, err
+	}
+	known, err := toGeneric(typed)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFields(spec, known, ""); err != nil {
+		return nil, err
+	}
+	// round-trip through JSON, so that values have the same types as in the
+	// generic documents of the live spec
+	normalized := map[string]interface{}{}
+	return normalized, convertSpec(spec, &normalized)
+}
+
+// checkFields checks that the fields of a generic document are fields of the
+// spec, known being the generic document of the spec once decoded. As field
+// names are case insensitive when decoding JSON, the fields of the document
+// are renamed like the fields of the spec. Empty fields that are unknown are
+// removed, as they are left out of the JSON encoding of the spec.
+func checkFields(doc, known map[string]interface{}, path string) error {
+	for key, value := range doc {
+		knownKey, ok := matchField(known, key)
+		if !ok {
+			if !isEmptyValue(value) {
+				return errors.Errorf("invalid service spec: unknown field %s%s", path, key)
+			}
+			delete(doc, key)
+			continue
+		}
+		if knownKey != key {
+			delete(doc, key)
+			doc[knownKey] = value
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if knownValue, ok := known[knownKey].(map[string]interface{}); ok {
+				if err := checkFields(v, knownValue, path+knownKey+"."); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			knownValue, ok := known[knownKey].([]interface{})
+			if !ok || len(knownValue) != len(v) {
+				continue
+			}
+			for i := range v {
+				item, ok := v[i].(map[string]interface{})
+				knownItem, knownOk := knownValue[i].(map[string]interface{})
+				if ok && knownOk {
+					if err := checkFields(item, knownItem, fmt.Sprintf("%s%s[%d].", path, knownKey, i)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matchField returns the field of a document matching a key, compared
+// without case if there is no exact match
+func matchField(doc map[string]interface{}, key string) (string, bool) {
+	if _, ok := doc[key]; ok {
+		return key, true
+	}
+	for field := range doc {
+		if strings.EqualFold(field, key) {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case int:
+		return v == 0
+	case float64:
+		return v == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// toGeneric returns the generic document of a spec
+func toGeneric(spec swarm.ServiceSpec) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	return doc, convertSpec(spec, &doc)
+}
+
+// convertSpec converts between specs and generic documents through their
+// JSON encoding
+func convertSpec(from, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, to); err != nil {
+		return errors.Wrap(err, "invalid service spec")
+	}
+	return nil
+}
+
+// mergeSpec merges the spec of a file into the live spec of a service. The
+// fields of the last applied spec that are not in the file anymore are
+// removed from the live spec. Objects are merged field by field, while lists
+// and other values of the file replace the live ones.
+func mergeSpec(live, last, desired map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(live))
+	for key, value := range live {
+		if _, removed := last[key]; removed {
+			if _, ok := desired[key]; !ok {
+				continue
+			}
+		}
+		merged[key] = value
+	}
+	for key, value := range desired {
+		desiredObject, ok := value.(map[string]interface{})
+		liveObject, liveOk := merged[key].(map[string]interface{})
+		if !ok || !liveOk {
+			merged[key] = value
+			continue
+		}
+		lastObject, _ := last[key].(map[string]interface{})
+		merged[key] = mergeSpec(liveObject, lastObject, desiredObject)
+	}
+	return merged
+}
+
+// specDiff returns the differences between two specs as a unified diff of
+// their YAML documents
+func specDiff(from, to swarm.ServiceSpec) (string, error) {
+	a, err := specLines(from)
+	if err != nil {
+		return "", err
+	}
+	b, err := specLines(to)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        a,
+		B:        b,
+		FromFile: "live",
+		ToFile:   "applied",
+		Context:  3,
+	})
+}
+
+func specLines(spec swarm.ServiceSpec) ([]string, error) {
+	if spec.Name == "" {
+		return nil, nil
+	}
+	data, err := marshalSpec(spec, yamlFormat)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	// the document ends with a newline, leaving an empty last line
+	return lines[:len(lines)-1], nil
+}
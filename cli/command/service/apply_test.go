@@ -0,0 +1,217 @@
+package service
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/internal/test"
+	// Import builders to get the builder function as package function
+	. "github.com/docker/cli/internal/test/builders"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+type notFound struct {
+	error
+}
+
+func (n notFound) NotFound() bool {
+	return true
+}
+
+// writeSpecFile writes a spec file in a temporary directory, that is removed
+// by the returned function
+func writeSpecFile(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "service-apply")
+	require.NoError(t, err)
+	filename := filepath.Join(dir, "service.yaml")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+	return filename, func() { os.RemoveAll(dir) }
+}
+
+const applySpec = `
+Name: web
+Labels:
+  team: billing
+TaskTemplate:
+  ContainerSpec:
+    Image: nginx:1.13-alpine
+Mode:
+  Replicated:
+    Replicas: 3
+`
+
+func TestServiceApplyUpdate(t *testing.T) {
+	filename, cleanup := writeSpecFile(t, applySpec)
+	defer cleanup()
+
+	live := *Service(
+		ServiceID("service-id"),
+		ServiceName("web"),
+		// owner was set by the previous apply, and is not in the file anymore.
+		// tier was set by another command, and is kept.
+		ServiceLabels(map[string]string{
+			"team":           "payments",
+			"owner":          "alice",
+			"tier":           "frontend",
+			labelLastApplied: `{"Labels":{"owner":"alice","team":"payments"},"Name":"web"}`,
+		}),
+		ServiceImage("nginx:1.12-alpine"),
+		ReplicatedService(2),
+	)
+	live.Version = swarm.Version{Index: 12}
+	live.Spec.TaskTemplate.ContainerSpec.Env = []string{"DEBUG=1"}
+
+	var updated swarm.ServiceSpec
+	cli := test.NewFakeCli(&fakeClient{
+		serviceInspectWithRawFunc: func(ctx context.Context, ref string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+			assert.Equal(t, "web", ref)
+			return live, nil, nil
+		},
+		serviceUpdateFunc: func(ctx context.Context, serviceID string, version swarm.Version, spec swarm.ServiceSpec, options types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error) {
+			assert.Equal(t, "service-id", serviceID)
+			assert.Equal(t, uint64(12), version.Index)
+			updated = spec
+			return types.ServiceUpdateResponse{}, nil
+		},
+	})
+	cmd := newApplyCommand(cli)
+	cmd.SetArgs([]string{"--detach", "--file", filename})
+	require.NoError(t, cmd.Execute())
+	golden.Assert(t, cli.OutBuffer().String(), "service-apply-update.golden")
+
+	assert.Equal(t, "nginx:1.13-alpine", updated.TaskTemplate.ContainerSpec.Image)
+	assert.Equal(t, []string{"DEBUG=1"}, updated.TaskTemplate.ContainerSpec.Env)
+	assert.Equal(t, uint64(3), *updated.Mode.Replicated.Replicas)
+	lastApplied := updated.Labels[labelLastApplied]
+	delete(updated.Labels, labelLastApplied)
+	assert.Equal(t, map[string]string{"team": "billing", "tier": "frontend"}, updated.Labels)
+
+	var spec swarm.ServiceSpec
+	require.NoError(t, json.Unmarshal([]byte(lastApplied), &spec))
+	assert.Equal(t, "web", spec.Name)
+	assert.Equal(t, "nginx:1.13-alpine", spec.TaskTemplate.ContainerSpec.Image)
+}
+
+func TestServiceApplyUpToDate(t *testing.T) {
+	filename, cleanup := writeSpecFile(t, applySpec)
+	defer cleanup()
+
+	desired, err := parseSpec([]byte(applySpec))
+	require.NoError(t, err)
+	lastApplied, err := json.Marshal(desired)
+	require.NoError(t, err)
+
+	cli := test.NewFakeCli(&fakeClient{
+		serviceInspectWithRawFunc: func(ctx context.Context, ref string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+			return *Service(
+				ServiceName("web"),
+				ServiceLabels(map[string]string{"team": "billing", labelLastApplied: string(lastApplied)}),
+				ServiceImage("nginx:1.13-alpine"),
+				ReplicatedService(3),
+			), nil, nil
+		},
+		serviceUpdateFunc: func(ctx context.Context, serviceID string, version swarm.Version, spec swarm.ServiceSpec, options types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error) {
+			return types.ServiceUpdateResponse{}, errors.New("unexpected update")
+		},
+	})
+	cmd := newApplyCommand(cli)
+	cmd.SetArgs([]string{"--file", filename})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "Service web is up to date\n", cli.OutBuffer().String())
+}
+
+func TestServiceApplyCreate(t *testing.T) {
+	filename, cleanup := writeSpecFile(t, applySpec)
+	defer cleanup()
+
+	var created swarm.ServiceSpec
+	cli := test.NewFakeCli(&fakeClient{
+		serviceInspectWithRawFunc: func(ctx context.Context, ref string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+			return swarm.Service{}, nil, notFound{errors.New("no such service: web")}
+		},
+		serviceCreateFunc: func(ctx context.Context, spec swarm.ServiceSpec, options types.ServiceCreateOptions) (types.ServiceCreateResponse, error) {
+			created = spec
+			return types.ServiceCreateResponse{ID: "service-id"}, nil
+		},
+	})
+	cmd := newApplyCommand(cli)
+	cmd.SetArgs([]string{"--detach", "--file", filename})
+	require.NoError(t, cmd.Execute())
+	golden.Assert(t, cli.OutBuffer().String(), "service-apply-create.golden")
+	assert.Equal(t, "nginx:1.13-alpine", created.TaskTemplate.ContainerSpec.Image)
+	assert.Contains(t, created.Labels, labelLastApplied)
+}
+
+func TestServiceApplyDryRun(t *testing.T) {
+	filename, cleanup := writeSpecFile(t, applySpec)
+	defer cleanup()
+
+	cli := test.NewFakeCli(&fakeClient{
+		serviceInspectWithRawFunc: func(ctx context.Context, ref string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+			return swarm.Service{}, nil, notFound{errors.New("no such service: web")}
+		},
+		serviceCreateFunc: func(ctx context.Context, spec swarm.ServiceSpec, options types.ServiceCreateOptions) (types.ServiceCreateResponse, error) {
+			return types.ServiceCreateResponse{}, errors.New("unexpected create")
+		},
+	})
+	cmd := newApplyCommand(cli)
+	cmd.SetArgs([]string{"--dry-run", "--file", filename})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, cli.OutBuffer().String(), "+Name: web\n")
+}
+
+func TestParseSpec(t *testing.T) {
+	spec, err := parseSpec([]byte(`{"name": "web", "taskTemplate": {"containerSpec": {"image": "nginx"}}, "Labels": {"Team": "billing"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Name":         "web",
+		"TaskTemplate": map[string]interface{}{"ContainerSpec": map[string]interface{}{"Image": "nginx"}},
+		"Labels":       map[string]interface{}{"Team": "billing"},
+	}, spec)
+}
+
+func TestServiceApplyErrors(t *testing.T) {
+	testCases := []struct {
+		spec          string
+		expectedError string
+	}{
+		{
+			spec:          "TaskTemplate:\n  ContainerSpec:\n    Image: nginx\n",
+			expectedError: "the service spec must have a name",
+		},
+		{
+			spec:          "Name: web\nTaskTemplate:\n  ContainerSpec:\n    Imgae: nginx\n",
+			expectedError: "unknown field TaskTemplate.ContainerSpec.Imgae",
+		},
+		{
+			spec:          "Name: web\nMode:\n  Replicated:\n    Replicas: three\n",
+			expectedError: "invalid service spec",
+		},
+		{
+			spec:          "- web\n",
+			expectedError: "invalid service spec: not an object",
+		},
+		{
+			spec:          "Name: web\nLabels:\n  " + labelLastApplied + ": foo\n",
+			expectedError: "label is reserved",
+		},
+	}
+	for _, tc := range testCases {
+		filename, cleanup := writeSpecFile(t, tc.spec)
+		cmd := newApplyCommand(test.NewFakeCli(&fakeClient{}))
+		cmd.SetArgs([]string{"--file", filename})
+		cmd.SetOutput(ioutil.Discard)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+		cleanup()
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// selectServices returns the services selected by --filter or --all, sorted
+// by name
+func selectServices(ctx context.Context, apiClient client.APIClient, options command.BatchOptions) ([]swarm.Service, error) {
+	services, err := apiClient.ServiceList(ctx, types.ServiceListOptions{Filters: options.Filter.Value()})
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 && !options.All {
+		return nil, errors.New("no service matches the filter")
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Spec.Name < services[j].Spec.Name
+	})
+	return services, nil
+}
+
+func serviceNames(services []swarm.Service) []string {
+	names := make([]string, len(services))
+	for i, service := range services {
+		names[i] = service.Spec.Name
+	}
+	return names
+}
+
+// waitOnServices waits for the services to converge, one after the other
+func waitOnServices(ctx context.Context, dockerCli command.Cli, serviceIDs []string, quiet bool) error {
+	var errs []string
+	for _, serviceID := range serviceIDs {
+		if err := waitOnService(ctx, dockerCli, serviceID, quiet); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", serviceID, err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, "\n"))
+}
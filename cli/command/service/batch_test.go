@@ -0,0 +1,200 @@
+package service
+
+import (
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// batchClient is a fake client with the web and api services, labeled
+// tier=frontend, that records the specs of the updated services
+type batchClient struct {
+	*fakeClient
+	mu      sync.Mutex
+	updated map[string]swarm.ServiceSpec
+	options map[string]types.ServiceUpdateOptions
+}
+
+func newBatchClient() *batchClient {
+	c := &batchClient{
+		updated: map[string]swarm.ServiceSpec{},
+		options: map[string]types.ServiceUpdateOptions{},
+	}
+	replicas := uint64(1)
+	services := map[string]swarm.Service{}
+	for _, name := range []string{"web", "api"} {
+		services[name] = swarm.Service{
+			ID: name + "-id",
+			Spec: swarm.ServiceSpec{
+				Annotations:  swarm.Annotations{Name: name, Labels: map[string]string{"tier": "frontend"}},
+				TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "nginx"}},
+				Mode:         swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+			},
+		}
+	}
+	c.fakeClient = &fakeClient{
+		serviceListFunc: func(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+			if options.Filters.Len() > 0 && !options.Filters.ExactMatch("label", "tier=frontend") {
+				return nil, nil
+			}
+			return []swarm.Service{services["web"], services["api"]}, nil
+		},
+		serviceInspectWithRawFunc: func(ctx context.Context, serviceID string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+			service, ok := services[serviceID]
+			if !ok {
+				return swarm.Service{}, nil, errors.Errorf("service %s not found", serviceID)
+			}
+			return service, nil, nil
+		},
+		serviceUpdateFunc: func(ctx context.Context, serviceID string, version swarm.Version, spec swarm.ServiceSpec, options types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.updated[serviceID] = spec
+			c.options[serviceID] = options
+			return types.ServiceUpdateResponse{}, nil
+		},
+	}
+	return c
+}
+
+func (c *batchClient) updatedServices() []string {
+	var ids []string
+	for id := range c.updated {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func sortedLines(s string) []string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestUpdateBatch(t *testing.T) {
+	client := newBatchClient()
+	cli := test.NewFakeCli(client)
+	cmd := newUpdateCommand(cli)
+	cmd.SetArgs([]string{"--filter", "label=tier=frontend", "--yes", "--image", "nginx:alpine"})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, []string{"api-id", "web-id"}, client.updatedServices())
+	for _, spec := range client.updated {
+		assert.Equal(t, "nginx:alpine", spec.TaskTemplate.ContainerSpec.Image)
+	}
+	assert.Equal(t, []string{"api", "web"}, sortedLines(cli.OutBuffer().String()))
+}
+
+func TestUpdateBatchNotConfirmed(t *testing.T) {
+	client := newBatchClient()
+	cli := test.NewFakeCli(client)
+	cli.SetIn(command.NewInStream(ioutil.NopCloser(strings.NewReader("n\n"))))
+	cmd := newUpdateCommand(cli)
+	cmd.SetArgs([]string{"--all", "--image", "nginx:alpine"})
+	require.NoError(t, cmd.Execute())
+
+	assert.Empty(t, client.updated)
+	assert.Equal(t, "The following will be updated:\n  api\n  web\nAre you sure you want to proceed? [y/N] ", cli.OutBuffer().String())
+}
+
+func TestUpdateBatchErrors(t *testing.T) {
+	client := newBatchClient()
+	client.serviceUpdateFunc = func(ctx context.Context, serviceID string, version swarm.Version, spec swarm.ServiceSpec, options types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error) {
+		if serviceID == "api-id" {
+			return types.ServiceUpdateResponse{}, errors.New("update out of sequence")
+		}
+		return types.ServiceUpdateResponse{}, nil
+	}
+	cli := test.NewFakeCli(client)
+	cmd := newUpdateCommand(cli)
+	cmd.SetArgs([]string{"--all", "-y", "--image", "nginx:alpine"})
+	cmd.SetOutput(ioutil.Discard)
+	assert.EqualError(t, cmd.Execute(), "api: update out of sequence")
+	assert.Equal(t, "web\n", cli.OutBuffer().String())
+}
+
+func TestScaleBatch(t *testing.T) {
+	client := newBatchClient()
+	cli := test.NewFakeCli(client)
+	cmd := newScaleCommand(cli)
+	cmd.SetArgs([]string{"--filter", "label=tier=frontend", "-y", "3"})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, []string{"api-id", "web-id"}, client.updatedServices())
+	for _, spec := range client.updated {
+		assert.Equal(t, uint64(3), *spec.Mode.Replicated.Replicas)
+	}
+	assert.Equal(t, []string{"api scaled to 3", "web scaled to 3"}, sortedLines(cli.OutBuffer().String()))
+}
+
+func TestRollbackBatch(t *testing.T) {
+	client := newBatchClient()
+	cli := test.NewFakeCli(client)
+	cmd := newRollbackCommand(cli)
+	cmd.SetArgs([]string{"--all", "--yes"})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, []string{"api-id", "web-id"}, client.updatedServices())
+	for _, options := range client.options {
+		assert.Equal(t, "previous", options.Rollback)
+	}
+}
+
+func TestBatchArgsErrors(t *testing.T) {
+	testCases := []struct {
+		cmd           func(command.Cli) *cobra.Command
+		args          []string
+		expectedError string
+	}{
+		{
+			cmd:           newUpdateCommand,
+			args:          []string{"--all", "web"},
+			expectedError: "accepts no argument",
+		},
+		{
+			cmd:           newUpdateCommand,
+			args:          []string{"--all", "--filter", "label=tier=frontend"},
+			expectedError: "--filter and --all can not be used together",
+		},
+		{
+			cmd:           newUpdateCommand,
+			args:          []string{"--filter", "label=tier=backend", "-y"},
+			expectedError: "no service matches the filter",
+		},
+		{
+			cmd:           newScaleCommand,
+			args:          []string{"--all", "-y", "web=3"},
+			expectedError: "invalid replicas value web=3",
+		},
+		{
+			cmd:           newScaleCommand,
+			args:          []string{"--all", "-y"},
+			expectedError: "requires exactly 1 argument",
+		},
+		{
+			cmd:           newRollbackCommand,
+			args:          []string{"--filter", "label=tier=frontend", "web"},
+			expectedError: "accepts no argument",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := tc.cmd(test.NewFakeCli(newBatchClient()))
+		cmd.SetArgs(tc.args)
+		cmd.SetOutput(ioutil.Discard)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
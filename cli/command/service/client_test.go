@@ -1,6 +1,10 @@
 package service
 
 import (
+	"io"
+	"io/ioutil"
+	"strings"
+
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/client"
@@ -12,16 +16,65 @@ import (
 type fakeClient struct {
 	client.Client
 	serviceInspectWithRawFunc func(ctx context.Context, serviceID string, options types.ServiceInspectOptions) (swarm.Service, []byte, error)
+	serviceCreateFunc         func(ctx context.Context, service swarm.ServiceSpec, options types.ServiceCreateOptions) (types.ServiceCreateResponse, error)
 	serviceUpdateFunc         func(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error)
 	serviceListFunc           func(context.Context, types.ServiceListOptions) ([]swarm.Service, error)
 	infoFunc                  func(ctx context.Context) (types.Info, error)
+	nodeListFunc              func(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error)
+	taskListFunc              func(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error)
+	networkInspectFunc        func(ctx context.Context, networkID string, options types.NetworkInspectOptions) (types.NetworkResource, error)
+	secretListFunc            func(ctx context.Context, options types.SecretListOptions) ([]swarm.Secret, error)
+	configListFunc            func(ctx context.Context, options types.ConfigListOptions) ([]swarm.Config, error)
+	serviceLogsFunc           func(ctx context.Context, serviceID string, options types.ContainerLogsOptions) (io.ReadCloser, error)
+	taskInspectWithRawFunc    func(ctx context.Context, taskID string) (swarm.Task, []byte, error)
+}
+
+func (f *fakeClient) ServiceLogs(ctx context.Context, serviceID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	if f.serviceLogsFunc != nil {
+		return f.serviceLogsFunc(ctx, serviceID, options)
+	}
+	return ioutil.NopCloser(strings.NewReader("")), nil
+}
+
+func (f *fakeClient) TaskInspectWithRaw(ctx context.Context, taskID string) (swarm.Task, []byte, error) {
+	if f.taskInspectWithRawFunc != nil {
+		return f.taskInspectWithRawFunc(ctx, taskID)
+	}
+	return *Task(TaskID(taskID)), nil, nil
+}
+
+func (f *fakeClient) NetworkInspect(ctx context.Context, networkID string, options types.NetworkInspectOptions) (types.NetworkResource, error) {
+	if f.networkInspectFunc != nil {
+		return f.networkInspectFunc(ctx, networkID, options)
+	}
+	return types.NetworkResource{ID: networkID, Name: networkID}, nil
+}
+
+func (f *fakeClient) SecretList(ctx context.Context, options types.SecretListOptions) ([]swarm.Secret, error) {
+	if f.secretListFunc != nil {
+		return f.secretListFunc(ctx, options)
+	}
+	return nil, nil
+}
+
+func (f *fakeClient) ConfigList(ctx context.Context, options types.ConfigListOptions) ([]swarm.Config, error) {
+	if f.configListFunc != nil {
+		return f.configListFunc(ctx, options)
+	}
+	return nil, nil
 }
 
 func (f *fakeClient) NodeList(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+	if f.nodeListFunc != nil {
+		return f.nodeListFunc(ctx, options)
+	}
 	return nil, nil
 }
 
 func (f *fakeClient) TaskList(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error) {
+	if f.taskListFunc != nil {
+		return f.taskListFunc(ctx, options)
+	}
 	return nil, nil
 }
 
@@ -41,6 +94,14 @@ func (f *fakeClient) ServiceList(ctx context.Context, options types.ServiceListO
 	return nil, nil
 }
 
+func (f *fakeClient) ServiceCreate(ctx context.Context, service swarm.ServiceSpec, options types.ServiceCreateOptions) (types.ServiceCreateResponse, error) {
+	if f.serviceCreateFunc != nil {
+		return f.serviceCreateFunc(ctx, service, options)
+	}
+
+	return types.ServiceCreateResponse{}, nil
+}
+
 func (f *fakeClient) ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error) {
 	if f.serviceUpdateFunc != nil {
 		return f.serviceUpdateFunc(ctx, serviceID, version, service, options)
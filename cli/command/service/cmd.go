@@ -27,6 +27,9 @@ func NewServiceCommand(dockerCli *command.DockerCli) *cobra.Command {
 		newUpdateCommand(dockerCli),
 		newLogsCommand(dockerCli),
 		newRollbackCommand(dockerCli),
+		newPlacementCommand(dockerCli),
+		newApplyCommand(dockerCli),
+		newGetCommand(dockerCli),
 	)
 	return cmd
 }
@@ -5,8 +5,10 @@ import (
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/formatter"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/versions"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"golang.org/x/net/context"
@@ -57,6 +59,7 @@ func newCreateCommand(dockerCli command.Cli) *cobra.Command {
 	flags.SetAnnotation(flagDNSSearch, "version", []string{"1.25"})
 	flags.Var(&opts.hosts, flagHost, "Set one or more custom host-to-IP mappings (host:ip)")
 	flags.SetAnnotation(flagHost, "version", []string{"1.25"})
+	flags.BoolVar(&opts.noPlacementCheck, flagNoPlacementCheck, false, "Do not check that the tasks of the service can be scheduled on a node")
 
 	flags.SetInterspersed(false)
 	return cmd
@@ -112,6 +115,18 @@ func runCreate(dockerCli command.Cli, flags *pflag.FlagSet, opts *serviceOptions
 		createOpts.QueryRegistry = true
 	}
 
+	// the placement is checked before creating the service, so that the ports
+	// it publishes are not reported as conflicting with itself
+	var placement []formatter.PlacementResult
+	if !opts.noPlacementCheck {
+		checker, err := NewPlacementChecker(ctx, apiClient)
+		if err != nil {
+			logrus.Debugf("skipping the placement check of the service: %v", err)
+		} else {
+			placement = checker.Check(service)
+		}
+	}
+
 	response, err := apiClient.ServiceCreate(ctx, service, createOpts)
 	if err != nil {
 		return err
@@ -120,6 +135,13 @@ func runCreate(dockerCli command.Cli, flags *pflag.FlagSet, opts *serviceOptions
 	for _, warning := range response.Warnings {
 		fmt.Fprintln(dockerCli.Err(), warning)
 	}
+	if placement != nil {
+		name := service.Name
+		if name == "" {
+			name = response.ID
+		}
+		WarnIfUnschedulable(dockerCli.Err(), name, placement)
+	}
 
 	fmt.Fprintf(dockerCli.Out(), "%s\n", response.ID)
 
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"io"
+
+	"github.com/docker/cli/cli/compose/convert"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// WriteCompose writes the services, and the networks, volumes, secrets and
+// configs they use, to out as a compose file.
+func WriteCompose(ctx context.Context, apiClient client.APIClient, out io.Writer, namespace convert.Namespace, services []swarm.Service) error {
+	objects, err := getServiceObjects(ctx, apiClient, services)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(convert.FromServices(namespace, services, objects))
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal compose file")
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// getServiceObjects returns the networks, secrets and configs referenced by
+// the services
+func getServiceObjects(ctx context.Context, apiClient client.APIClient, services []swarm.Service) (convert.ServiceObjects, error) {
+	objects := convert.ServiceObjects{
+		Networks: map[string]types.NetworkResource{},
+		Secrets:  map[string]swarm.Secret{},
+		Configs:  map[string]swarm.Config{},
+	}
+	secretFilter := filters.NewArgs()
+	configFilter := filters.NewArgs()
+	for _, service := range services {
+		networks := service.Spec.TaskTemplate.Networks
+		if len(networks) == 0 {
+			networks = service.Spec.Networks
+		}
+		for _, attachment := range networks {
+			if _, ok := objects.Networks[attachment.Target]; ok {
+				continue
+			}
+			network, err := apiClient.NetworkInspect(ctx, attachment.Target, types.NetworkInspectOptions{Scope: "swarm"})
+			if err != nil {
+				return objects, err
+			}
+			objects.Networks[attachment.Target] = network
+		}
+
+		containerSpec := service.Spec.TaskTemplate.ContainerSpec
+		if containerSpec == nil {
+			continue
+		}
+		for _, ref := range containerSpec.Secrets {
+			secretFilter.Add("id", ref.SecretID)
+		}
+		for _, ref := range containerSpec.Configs {
+			configFilter.Add("id", ref.ConfigID)
+		}
+	}
+
+	// only list secrets and configs when they are used, so that exporting
+	// works with daemons that don't support configs
+	if secretFilter.Len() > 0 {
+		secrets, err := apiClient.SecretList(ctx, types.SecretListOptions{Filters: secretFilter})
+		if err != nil {
+			return objects, err
+		}
+		for _, secret := range secrets {
+			objects.Secrets[secret.ID] = secret
+		}
+	}
+	if configFilter.Len() > 0 {
+		configs, err := apiClient.ConfigList(ctx, types.ConfigListOptions{Filters: configFilter})
+		if err != nil {
+			return objects, err
+		}
+		for _, config := range configs {
+			objects.Configs[config.ID] = config
+		}
+	}
+	return objects, nil
+}
+
+// exportNamespace returns the namespace of the stack the services belong to,
+// or an empty namespace if they don't all belong to the same stack.
+func exportNamespace(services []swarm.Service) convert.Namespace {
+	var name string
+	for i, service := range services {
+		stack := service.Spec.Labels[convert.LabelNamespace]
+		if i > 0 && stack != name {
+			return convert.NewNamespace("")
+		}
+		name = stack
+	}
+	return convert.NewNamespace(name)
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	yamlFormat = "yaml"
+	jsonFormat = "json"
+
+	// labelLastApplied is the label holding the spec of a service, as last
+	// applied with `docker service apply`
+	labelLastApplied = "com.docker.service.last-applied"
+)
+
+type getOptions struct {
+	service string
+	output  string
+}
+
+func newGetCommand(dockerCli command.Cli) *cobra.Command {
+	var opts getOptions
+
+	cmd := &cobra.Command{
+		Use:   "get [OPTIONS] SERVICE",
+		Short: "Print the spec of a service",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.service = args[0]
+			return runGet(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.output, "output", "o", yamlFormat, "Output format (yaml or json)")
+	return cmd
+}
+
+func runGet(dockerCli command.Cli, opts getOptions) error {
+	if opts.output != yamlFormat && opts.output != jsonFormat {
+		return errors.Errorf("invalid output format %q: must be yaml or json", opts.output)
+	}
+
+	ctx := context.Background()
+	service, _, err := dockerCli.Client().ServiceInspectWithRaw(ctx, opts.service, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	data, err := marshalSpec(withoutLastApplied(service.Spec), opts.output)
+	if err != nil {
+		return err
+	}
+	_, err = dockerCli.Out().Write(data)
+	return err
+}
+
+// withoutLastApplied returns the spec without the last applied spec label
+func withoutLastApplied(spec swarm.ServiceSpec) swarm.ServiceSpec {
+	if _, ok := spec.Labels[labelLastApplied]; !ok {
+		return spec
+	}
+	labels := make(map[string]string, len(spec.Labels))
+	for key, value := range spec.Labels {
+		if key != labelLastApplied {
+			labels[key] = value
+		}
+	}
+	spec.Labels = labels
+	return spec
+}
+
+// marshalSpec returns a service spec as YAML or JSON. Fields are named as in
+// the API, and empty fields are left out.
+func marshalSpec(spec swarm.ServiceSpec, format string) ([]byte, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML. It is parsed into an ordered map, so that the fields
+	// keep the order of the API types.
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	cleaned := withoutEmptyFields(doc)
+	if format == jsonFormat {
+		data, err := json.MarshalIndent(toJSONValue(cleaned), "", "    ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+	return yaml.Marshal(cleaned)
+}
+
+// withoutEmptyFields removes the null and empty values of a document
+func withoutEmptyFields(value interface{}) interface{} {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		cleaned := yaml.MapSlice{}
+		for _, item := range v {
+			if value := withoutEmptyFields(item.Value); !isEmpty(value) {
+				cleaned = append(cleaned, yaml.MapItem{Key: item.Key, Value: value})
+			}
+		}
+		return cleaned
+	case []interface{}:
+		cleaned := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			cleaned = append(cleaned, withoutEmptyFields(item))
+		}
+		return cleaned
+	}
+	return value
+}
+
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case yaml.MapSlice:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// toJSONValue converts a YAML document to a value that can be marshaled to
+// JSON. The order of the fields of ordered maps is not kept.
+func toJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		m := make(map[string]interface{}, len(v))
+		for _, item := range v {
+			m[keyString(item.Key)] = toJSONValue(item.Value)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[keyString(key)] = toJSONValue(item)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, item := range v {
+			l[i] = toJSONValue(item)
+		}
+		return l
+	}
+	return value
+}
+
+func keyString(key interface{}) string {
+	// keys of YAML documents can be numbers or booleans, like label keys
+	return fmt.Sprint(key)
+}
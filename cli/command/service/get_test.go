@@ -0,0 +1,55 @@
+package service
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/docker/cli/internal/test"
+	// Import builders to get the builder function as package function
+	. "github.com/docker/cli/internal/test/builders"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func newGetFakeClient() *fakeClient {
+	return &fakeClient{
+		serviceInspectWithRawFunc: func(ctx context.Context, ref string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+			return *Service(
+				ServiceID("service-id"),
+				ServiceName("web"),
+				ServiceLabels(map[string]string{"team": "billing", labelLastApplied: "{}"}),
+				ServiceImage("nginx:alpine"),
+				ReplicatedService(3),
+				ServicePort(swarm.PortConfig{Protocol: swarm.PortConfigProtocolTCP, TargetPort: 80, PublishedPort: 8080}),
+			), nil, nil
+		},
+	}
+}
+
+func TestServiceGet(t *testing.T) {
+	testCases := []struct {
+		output string
+		golden string
+	}{
+		{output: "yaml", golden: "service-get.yaml.golden"},
+		{output: "json", golden: "service-get.json.golden"},
+	}
+	for _, tc := range testCases {
+		cli := test.NewFakeCli(newGetFakeClient())
+		cmd := newGetCommand(cli)
+		cmd.SetArgs([]string{"--output", tc.output, "web"})
+		require.NoError(t, cmd.Execute())
+		golden.Assert(t, cli.OutBuffer().String(), tc.golden)
+	}
+}
+
+func TestServiceGetInvalidOutput(t *testing.T) {
+	cmd := newGetCommand(test.NewFakeCli(newGetFakeClient()))
+	cmd.SetArgs([]string{"--output", "toml", "web"})
+	cmd.SetOutput(ioutil.Discard)
+	testutil.ErrorContains(t, cmd.Execute(), `invalid output format "toml": must be yaml or json`)
+}
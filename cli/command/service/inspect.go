@@ -9,11 +9,15 @@ import (
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/formatter"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
 	apiclient "github.com/docker/docker/client"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
+// composeFormat is the format to write services as a compose file
+const composeFormat = "compose"
+
 type inspectOptions struct {
 	refs   []string
 	format string
@@ -38,7 +42,7 @@ func newInspectCommand(dockerCli command.Cli) *cobra.Command {
 	}
 
 	flags := cmd.Flags()
-	flags.StringVarP(&opts.format, "format", "f", "", "Format the output using the given Go template")
+	flags.StringVarP(&opts.format, "format", "f", "", `Format the output using the given Go template, or "compose" to write a compose file`)
 	flags.BoolVar(&opts.pretty, "pretty", false, "Print the information in a human friendly format")
 	return cmd
 }
@@ -76,6 +80,10 @@ func runInspect(dockerCli command.Cli, opts inspectOptions) error {
 		}
 	}
 
+	if f == composeFormat {
+		return runInspectCompose(ctx, dockerCli, opts.refs)
+	}
+
 	// check if the user is trying to apply a template to the pretty format, which
 	// is not supported
 	if strings.HasPrefix(f, "pretty") && f != "pretty" {
@@ -92,3 +100,20 @@ func runInspect(dockerCli command.Cli, opts inspectOptions) error {
 	}
 	return nil
 }
+
+func runInspectCompose(ctx context.Context, dockerCli command.Cli, refs []string) error {
+	client := dockerCli.Client()
+
+	var services []swarm.Service
+	for _, ref := range refs {
+		service, _, err := client.ServiceInspectWithRaw(ctx, ref, types.ServiceInspectOptions{})
+		if err != nil {
+			if apiclient.IsErrServiceNotFound(err) {
+				return errors.Errorf("Error: no such service: %s", ref)
+			}
+			return err
+		}
+		services = append(services, service)
+	}
+	return WriteCompose(ctx, client, dockerCli.Out(), exportNamespace(services), services)
+}
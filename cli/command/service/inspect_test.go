@@ -8,9 +8,17 @@ import (
 	"time"
 
 	"github.com/docker/cli/cli/command/formatter"
+	"github.com/docker/cli/cli/compose/convert"
+	"github.com/docker/cli/internal/test"
+	// Import builders to get the builder function as package function
+	. "github.com/docker/cli/internal/test/builders"
 	"github.com/docker/docker/api/types"
+	mounttypes "github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/gotestyourself/gotestyourself/golden"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
 )
 
 func formatServiceInspect(t *testing.T, format formatter.Format, now time.Time) string {
@@ -132,3 +140,72 @@ func TestJSONFormatWithNoUpdateConfig(t *testing.T) {
 	}
 	assert.Equal(t, m1, m2)
 }
+
+func TestInspectComposeFormat(t *testing.T) {
+	replicas := uint64(3)
+	service := *Service(ServiceID("service-id"), ServiceName("web_api"), ServiceLabels(map[string]string{
+		convert.LabelNamespace: "web",
+		convert.LabelImage:     "api:1.0",
+		"tier":                 "backend",
+	}))
+	service.Spec.Mode = swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}}
+	service.Spec.TaskTemplate.ContainerSpec = &swarm.ContainerSpec{
+		Image: "api:1.0@sha256:deadbeef",
+		Args:  []string{"serve", "--verbose"},
+		Env:   []string{"MODE=production", "DEBUG"},
+		Mounts: []mounttypes.Mount{
+			{
+				Type:          mounttypes.TypeVolume,
+				Source:        "web_data",
+				Target:        "/data",
+				VolumeOptions: &mounttypes.VolumeOptions{Labels: map[string]string{convert.LabelNamespace: "web"}},
+			},
+			{Type: mounttypes.TypeBind, Source: "/var/log", Target: "/logs", ReadOnly: true},
+		},
+		Secrets: []*swarm.SecretReference{{
+			SecretID:   "secret-id",
+			SecretName: "web_db_password_" + convert.ContentHash([]byte("secret"))[:12],
+			File:       &swarm.SecretReferenceFileTarget{Name: "db_password", UID: "0", GID: "0", Mode: 0444},
+		}},
+		Configs: []*swarm.ConfigReference{{
+			ConfigID:   "config-id",
+			ConfigName: "shared_conf",
+			File:       &swarm.ConfigReferenceFileTarget{Name: "/etc/api.conf", UID: "0", GID: "0", Mode: 0400},
+		}},
+	}
+	service.Spec.TaskTemplate.Networks = []swarm.NetworkAttachmentConfig{
+		{Target: "backend-id", Aliases: []string{"api"}},
+		{Target: "public-id"},
+	}
+	service.Spec.EndpointSpec = &swarm.EndpointSpec{
+		Mode:  swarm.ResolutionModeVIP,
+		Ports: []swarm.PortConfig{{Protocol: swarm.PortConfigProtocolTCP, TargetPort: 8080, PublishedPort: 80, PublishMode: swarm.PortConfigPublishModeIngress}},
+	}
+
+	cli := test.NewFakeCli(&fakeClient{
+		serviceInspectWithRawFunc: func(ctx context.Context, serviceID string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+			assert.False(t, options.InsertDefaults)
+			return service, nil, nil
+		},
+		networkInspectFunc: func(ctx context.Context, networkID string, options types.NetworkInspectOptions) (types.NetworkResource, error) {
+			if networkID == "backend-id" {
+				return types.NetworkResource{ID: networkID, Name: "web_backend", Driver: "overlay", Labels: map[string]string{convert.LabelNamespace: "web"}}, nil
+			}
+			return types.NetworkResource{ID: networkID, Name: "public", Driver: "overlay"}, nil
+		},
+		secretListFunc: func(ctx context.Context, options types.SecretListOptions) ([]swarm.Secret, error) {
+			return []swarm.Secret{*Secret(SecretID("secret-id"), SecretName("web_db_password"), SecretLabels(map[string]string{
+				convert.LabelNamespace:   "web",
+				convert.LabelContentHash: convert.ContentHash([]byte("secret")),
+			}))}, nil
+		},
+		configListFunc: func(ctx context.Context, options types.ConfigListOptions) ([]swarm.Config, error) {
+			return []swarm.Config{*Config(ConfigID("config-id"), ConfigName("shared_conf"))}, nil
+		},
+	})
+	cmd := newInspectCommand(cli)
+	cmd.SetArgs([]string{"web_api"})
+	cmd.Flags().Set("format", "compose")
+	require.NoError(t, cmd.Execute())
+	golden.Assert(t, cli.OutBuffer().String(), "service-inspect-compose.golden")
+}
@@ -1,20 +1,26 @@
 package service
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/net/context"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/idresolver"
+	"github.com/docker/cli/cli/compose/convert"
+	"github.com/docker/cli/opts"
 	"github.com/docker/cli/service/logs"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
@@ -29,23 +35,36 @@ type logsOptions struct {
 	noTaskIDs  bool
 	follow     bool
 	since      string
+	until      string
 	timestamps bool
 	tail       string
 	details    bool
 	raw        bool
-
-	target string
+	noColor    bool
+	stack      string
+	grep       string
+	json       bool
+	fields     opts.ListOpts
+	output     string
+	gzip       bool
+
+	targets []string
 }
 
-func newLogsCommand(dockerCli *command.DockerCli) *cobra.Command {
-	var opts logsOptions
+func newLogsCommand(dockerCli command.Cli) *cobra.Command {
+	opts := logsOptions{fields: opts.NewListOpts(nil)}
 
 	cmd := &cobra.Command{
-		Use:   "logs [OPTIONS] SERVICE|TASK",
-		Short: "Fetch the logs of a service or task",
-		Args:  cli.ExactArgs(1),
+		Use:   "logs [OPTIONS] SERVICE|TASK [SERVICE|TASK...]",
+		Short: "Fetch the logs of services or tasks",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.stack != "" {
+				return nil
+			}
+			return cli.RequiresMinArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			opts.target = args[0]
+			opts.targets = args
 			return runLogs(dockerCli, &opts)
 		},
 		Tags: map[string]string{"version": "1.29"},
@@ -58,9 +77,17 @@ func newLogsCommand(dockerCli *command.DockerCli) *cobra.Command {
 	flags.BoolVar(&opts.raw, "raw", false, "Do not neatly format logs")
 	flags.SetAnnotation("raw", "version", []string{"1.30"})
 	flags.BoolVar(&opts.noTaskIDs, "no-task-ids", false, "Do not include task IDs in output")
+	flags.BoolVar(&opts.noColor, "no-color", false, "Do not colorize the task names")
+	flags.StringVar(&opts.stack, "stack", "", "Fetch the logs of all the services of a stack")
+	flags.StringVar(&opts.grep, "grep", "", "Only show log lines matching a regular expression")
+	flags.BoolVar(&opts.json, "json", false, "Parse log lines as JSON objects and print their fields")
+	flags.Var(&opts.fields, "field", "Only show JSON log lines with a field value (key=value), used with --json")
+	flags.StringVarP(&opts.output, "output", "o", "", "Write the logs of each task to a file in a directory")
+	flags.BoolVar(&opts.gzip, "gzip", false, "Compress the log files written with --output")
 	// options identical to container logs
 	flags.BoolVarP(&opts.follow, "follow", "f", false, "Follow log output")
 	flags.StringVar(&opts.since, "since", "", "Show logs since timestamp (e.g. 2013-01-02T13:23:37) or relative (e.g. 42m for 42 minutes)")
+	flags.StringVar(&opts.until, "until", "", "Show logs before a timestamp (e.g. 2013-01-02T13:23:37) or relative (e.g. 42m for 42 minutes)")
 	flags.BoolVarP(&opts.timestamps, "timestamps", "t", false, "Show timestamps")
 	flags.BoolVar(&opts.details, "details", false, "Show extra details provided to logs")
 	flags.SetAnnotation("details", "version", []string{"1.30"})
@@ -68,14 +95,94 @@ func newLogsCommand(dockerCli *command.DockerCli) *cobra.Command {
 	return cmd
 }
 
-func runLogs(dockerCli *command.DockerCli, opts *logsOptions) error {
-	ctx := context.Background()
+// logTarget is a service or task to fetch the logs of
+type logTarget struct {
+	id   string
+	task bool
+	tty  bool
+	// maxLength is the length of the largest slot number of the target
+	maxLength int
+}
+
+func runLogs(dockerCli command.Cli, opts *logsOptions) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if opts.fields.Len() > 0 && !opts.json {
+		return errors.New("--field can only be used with --json")
+	}
+	if opts.output != "" && opts.follow {
+		return errors.New("--output can not be used with --follow")
+	}
+	if opts.output != "" && opts.raw {
+		return errors.New("--output can not be used with --raw")
+	}
+	if opts.gzip && opts.output == "" {
+		return errors.New("--gzip can only be used with --output")
+	}
+	filter, err := logs.NewFilter(opts.grep, opts.json, opts.fields.GetAll())
+	if err != nil {
+		return err
+	}
+	// the vendored types.ContainerLogsOptions has no Until, so the daemon
+	// sends all the logs after since, and the lines written after until are
+	// dropped by the CLI
+	var until time.Time
+	if opts.until != "" {
+		until, err = logs.ParseUntil(opts.until, time.Now())
+		if err != nil {
+			return err
+		}
+		if opts.follow {
+			if until.After(time.Now()) {
+				// stop following the logs at the end of the time window
+				ctx, cancel = context.WithDeadline(ctx, until)
+				defer cancel()
+			} else {
+				// the time window has already ended: there is nothing to follow
+				opts.follow = false
+			}
+		}
+	}
+
+	cli := dockerCli.Client()
+	targets, err := getLogTargets(ctx, cli, opts)
+	if err != nil {
+		return err
+	}
+
+	maxLength := 1
+	for _, target := range targets {
+		// we can't prettify tty logs. tell the user that this is the case.
+		if target.tty && !opts.raw {
+			return errors.New("tty service logs only supported with --raw")
+		}
+		if target.maxLength > maxLength {
+			maxLength = target.maxLength
+		}
+	}
+
+	if opts.output != "" {
+		export, err := logs.NewExport(opts.output, logs.ExportOptions{Compress: opts.gzip, Since: opts.since, Until: opts.until})
+		if err != nil {
+			return err
+		}
+		if err := exportLogs(ctx, cli, export, targets, opts, until); err != nil {
+			export.Close()
+			return err
+		}
+		return export.Close()
+	}
+
+	// the logs of several services or tasks are merged by timestamp, unless
+	// they are followed, in which case lines are printed as they arrive
+	sorted := len(targets) > 1 && !opts.follow
 
 	options := types.ContainerLogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
 		Since:      opts.since,
-		Timestamps: opts.timestamps,
+		Timestamps: opts.timestamps || sorted || !until.IsZero(),
 		Follow:     opts.follow,
 		Tail:       opts.tail,
 		// get the details if we request it OR if we're not doing raw mode
@@ -83,80 +190,212 @@ func runLogs(dockerCli *command.DockerCli, opts *logsOptions) error {
 		Details: opts.details || !opts.raw,
 	}
 
-	cli := dockerCli.Client()
+	merger := logs.NewMerger(dockerCli.Out(), dockerCli.Err(), sorted)
+	taskFormatter := newTaskFormatter(cli, opts, maxLength)
+	newWriter := func(stderr bool) *logWriter {
+		return &logWriter{
+			ctx:        ctx,
+			opts:       opts,
+			f:          taskFormatter,
+			filter:     filter,
+			merger:     merger,
+			timestamps: options.Timestamps,
+			until:      until,
+			color:      !opts.noColor && dockerCli.Out().IsTerminal(),
+			stderr:     stderr,
+		}
+	}
+
+	results := make(chan error, len(targets))
+	for _, target := range targets {
+		go func(target logTarget) {
+			results <- copyLogs(ctx, cli, target, options, newWriter(false), newWriter(true))
+		}(target)
+	}
+
+	var errs []string
+	for range targets {
+		// errors are expected once following the logs is stopped
+		if err := <-results; err != nil && ctx.Err() == nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if err := merger.Flush(); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// ExportLogs writes the logs of the services to an export, one file per task.
+// The logs written in the time window between since and until are exported.
+// The logs of the services with a TTY can't be split per task, and are not
+// exported: these services are recorded in the manifest of the export, and
+// their names are returned.
+func ExportLogs(ctx context.Context, apiClient client.APIClient, export *logs.Export, services []swarm.Service, since, until string) ([]string, error) {
+	var untilTime time.Time
+	if until != "" {
+		var err error
+		untilTime, err = logs.ParseUntil(until, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	var (
-		maxLength    = 1
-		responseBody io.ReadCloser
-		tty          bool
-		// logfunc is used to delay the call to logs so that we can do some
-		// processing before we actually get the logs
-		logfunc func(context.Context, string, types.ContainerLogsOptions) (io.ReadCloser, error)
+		targets []logTarget
+		skipped []string
 	)
+	for _, service := range services {
+		target := serviceLogTarget(service)
+		if target.tty {
+			export.Skip(logs.ManifestSkipped{
+				ServiceID:   service.ID,
+				ServiceName: service.Spec.Name,
+				Reason:      "the logs of services with a TTY can't be split per task",
+			})
+			skipped = append(skipped, service.Spec.Name)
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return skipped, exportLogs(ctx, apiClient, export, targets, &logsOptions{since: since, tail: "all", timestamps: true, fields: opts.NewListOpts(nil)}, untilTime)
+}
 
-	service, _, err := cli.ServiceInspectWithRaw(ctx, opts.target, types.ServiceInspectOptions{})
+// exportLogs writes the logs of the services or tasks to an export. The
+// lines are written to the file of their task instead of the output.
+func exportLogs(ctx context.Context, apiClient client.APIClient, export *logs.Export, targets []logTarget, opts *logsOptions, until time.Time) error {
+	filter, err := logs.NewFilter(opts.grep, opts.json, opts.fields.GetAll())
 	if err != nil {
+		return err
+	}
+	options := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      opts.since,
+		Timestamps: opts.timestamps || !until.IsZero(),
+		Tail:       opts.tail,
+		Details:    true,
+	}
+	taskFormatter := newTaskFormatter(apiClient, opts, 0)
+	taskFormatter.export = export
+	newWriter := func(stderr bool) *logWriter {
+		return &logWriter{
+			ctx:        ctx,
+			opts:       opts,
+			f:          taskFormatter,
+			filter:     filter,
+			timestamps: options.Timestamps,
+			until:      until,
+			stderr:     stderr,
+		}
+	}
+
+	var errs []string
+	for _, target := range targets {
+		if err := copyLogs(ctx, apiClient, target, options, newWriter(false), newWriter(true)); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// getLogTargets returns the services of the stack, and the services or tasks
+// passed as arguments
+func getLogTargets(ctx context.Context, cli client.APIClient, opts *logsOptions) ([]logTarget, error) {
+	var targets []logTarget
+	if opts.stack != "" {
+		filter := filters.NewArgs()
+		filter.Add("label", convert.LabelNamespace+"="+opts.stack)
+		services, err := cli.ServiceList(ctx, types.ServiceListOptions{Filters: filter})
+		if err != nil {
+			return nil, err
+		}
+		if len(services) == 0 {
+			return nil, errors.Errorf("nothing found in stack: %s", opts.stack)
+		}
+		for _, service := range services {
+			targets = append(targets, serviceLogTarget(service))
+		}
+	}
+
+	for _, ref := range opts.targets {
+		service, _, err := cli.ServiceInspectWithRaw(ctx, ref, types.ServiceInspectOptions{})
+		if err == nil {
+			targets = append(targets, serviceLogTarget(service))
+			continue
+		}
 		// if it's any error other than service not found, it's Real
 		if !client.IsErrServiceNotFound(err) {
-			return err
+			return nil, err
 		}
-		task, _, err := cli.TaskInspectWithRaw(ctx, opts.target)
+		task, _, err := cli.TaskInspectWithRaw(ctx, ref)
 		if err != nil {
 			if client.IsErrTaskNotFound(err) {
 				// if the task isn't found, rewrite the error to be clear
 				// that we looked for services AND tasks and found none
-				err = fmt.Errorf("no such task or service: %v", opts.target)
+				err = fmt.Errorf("no such task or service: %v", ref)
 			}
-			return err
-		}
-
-		tty = task.Spec.ContainerSpec.TTY
-		maxLength = getMaxLength(task.Slot)
-
-		// use the TaskLogs api function
-		logfunc = cli.TaskLogs
-	} else {
-		// use ServiceLogs api function
-		logfunc = cli.ServiceLogs
-		tty = service.Spec.TaskTemplate.ContainerSpec.TTY
-		if service.Spec.Mode.Replicated != nil && service.Spec.Mode.Replicated.Replicas != nil {
-			// if replicas are initialized, figure out if we need to pad them
-			replicas := *service.Spec.Mode.Replicated.Replicas
-			maxLength = getMaxLength(int(replicas))
+			return nil, err
 		}
+		targets = append(targets, logTarget{
+			id:        task.ID,
+			task:      true,
+			tty:       task.Spec.ContainerSpec.TTY,
+			maxLength: getMaxLength(task.Slot),
+		})
 	}
+	return targets, nil
+}
 
-	// we can't prettify tty logs. tell the user that this is the case.
-	// this is why we assign the logs function to a variable and delay calling
-	// it. we want to check this before we make the call and checking twice in
-	// each branch is even sloppier than this CLI disaster already is
-	if tty && !opts.raw {
-		return errors.New("tty service logs only supported with --raw")
+func serviceLogTarget(service swarm.Service) logTarget {
+	target := logTarget{id: service.ID, maxLength: 1}
+	if service.Spec.TaskTemplate.ContainerSpec != nil {
+		target.tty = service.Spec.TaskTemplate.ContainerSpec.TTY
+	}
+	if service.Spec.Mode.Replicated != nil && service.Spec.Mode.Replicated.Replicas != nil {
+		// if replicas are initialized, figure out if we need to pad them
+		replicas := *service.Spec.Mode.Replicated.Replicas
+		target.maxLength = getMaxLength(int(replicas))
 	}
+	return target
+}
 
-	// now get the logs
-	responseBody, err = logfunc(ctx, opts.target, options)
+// copyLogs copies the logs of a service or task to the log writers
+func copyLogs(ctx context.Context, cli client.APIClient, target logTarget, options types.ContainerLogsOptions, stdout, stderr io.Writer) error {
+	logfunc := cli.ServiceLogs
+	if target.task {
+		logfunc = cli.TaskLogs
+	}
+	responseBody, err := logfunc(ctx, target.id, options)
 	if err != nil {
 		return err
 	}
 	defer responseBody.Close()
 
-	// tty logs get straight copied. they're not muxed with stdcopy
-	if tty {
-		_, err = io.Copy(dockerCli.Out(), responseBody)
-		return err
-	}
-
-	// otherwise, logs are multiplexed. if we're doing pretty printing, also
-	// create a task formatter.
-	var stdout, stderr io.Writer
-	stdout = dockerCli.Out()
-	stderr = dockerCli.Err()
-	if !opts.raw {
-		taskFormatter := newTaskFormatter(cli, opts, maxLength)
-
-		stdout = &logWriter{ctx: ctx, opts: opts, f: taskFormatter, w: stdout}
-		stderr = &logWriter{ctx: ctx, opts: opts, f: taskFormatter, w: stderr}
+	// tty logs are not muxed with stdcopy. they are split in lines, so that
+	// they can be filtered
+	if target.tty {
+		reader := bufio.NewReader(responseBody)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				if _, err := stdout.Write(line); err != nil {
+					return err
+				}
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	_, err = stdcopy.StdCopy(stdout, stderr, responseBody)
@@ -169,6 +408,9 @@ func getMaxLength(i int) int {
 }
 
 type taskFormatter struct {
+	// mu protects the resolver and the cache, as logs of several services
+	// or tasks are formatted concurrently
+	mu      sync.Mutex
 	client  client.APIClient
 	opts    *logsOptions
 	padding int
@@ -177,6 +419,10 @@ type taskFormatter struct {
 	// cache saves a pre-cooked logContext formatted string based on a
 	// logcontext object, so we don't have to resolve names every time
 	cache map[logContext]string
+	// export receives the logs of each task in a file, when logs are
+	// exported instead of printed
+	export *logs.Export
+	files  map[string]io.Writer
 }
 
 func newTaskFormatter(client client.APIClient, opts *logsOptions, padding int) *taskFormatter {
@@ -186,10 +432,14 @@ func newTaskFormatter(client client.APIClient, opts *logsOptions, padding int) *
 		padding: padding,
 		r:       idresolver.New(client, opts.noResolve),
 		cache:   make(map[logContext]string),
+		files:   make(map[string]io.Writer),
 	}
 }
 
 func (f *taskFormatter) format(ctx context.Context, logCtx logContext) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	if cached, ok := f.cache[logCtx]; ok {
 		return cached, nil
 	}
@@ -228,11 +478,65 @@ func (f *taskFormatter) format(ctx context.Context, logCtx logContext) (string,
 	return formatted, nil
 }
 
+// exportFile returns the file of the task the log context belongs to,
+// creating it on first use
+func (f *taskFormatter) exportFile(ctx context.Context, logCtx logContext) (io.Writer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if file, ok := f.files[logCtx.taskID]; ok {
+		return file, nil
+	}
+
+	task, _, err := f.client.TaskInspectWithRaw(ctx, logCtx.taskID)
+	if err != nil {
+		return nil, err
+	}
+	service, _, err := f.client.ServiceInspectWithRaw(ctx, logCtx.serviceID, types.ServiceInspectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	nodeName, err := f.r.Resolve(ctx, swarm.Node{}, logCtx.nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// files are named like the containers of the tasks
+	name := fmt.Sprintf("%s.%d.%s", service.Spec.Name, task.Slot, task.ID)
+	if task.Slot == 0 {
+		name = fmt.Sprintf("%s.%s.%s", service.Spec.Name, task.NodeID, task.ID)
+	}
+	entry := logs.ManifestEntry{
+		ServiceID:      service.ID,
+		ServiceName:    service.Spec.Name,
+		ServiceVersion: service.Version.Index,
+		TaskID:         task.ID,
+		Slot:           task.Slot,
+		NodeID:         logCtx.nodeID,
+		NodeName:       nodeName,
+		ContainerID:    task.Status.ContainerStatus.ContainerID,
+	}
+	file, err := f.export.Create(name, entry)
+	if err != nil {
+		return nil, err
+	}
+	f.files[logCtx.taskID] = file
+	return file, nil
+}
+
 type logWriter struct {
-	ctx  context.Context
-	opts *logsOptions
-	f    *taskFormatter
-	w    io.Writer
+	ctx    context.Context
+	opts   *logsOptions
+	f      *taskFormatter
+	filter *logs.Filter
+	merger *logs.Merger
+	// timestamps is true if log lines start with a timestamp, which is the
+	// case when the user asked for them, or when logs are sorted or filtered
+	timestamps bool
+	// until drops the lines written after it, if set
+	until  time.Time
+	color  bool
+	stderr bool
 }
 
 func (lw *logWriter) Write(buf []byte) (int, error) {
@@ -241,78 +545,112 @@ func (lw *logWriter) Write(buf []byte) (int, error) {
 	// reneged on that assumption. (@god forgive me)
 	// also this only works because the logs format is, like, barely parsable.
 	// if something changes in the logs format, this is gonna break
+	line := buf
 
-	// there should always be at least 2 parts: details and message. if there
-	// is no timestamp, details will be first (index 0) when we split on
-	// spaces. if there is a timestamp, details will be 2nd (`index 1)
-	detailsIndex := 0
-	numParts := 2
-	if lw.opts.timestamps {
-		detailsIndex++
-		numParts++
+	output := []byte{}
+	var timestamp time.Time
+	if lw.timestamps {
+		parts := bytes.SplitN(line, []byte(" "), 2)
+		if len(parts) != 2 {
+			return 0, errors.Errorf("invalid timestamp in log message: %v", string(buf))
+		}
+		var err error
+		timestamp, err = time.Parse(time.RFC3339Nano, string(parts[0]))
+		if err != nil {
+			return 0, errors.Errorf("invalid timestamp in log message: %v", string(buf))
+		}
+		if logs.After(timestamp, lw.until) {
+			return len(buf), nil
+		}
+		// if the user asked for timestamps, add them to the front
+		if lw.opts.timestamps {
+			output = append(output, parts[0]...)
+			output = append(output, ' ')
+		}
+		line = parts[1]
 	}
 
-	// break up the log line into parts.
-	parts := bytes.SplitN(buf, []byte(" "), numParts)
-	if len(parts) != numParts {
-		return 0, errors.Errorf("invalid context in log message: %v", string(buf))
-	}
-	// parse the details out
-	details, err := logs.ParseLogDetails(string(parts[detailsIndex]))
-	if err != nil {
-		return 0, err
-	}
-	// and then create a context from the details
-	// this removes the context-specific details from the details map, so we
-	// can more easily print the details later
-	logCtx, err := lw.parseContext(details)
-	if err != nil {
-		return 0, err
-	}
+	message := line
+	var file io.Writer
+	if !lw.opts.raw {
+		// there should always be at least 2 parts: details and message
+		parts := bytes.SplitN(line, []byte(" "), 2)
+		if len(parts) != 2 {
+			return 0, errors.Errorf("invalid context in log message: %v", string(buf))
+		}
+		// parse the details out
+		details, err := logs.ParseLogDetails(string(parts[0]))
+		if err != nil {
+			return 0, err
+		}
+		// and then create a context from the details
+		// this removes the context-specific details from the details map, so we
+		// can more easily print the details later
+		logCtx, err := lw.parseContext(details)
+		if err != nil {
+			return 0, err
+		}
+		message = parts[1]
+		if !lw.filter.Match(message) {
+			return len(buf), nil
+		}
 
-	output := []byte{}
-	// if we included timestamps, add them to the front
-	if lw.opts.timestamps {
-		output = append(output, parts[0]...)
-		output = append(output, ' ')
-	}
-	// add the context, nice and formatted
-	formatted, err := lw.f.format(lw.ctx, logCtx)
-	if err != nil {
-		return 0, err
-	}
-	output = append(output, []byte(formatted+"    | ")...)
-	// if the user asked for details, add them to be log message
-	if lw.opts.details {
-		// ugh i hate this it's basically a dupe of api/server/httputils/write_log_stream.go:stringAttrs()
-		// ok but we're gonna do it a bit different
-
-		// there are optimizations that can be made here. for starters, i'd
-		// suggest caching the details keys. then, we can maybe draw maps and
-		// slices from a pool to avoid alloc overhead on them. idk if it's
-		// worth the time yet.
-
-		// first we need a slice
-		d := make([]string, 0, len(details))
-		// then let's add all the pairs
-		for k := range details {
-			d = append(d, k+"="+details[k])
+		if lw.f.export != nil {
+			// exported lines go to the file of their task, without context
+			file, err = lw.f.exportFile(lw.ctx, logCtx)
+			if err != nil {
+				return 0, err
+			}
+		} else {
+			// add the context, nice and formatted
+			formatted, err := lw.f.format(lw.ctx, logCtx)
+			if err != nil {
+				return 0, err
+			}
+			prefix := formatted + "    | "
+			if lw.color {
+				prefix = logs.Colorize(formatted, prefix)
+			}
+			output = append(output, []byte(prefix)...)
+		}
+		// if the user asked for details, add them to be log message
+		if lw.opts.details {
+			// ugh i hate this it's basically a dupe of api/server/httputils/write_log_stream.go:stringAttrs()
+			// ok but we're gonna do it a bit different
+
+			// there are optimizations that can be made here. for starters, i'd
+			// suggest caching the details keys. then, we can maybe draw maps and
+			// slices from a pool to avoid alloc overhead on them. idk if it's
+			// worth the time yet.
+
+			// first we need a slice
+			d := make([]string, 0, len(details))
+			// then let's add all the pairs
+			for k := range details {
+				d = append(d, k+"="+details[k])
+			}
+			// then sort em
+			sort.Strings(d)
+			// then join and append
+			output = append(output, []byte(strings.Join(d, ","))...)
+			output = append(output, ' ')
 		}
-		// then sort em
-		sort.Strings(d)
-		// then join and append
-		output = append(output, []byte(strings.Join(d, ","))...)
-		output = append(output, ' ')
+	} else if !lw.filter.Match(message) {
+		return len(buf), nil
 	}
 
 	// add the log message itself, finally
-	output = append(output, parts[detailsIndex+1]...)
+	output = append(output, lw.filter.Format(message)...)
 
-	_, err = lw.w.Write(output)
-	if err != nil {
+	if file != nil {
+		if _, err := file.Write(output); err != nil {
+			return 0, err
+		}
+		return len(buf), nil
+	}
+	if err := lw.merger.WriteLine(timestamp, lw.stderr, output); err != nil {
 		return 0, err
 	}
-
 	return len(buf), nil
 }
 
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/cli/internal/test"
+	// Import builders to get the builder function as package function
+	. "github.com/docker/cli/internal/test/builders"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/cli/service/logs"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+type fakeLogLine struct {
+	timestamp string
+	taskID    string
+	message   string
+	stderr    bool
+}
+
+// fakeLogs returns a multiplexed log stream with details, as returned by the
+// daemon for a service
+func fakeLogs(serviceID string, lines []fakeLogLine) io.ReadCloser {
+	buf := new(bytes.Buffer)
+	stdout := stdcopy.NewStdWriter(buf, stdcopy.Stdout)
+	stderr := stdcopy.NewStdWriter(buf, stdcopy.Stderr)
+	for _, line := range lines {
+		w := stdout
+		if line.stderr {
+			w = stderr
+		}
+		details := "com.docker.swarm.node.id=node-1,com.docker.swarm.service.id=" + serviceID + ",com.docker.swarm.task.id=" + line.taskID
+		w.Write([]byte(line.timestamp + " " + details + " " + line.message + "\n"))
+	}
+	return ioutil.NopCloser(buf)
+}
+
+func newLogsFakeClient(t *testing.T) *fakeClient {
+	return &fakeClient{
+		serviceInspectWithRawFunc: func(ctx context.Context, ref string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+			return *Service(ServiceID(ref+"-id"), ServiceName(ref)), nil, nil
+		},
+		taskInspectWithRawFunc: func(ctx context.Context, taskID string) (swarm.Task, []byte, error) {
+			return *Task(TaskID(taskID), TaskSlot(1)), nil, nil
+		},
+		serviceLogsFunc: func(ctx context.Context, serviceID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+			assert.True(t, options.Timestamps)
+			assert.True(t, options.Details)
+			switch serviceID {
+			case "web-id":
+				return fakeLogs(serviceID, []fakeLogLine{
+					{timestamp: "2017-10-19T10:00:01.000000000Z", taskID: "webtask", message: `{"level":"info","msg":"started"}`},
+					{timestamp: "2017-10-19T10:00:03.000000000Z", taskID: "webtask", message: `{"level":"error","msg":"request failed","http":{"status":500}}`},
+				}), nil
+			default:
+				return fakeLogs(serviceID, []fakeLogLine{
+					{timestamp: "2017-10-19T10:00:02.000000000Z", taskID: "dbtask", message: "ready to accept connections"},
+					{timestamp: "2017-10-19T10:00:04.000000000Z", taskID: "dbtask", message: "connection refused", stderr: true},
+				}), nil
+			}
+		},
+	}
+}
+
+func TestServiceLogsMerge(t *testing.T) {
+	cli := test.NewFakeCli(newLogsFakeClient(t))
+	cmd := newLogsCommand(cli)
+	cmd.SetArgs([]string{"--no-resolve", "web", "db"})
+	require.NoError(t, cmd.Execute())
+	golden.Assert(t, cli.OutBuffer().String(), "service-logs-merge.golden")
+	assert.Equal(t, "db-id.1.dbtask@node-1    | connection refused\n", cli.ErrBuffer().String())
+}
+
+func TestServiceLogsFilters(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args:     []string{"--grep", "fail|refused"},
+			expected: "web-id.1.webtask@node-1    | {\"level\":\"error\",\"msg\":\"request failed\",\"http\":{\"status\":500}}\n",
+		},
+		{
+			args:     []string{"--json", "--field", "level=error"},
+			expected: "web-id.1.webtask@node-1    | http.status=500 level=error msg=\"request failed\"\n",
+		},
+		{
+			args:     []string{"--json", "--field", "http.status=500", "--timestamps"},
+			expected: "2017-10-19T10:00:03.000000000Z web-id.1.webtask@node-1    | http.status=500 level=error msg=\"request failed\"\n",
+		},
+	}
+	for _, tc := range testCases {
+		cli := test.NewFakeCli(newLogsFakeClient(t))
+		cmd := newLogsCommand(cli)
+		cmd.SetArgs(append([]string{"--no-resolve", "web", "db"}, tc.args...))
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, tc.expected, cli.OutBuffer().String())
+	}
+}
+
+func TestServiceLogsUntil(t *testing.T) {
+	cli := test.NewFakeCli(newLogsFakeClient(t))
+	cmd := newLogsCommand(cli)
+	cmd.SetArgs([]string{"--no-resolve", "--until", "2017-10-19T10:00:02Z", "web", "db"})
+	require.NoError(t, cmd.Execute())
+	expected := `web-id.1.webtask@node-1    | {"level":"info","msg":"started"}
+db-id.1.dbtask@node-1    | ready to accept connections
+`
+	assert.Equal(t, expected, cli.OutBuffer().String())
+	assert.Equal(t, "", cli.ErrBuffer().String())
+}
+
+func TestServiceLogsFollowUntil(t *testing.T) {
+	testCases := []struct {
+		until  string
+		follow bool
+	}{
+		{until: "2017-10-19T10:00:02Z", follow: false},
+		{until: "10m", follow: false},
+		{until: time.Now().Add(time.Hour).Format(time.RFC3339), follow: true},
+	}
+	for _, tc := range testCases {
+		client := newLogsFakeClient(t)
+		logsFunc := client.serviceLogsFunc
+		client.serviceLogsFunc = func(ctx context.Context, serviceID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+			// the logs are only followed, until the end of the time window,
+			// if it has not ended
+			require.NoError(t, ctx.Err(), tc.until)
+			_, deadline := ctx.Deadline()
+			assert.Equal(t, tc.follow, deadline, tc.until)
+			assert.Equal(t, tc.follow, options.Follow, tc.until)
+			return logsFunc(ctx, serviceID, options)
+		}
+		cli := test.NewFakeCli(client)
+		cmd := newLogsCommand(cli)
+		cmd.SetArgs([]string{"--no-resolve", "--follow", "--until", tc.until, "web"})
+		require.NoError(t, cmd.Execute(), tc.until)
+		assert.Contains(t, cli.OutBuffer().String(), `web-id.1.webtask@node-1    | {"level":"info","msg":"started"}`, tc.until)
+	}
+}
+
+func TestServiceLogsOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "service-logs-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cli := test.NewFakeCli(newLogsFakeClient(t))
+	cmd := newLogsCommand(cli)
+	cmd.SetArgs([]string{"--no-resolve", "--timestamps", "--output", dir, "web", "db"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "", cli.OutBuffer().String())
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "web-id.1.webtask.log"))
+	require.NoError(t, err)
+	assert.Equal(t, `2017-10-19T10:00:01.000000000Z {"level":"info","msg":"started"}
+2017-10-19T10:00:03.000000000Z {"level":"error","msg":"request failed","http":{"status":500}}
+`, string(data))
+	data, err = ioutil.ReadFile(filepath.Join(dir, "db-id.1.dbtask.log"))
+	require.NoError(t, err)
+	assert.Equal(t, `2017-10-19T10:00:02.000000000Z ready to accept connections
+2017-10-19T10:00:04.000000000Z connection refused
+`, string(data))
+
+	var manifest logs.Manifest
+	data, err = ioutil.ReadFile(filepath.Join(dir, logs.ManifestFile))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, []logs.ManifestEntry{
+		{File: "web-id.1.webtask.log", ServiceID: "web-id-id", ServiceName: "web-id", TaskID: "webtask", Slot: 1, NodeID: "node-1", NodeName: "node-1", Lines: 2},
+		{File: "db-id.1.dbtask.log", ServiceID: "db-id-id", ServiceName: "db-id", TaskID: "dbtask", Slot: 1, NodeID: "node-1", NodeName: "node-1", Lines: 2},
+	}, manifest.Files)
+}
+
+func TestServiceLogsErrors(t *testing.T) {
+	testCases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          []string{},
+			expectedError: "requires at least 1 argument",
+		},
+		{
+			args:          []string{"--field", "level=error", "web"},
+			expectedError: "--field can only be used with --json",
+		},
+		{
+			args:          []string{"--json", "--field", "level", "web"},
+			expectedError: `invalid field filter "level": must be key=value`,
+		},
+		{
+			args:          []string{"--grep", "(", "web"},
+			expectedError: "invalid --grep expression",
+		},
+		{
+			args:          []string{"--stack", "foo"},
+			expectedError: "nothing found in stack: foo",
+		},
+		{
+			args:          []string{"--output", "logs", "--follow", "web"},
+			expectedError: "--output can not be used with --follow",
+		},
+		{
+			args:          []string{"--gzip", "web"},
+			expectedError: "--gzip can only be used with --output",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := newLogsCommand(test.NewFakeCli(newLogsFakeClient(t)))
+		cmd.SetArgs(tc.args)
+		cmd.SetOutput(ioutil.Discard)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
@@ -497,8 +497,9 @@ type serviceOptions struct {
 	networks       opts.NetworkOpt
 	endpoint       endpointOptions
 
-	registryAuth   bool
-	noResolveImage bool
+	registryAuth     bool
+	noResolveImage   bool
+	noPlacementCheck bool
 
 	logDriver logDriverOptions
 
@@ -865,6 +866,7 @@ const (
 	flagWorkdir                 = "workdir"
 	flagRegistryAuth            = "with-registry-auth"
 	flagNoResolveImage          = "no-resolve-image"
+	flagNoPlacementCheck        = "no-placement-check"
 	flagLogDriver               = "log-driver"
 	flagLogOpt                  = "log-opt"
 	flagHealthCmd               = "health-cmd"
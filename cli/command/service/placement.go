@@ -0,0 +1,409 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/formatter"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	units "github.com/docker/go-units"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+type placementOptions struct {
+	service string
+	format  string
+}
+
+func newPlacementCommand(dockerCli command.Cli) *cobra.Command {
+	options := placementOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "placement [OPTIONS] SERVICE",
+		Short: "Show which nodes can run the tasks of a service",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.service = args[0]
+			return runPlacement(dockerCli, options)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&options.format, "format", "", "Pretty-print placement using a Go template")
+	return cmd
+}
+
+func runPlacement(dockerCli command.Cli, options placementOptions) error {
+	client := dockerCli.Client()
+	ctx := context.Background()
+
+	service, _, err := client.ServiceInspectWithRaw(ctx, options.service, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+
+	checker, err := NewPlacementChecker(ctx, client)
+	if err != nil {
+		return err
+	}
+	results := checker.Check(service.Spec)
+
+	format := options.format
+	if len(format) == 0 {
+		format = formatter.TableFormatKey
+	}
+	placementCtx := formatter.Context{
+		Output: dockerCli.Out(),
+		Format: formatter.NewPlacementFormat(format, hasSpreadPreferences(service.Spec)),
+	}
+	return formatter.PlacementWrite(placementCtx, results)
+}
+
+// PlacementChecker evaluates the placement of services against the nodes of
+// the swarm, the same way the scheduler does, to explain why tasks of a
+// service can not be scheduled.
+type PlacementChecker struct {
+	nodes []swarm.Node
+	// tasks holds the tasks that are running or about to run, per node ID
+	tasks    map[string][]swarm.Task
+	services []swarm.Service
+}
+
+// NewPlacementChecker returns a PlacementChecker for the current state of the
+// swarm.
+func NewPlacementChecker(ctx context.Context, apiClient client.APIClient) (*PlacementChecker, error) {
+	nodes, err := apiClient.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Description.Hostname < nodes[j].Description.Hostname
+	})
+
+	filter := filters.NewArgs()
+	filter.Add("desired-state", string(swarm.TaskStateRunning))
+	runningTasks, err := apiClient.TaskList(ctx, types.TaskListOptions{Filters: filter})
+	if err != nil {
+		return nil, err
+	}
+	tasks := map[string][]swarm.Task{}
+	for _, task := range runningTasks {
+		if task.NodeID != "" {
+			tasks[task.NodeID] = append(tasks[task.NodeID], task)
+		}
+	}
+
+	services, err := apiClient.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &PlacementChecker{nodes: nodes, tasks: tasks, services: services}, nil
+}
+
+// Check evaluates the placement constraints, platforms, resource reservations
+// and published ports of the service against each node. The tasks and ports of
+// an existing service with the same name are ignored, as they would be
+// replaced by the tasks of the service being checked.
+func (c *PlacementChecker) Check(spec swarm.ServiceSpec) []formatter.PlacementResult {
+	serviceID := ""
+	for _, service := range c.services {
+		if service.Spec.Name == spec.Name {
+			serviceID = service.ID
+		}
+	}
+
+	clusterReasons := c.checkIngressPorts(spec, serviceID)
+	results := make([]formatter.PlacementResult, 0, len(c.nodes))
+	for _, node := range c.nodes {
+		result := formatter.PlacementResult{
+			NodeID:   node.ID,
+			Hostname: node.Description.Hostname,
+			Spread:   spreadValues(spec, node),
+		}
+		result.Reasons = append(result.Reasons, checkNodeStatus(node)...)
+		result.Reasons = append(result.Reasons, checkConstraints(spec, node)...)
+		result.Reasons = append(result.Reasons, checkPlatforms(spec, node)...)
+		result.Reasons = append(result.Reasons, c.checkResources(spec, serviceID, node)...)
+		result.Reasons = append(result.Reasons, c.checkHostPorts(spec, serviceID, node)...)
+		result.Reasons = append(result.Reasons, clusterReasons...)
+		results = append(results, result)
+	}
+	return results
+}
+
+// WarnIfUnschedulable prints a warning if none of the nodes can run the tasks
+// of the service.
+func WarnIfUnschedulable(out io.Writer, name string, results []formatter.PlacementResult) {
+	if len(results) == 0 {
+		return
+	}
+	reasons := map[string]struct{}{}
+	for _, result := range results {
+		if result.Eligible() {
+			return
+		}
+		for _, reason := range result.Reasons {
+			reasons[reason] = struct{}{}
+		}
+	}
+
+	sorted := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		sorted = append(sorted, reason)
+	}
+	sort.Strings(sorted)
+	fmt.Fprintf(out, "Warning: no node can currently run the tasks of service %s:\n", name)
+	for _, reason := range sorted {
+		fmt.Fprintf(out, " - %s\n", reason)
+	}
+	fmt.Fprintf(out, "Use \"docker service placement %s\" for details.\n", name)
+}
+
+func checkNodeStatus(node swarm.Node) []string {
+	var reasons []string
+	if node.Status.State != swarm.NodeStateReady {
+		reasons = append(reasons, fmt.Sprintf("node is %s", node.Status.State))
+	}
+	if node.Spec.Availability != swarm.NodeAvailabilityActive {
+		reasons = append(reasons, fmt.Sprintf("node availability is %s", node.Spec.Availability))
+	}
+	return reasons
+}
+
+func checkConstraints(spec swarm.ServiceSpec, node swarm.Node) []string {
+	placement := spec.TaskTemplate.Placement
+	if placement == nil {
+		return nil
+	}
+	var reasons []string
+	for _, constraint := range placement.Constraints {
+		ok, err := matchConstraint(constraint, node)
+		switch {
+		case err != nil:
+			reasons = append(reasons, err.Error())
+		case !ok:
+			reasons = append(reasons, fmt.Sprintf("constraint %q is not satisfied", constraint))
+		}
+	}
+	return reasons
+}
+
+// matchConstraint evaluates a placement constraint expression, such as
+// "node.labels.region==east", against a node.
+func matchConstraint(constraint string, node swarm.Node) (bool, error) {
+	var key, value string
+	equal := true
+	if parts := strings.SplitN(constraint, "!=", 2); len(parts) == 2 {
+		key, value, equal = parts[0], parts[1], false
+	} else if parts := strings.SplitN(constraint, "==", 2); len(parts) == 2 {
+		key, value = parts[0], parts[1]
+	} else {
+		return false, errors.Errorf("invalid constraint %q", constraint)
+	}
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+
+	actual, found := nodeAttribute(key, node)
+	if !found && !isKnownAttribute(key) {
+		return false, errors.Errorf("invalid constraint %q: unknown attribute %q", constraint, key)
+	}
+	matches := found && strings.EqualFold(actual, value)
+	return matches == equal, nil
+}
+
+func isKnownAttribute(key string) bool {
+	return strings.HasPrefix(key, "node.labels.") || strings.HasPrefix(key, "engine.labels.")
+}
+
+// nodeAttribute returns the value of a node attribute as used in placement
+// constraints and preferences.
+func nodeAttribute(key string, node swarm.Node) (string, bool) {
+	switch key {
+	case "node.id":
+		return node.ID, true
+	case "node.hostname":
+		return node.Description.Hostname, true
+	case "node.role":
+		return string(node.Spec.Role), true
+	case "node.platform.os":
+		return node.Description.Platform.OS, true
+	case "node.platform.arch":
+		return node.Description.Platform.Architecture, true
+	}
+	if strings.HasPrefix(key, "node.labels.") {
+		value, ok := node.Spec.Labels[strings.TrimPrefix(key, "node.labels.")]
+		return value, ok
+	}
+	if strings.HasPrefix(key, "engine.labels.") {
+		value, ok := node.Description.Engine.Labels[strings.TrimPrefix(key, "engine.labels.")]
+		return value, ok
+	}
+	return "", false
+}
+
+func checkPlatforms(spec swarm.ServiceSpec, node swarm.Node) []string {
+	placement := spec.TaskTemplate.Placement
+	if placement == nil || len(placement.Platforms) == 0 {
+		return nil
+	}
+	nodeOS := node.Description.Platform.OS
+	nodeArch := normalizeArch(node.Description.Platform.Architecture)
+	var supported []string
+	for _, platform := range placement.Platforms {
+		if (platform.OS == "" || strings.EqualFold(platform.OS, nodeOS)) &&
+			(platform.Architecture == "" || normalizeArch(platform.Architecture) == nodeArch) {
+			return nil
+		}
+		supported = append(supported, platform.OS+"/"+platform.Architecture)
+	}
+	return []string{fmt.Sprintf("platform %s/%s is not supported by the image (%s)", nodeOS, node.Description.Platform.Architecture, strings.Join(supported, ", "))}
+}
+
+// normalizeArch returns the architecture name used by images for the
+// architecture names reported by nodes
+func normalizeArch(arch string) string {
+	switch strings.ToLower(arch) {
+	case "x86_64", "x86-64", "amd64":
+		return "amd64"
+	case "aarch64", "arm64":
+		return "arm64"
+	case "i386", "i686", "386":
+		return "386"
+	}
+	return strings.ToLower(arch)
+}
+
+func (c *PlacementChecker) checkResources(spec swarm.ServiceSpec, serviceID string, node swarm.Node) []string {
+	resources := spec.TaskTemplate.Resources
+	if resources == nil || resources.Reservations == nil {
+		return nil
+	}
+
+	var reservedCPU, reservedMemory int64
+	for _, task := range c.tasks[node.ID] {
+		if task.ServiceID == serviceID || task.Spec.Resources == nil || task.Spec.Resources.Reservations == nil {
+			continue
+		}
+		reservedCPU += task.Spec.Resources.Reservations.NanoCPUs
+		reservedMemory += task.Spec.Resources.Reservations.MemoryBytes
+	}
+
+	var reasons []string
+	availableCPU := nonNegative(node.Description.Resources.NanoCPUs - reservedCPU)
+	availableMemory := nonNegative(node.Description.Resources.MemoryBytes - reservedMemory)
+	if wanted := resources.Reservations.NanoCPUs; wanted > availableCPU {
+		reasons = append(reasons, fmt.Sprintf("insufficient CPU: %g reserved, %g available",
+			float64(wanted)/1e9, float64(availableCPU)/1e9))
+	}
+	if wanted := resources.Reservations.MemoryBytes; wanted > availableMemory {
+		reasons = append(reasons, fmt.Sprintf("insufficient memory: %s reserved, %s available",
+			units.BytesSize(float64(wanted)), units.BytesSize(float64(availableMemory))))
+	}
+	return reasons
+}
+
+func nonNegative(value int64) int64 {
+	if value < 0 {
+		return 0
+	}
+	return value
+}
+
+// checkHostPorts checks if ports published in host mode are already used on
+// the node by the tasks of other services
+func (c *PlacementChecker) checkHostPorts(spec swarm.ServiceSpec, serviceID string, node swarm.Node) []string {
+	if spec.EndpointSpec == nil {
+		return nil
+	}
+	var reasons []string
+	for _, port := range spec.EndpointSpec.Ports {
+		if port.PublishMode != swarm.PortConfigPublishModeHost || port.PublishedPort == 0 {
+			continue
+		}
+	tasks:
+		for _, task := range c.tasks[node.ID] {
+			if task.ServiceID == serviceID {
+				continue
+			}
+			for _, used := range task.Status.PortStatus.Ports {
+				if used.PublishedPort == port.PublishedPort && protocol(used) == protocol(port) {
+					reasons = append(reasons, fmt.Sprintf("port %d/%s is already in use on the node", port.PublishedPort, protocol(port)))
+					break tasks
+				}
+			}
+		}
+	}
+	return reasons
+}
+
+// checkIngressPorts checks if ports published in ingress mode are already
+// published by other services, which prevents scheduling on every node
+func (c *PlacementChecker) checkIngressPorts(spec swarm.ServiceSpec, serviceID string) []string {
+	if spec.EndpointSpec == nil {
+		return nil
+	}
+	var reasons []string
+	for _, port := range spec.EndpointSpec.Ports {
+		if port.PublishMode == swarm.PortConfigPublishModeHost || port.PublishedPort == 0 {
+			continue
+		}
+		for _, service := range c.services {
+			if service.ID == serviceID {
+				continue
+			}
+			for _, used := range service.Endpoint.Ports {
+				if used.PublishMode != swarm.PortConfigPublishModeHost && used.PublishedPort == port.PublishedPort && protocol(used) == protocol(port) {
+					reasons = append(reasons, fmt.Sprintf("port %d/%s is already published by service %s", port.PublishedPort, protocol(port), service.Spec.Name))
+				}
+			}
+		}
+	}
+	return reasons
+}
+
+func protocol(port swarm.PortConfig) swarm.PortConfigProtocol {
+	if port.Protocol == "" {
+		return swarm.PortConfigProtocolTCP
+	}
+	return port.Protocol
+}
+
+func hasSpreadPreferences(spec swarm.ServiceSpec) bool {
+	placement := spec.TaskTemplate.Placement
+	if placement == nil {
+		return false
+	}
+	for _, preference := range placement.Preferences {
+		if preference.Spread != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// spreadValues returns the value of the node for each spread placement
+// preference of the service
+func spreadValues(spec swarm.ServiceSpec, node swarm.Node) []string {
+	placement := spec.TaskTemplate.Placement
+	if placement == nil {
+		return nil
+	}
+	var values []string
+	for _, preference := range placement.Preferences {
+		if preference.Spread == nil {
+			continue
+		}
+		descriptor := preference.Spread.SpreadDescriptor
+		value, _ := nodeAttribute(descriptor, node)
+		values = append(values, descriptor+"="+value)
+	}
+	return values
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/docker/cli/internal/test"
+	// Import builders to get the builder function as package function
+	. "github.com/docker/cli/internal/test/builders"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestMatchConstraint(t *testing.T) {
+	node := *Node(
+		NodeID("node-id"),
+		Hostname("node-1"),
+		NodeLabels(map[string]string{"region": "east"}),
+	)
+	testCases := []struct {
+		constraint string
+		expected   bool
+	}{
+		{constraint: "node.labels.region==east", expected: true},
+		{constraint: "node.labels.region == EAST", expected: true},
+		{constraint: "node.labels.region!=east", expected: false},
+		{constraint: "node.labels.zone==a", expected: false},
+		{constraint: "node.labels.zone!=a", expected: true},
+		{constraint: "node.role==manager", expected: false},
+		{constraint: "node.hostname==node-1", expected: true},
+		{constraint: "node.id!=node-id", expected: false},
+		{constraint: "node.platform.os==linux", expected: true},
+		{constraint: "engine.labels.engine==label", expected: true},
+	}
+	for _, tc := range testCases {
+		actual, err := matchConstraint(tc.constraint, node)
+		require.NoError(t, err, tc.constraint)
+		assert.Equal(t, tc.expected, actual, tc.constraint)
+	}
+
+	_, err := matchConstraint("node.labels.region", node)
+	assert.EqualError(t, err, `invalid constraint "node.labels.region"`)
+	_, err = matchConstraint("node.unknown==foo", node)
+	assert.EqualError(t, err, `invalid constraint "node.unknown==foo": unknown attribute "node.unknown"`)
+}
+
+func TestPlacementCheck(t *testing.T) {
+	client := &fakeClient{
+		nodeListFunc: func(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+			return []swarm.Node{
+				*Node(NodeID("node-3"), Hostname("node-3"), NodeLabels(map[string]string{"zone": "b"}), func(node *swarm.Node) {
+					node.Spec.Availability = swarm.NodeAvailabilityDrain
+				}),
+				*Node(NodeID("node-1"), Hostname("node-1"), NodeLabels(map[string]string{"zone": "a"})),
+				*Node(NodeID("node-2"), Hostname("node-2"), func(node *swarm.Node) {
+					node.Description.Platform.Architecture = "aarch64"
+				}),
+			}, nil
+		},
+		taskListFunc: func(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error) {
+			return []swarm.Task{
+				*Task(TaskServiceID("other"), TaskNodeID("node-1"), WithStatus(PortStatus([]swarm.PortConfig{
+					{PublishMode: swarm.PortConfigPublishModeHost, PublishedPort: 8080, TargetPort: 80},
+				}))),
+			}, nil
+		},
+	}
+	checker, err := NewPlacementChecker(context.Background(), client)
+	require.NoError(t, err)
+
+	spec := swarm.ServiceSpec{
+		Annotations: swarm.Annotations{Name: "web"},
+		TaskTemplate: swarm.TaskSpec{
+			Placement: &swarm.Placement{
+				Constraints: []string{"node.labels.zone!=c"},
+				Preferences: []swarm.PlacementPreference{{Spread: &swarm.SpreadOver{SpreadDescriptor: "node.labels.zone"}}},
+				Platforms:   []swarm.Platform{{OS: "linux", Architecture: "amd64"}},
+			},
+		},
+		EndpointSpec: &swarm.EndpointSpec{
+			Ports: []swarm.PortConfig{{PublishMode: swarm.PortConfigPublishModeHost, PublishedPort: 8080, TargetPort: 80}},
+		},
+	}
+	results := checker.Check(spec)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "node-1", results[0].Hostname)
+	assert.Equal(t, []string{"port 8080/tcp is already in use on the node"}, results[0].Reasons)
+	assert.Equal(t, []string{"node.labels.zone=a"}, results[0].Spread)
+
+	assert.Equal(t, "node-2", results[1].Hostname)
+	assert.Equal(t, []string{"platform linux/aarch64 is not supported by the image (linux/amd64)"}, results[1].Reasons)
+
+	assert.Equal(t, "node-3", results[2].Hostname)
+	assert.Equal(t, []string{"node availability is drain"}, results[2].Reasons)
+
+	var out bytes.Buffer
+	WarnIfUnschedulable(&out, "web", results)
+	golden.Assert(t, out.String(), "service-placement-warning.golden")
+}
+
+func TestPlacementCheckResources(t *testing.T) {
+	client := &fakeClient{
+		nodeListFunc: func(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+			return []swarm.Node{*Node(NodeID("node-1"), Hostname("node-1"))}, nil
+		},
+		taskListFunc: func(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error) {
+			return []swarm.Task{
+				*Task(TaskServiceID("other"), TaskNodeID("node-1"), WithTaskSpec(func(spec *swarm.TaskSpec) {
+					spec.Resources = &swarm.ResourceRequirements{Reservations: &swarm.Resources{MemoryBytes: 16 * 1024 * 1024}}
+				})),
+			}, nil
+		},
+	}
+	checker, err := NewPlacementChecker(context.Background(), client)
+	require.NoError(t, err)
+
+	spec := swarm.ServiceSpec{
+		TaskTemplate: swarm.TaskSpec{
+			Resources: &swarm.ResourceRequirements{Reservations: &swarm.Resources{MemoryBytes: 8 * 1024 * 1024}},
+		},
+	}
+	results := checker.Check(spec)
+	require.Len(t, results, 1)
+	assert.Equal(t, []string{"insufficient memory: 8MiB reserved, 4MiB available"}, results[0].Reasons)
+}
+
+func TestRunPlacement(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeListFunc: func(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+			return []swarm.Node{
+				*Node(NodeID("node-1"), Hostname("node-1"), Manager()),
+				*Node(NodeID("node-2"), Hostname("node-2")),
+			}, nil
+		},
+		serviceInspectWithRawFunc: func(ctx context.Context, serviceID string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+			service := *Service(ServiceID("service-id"), ServiceName("web"))
+			service.Spec.TaskTemplate.Placement = &swarm.Placement{Constraints: []string{"node.role==manager"}}
+			return service, nil, nil
+		},
+	})
+	cmd := newPlacementCommand(cli)
+	cmd.SetArgs([]string{"web"})
+	require.NoError(t, cmd.Execute())
+	golden.Assert(t, cli.OutBuffer().String(), "service-placement.golden")
+}
+
+func TestCreateNoPlacementCheck(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeListFunc: func(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+			t.Fatal("unexpected node list")
+			return nil, nil
+		},
+		serviceCreateFunc: func(ctx context.Context, service swarm.ServiceSpec, options types.ServiceCreateOptions) (types.ServiceCreateResponse, error) {
+			return types.ServiceCreateResponse{ID: "service-id"}, nil
+		},
+	})
+	cmd := newCreateCommand(cli)
+	cmd.SetArgs([]string{"--detach", "--no-placement-check", "busybox"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "service-id\n", cli.OutBuffer().String())
+}
@@ -24,6 +24,7 @@ type psOptions struct {
 	noTrunc   bool
 	format    string
 	filter    opts.FilterOpt
+	why       task.WhyOptions
 }
 
 func newPsCommand(dockerCli command.Cli) *cobra.Command {
@@ -44,6 +45,7 @@ func newPsCommand(dockerCli command.Cli) *cobra.Command {
 	flags.BoolVar(&options.noResolve, "no-resolve", false, "Do not map IDs to Names")
 	flags.StringVar(&options.format, "format", "", "Pretty-print tasks using a Go template")
 	flags.VarP(&options.filter, "filter", "f", "Filter output based on conditions provided")
+	task.AddWhyFlags(flags, &options.why)
 
 	return cmd
 }
@@ -52,6 +54,10 @@ func runPS(dockerCli command.Cli, options psOptions) error {
 	client := dockerCli.Client()
 	ctx := context.Background()
 
+	if err := options.why.Validate(options.quiet, options.format); err != nil {
+		return err
+	}
+
 	filter, notfound, err := createFilter(ctx, client, options)
 	if err != nil {
 		return err
@@ -65,12 +71,19 @@ func runPS(dockerCli command.Cli, options psOptions) error {
 		return err
 	}
 
-	format := options.format
-	if len(format) == 0 {
-		format = task.DefaultFormat(dockerCli.ConfigFile(), options.quiet)
-	}
-	if err := task.Print(ctx, dockerCli, tasks, idresolver.New(client, options.noResolve), !options.noTrunc, options.quiet, format); err != nil {
-		return err
+	resolver := idresolver.New(client, options.noResolve)
+	if options.why.Enabled {
+		if err := task.PrintWhy(ctx, dockerCli, tasks, resolver, options.why); err != nil {
+			return err
+		}
+	} else {
+		format := options.format
+		if len(format) == 0 {
+			format = task.DefaultFormat(dockerCli.ConfigFile(), options.quiet)
+		}
+		if err := task.Print(ctx, dockerCli, tasks, resolver, !options.noTrunc, options.quiet, format); err != nil {
+			return err
+		}
 	}
 	if len(notfound) != 0 {
 		return errors.New(strings.Join(notfound, "\n"))
@@ -1,7 +1,6 @@
 package service
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/docker/cli/cli"
@@ -9,16 +8,21 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/versions"
 	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
 )
 
 func newRollbackCommand(dockerCli command.Cli) *cobra.Command {
 	options := newServiceOptions()
+	batch := command.NewBatchOptions()
 
 	cmd := &cobra.Command{
 		Use:   "rollback [OPTIONS] SERVICE",
 		Short: "Revert changes to a service's configuration",
-		Args:  cli.ExactArgs(1),
+		Args:  command.BatchArgs(&batch, cli.ExactArgs(1), cli.NoArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if batch.Enabled() {
+				return runBatchRollback(dockerCli, options, batch)
+			}
 			return runRollback(dockerCli, options, args[0])
 		},
 		Tags: map[string]string{"version": "1.31"},
@@ -27,14 +31,50 @@ func newRollbackCommand(dockerCli command.Cli) *cobra.Command {
 	flags := cmd.Flags()
 	flags.BoolVarP(&options.quiet, flagQuiet, "q", false, "Suppress progress output")
 	addDetachFlag(flags, &options.detach)
+	command.AddBatchFlags(flags, &batch, "services")
 
 	return cmd
 }
 
 func runRollback(dockerCli command.Cli, options *serviceOptions, serviceID string) error {
-	apiClient := dockerCli.Client()
 	ctx := context.Background()
 
+	if err := rollbackService(ctx, dockerCli, serviceID); err != nil {
+		return err
+	}
+	if options.detach || versions.LessThan(dockerCli.Client().ClientVersion(), "1.29") {
+		return nil
+	}
+	return waitOnService(ctx, dockerCli, serviceID, options.quiet)
+}
+
+// runBatchRollback rolls back the services selected by --filter or --all
+func runBatchRollback(dockerCli command.Cli, options *serviceOptions, batch command.BatchOptions) error {
+	ctx := context.Background()
+
+	services, err := selectServices(ctx, dockerCli.Client(), batch)
+	if err != nil || len(services) == 0 {
+		return err
+	}
+	names := serviceNames(services)
+	if !command.ConfirmBatch(dockerCli, "rolled back", names, batch.Yes) {
+		return nil
+	}
+	err = command.RunBatch(ctx, dockerCli, names, func(ctx context.Context, dockerCli command.Cli, name string) error {
+		return rollbackService(ctx, dockerCli, name)
+	})
+	if err != nil {
+		return err
+	}
+	if options.detach || versions.LessThan(dockerCli.Client().ClientVersion(), "1.29") {
+		return nil
+	}
+	return waitOnServices(ctx, dockerCli, names, options.quiet)
+}
+
+func rollbackService(ctx context.Context, dockerCli command.Cli, serviceID string) error {
+	apiClient := dockerCli.Client()
+
 	service, _, err := apiClient.ServiceInspectWithRaw(ctx, serviceID, types.ServiceInspectOptions{})
 	if err != nil {
 		return err
@@ -55,10 +95,5 @@ func runRollback(dockerCli command.Cli, options *serviceOptions, serviceID strin
 	}
 
 	fmt.Fprintf(dockerCli.Out(), "%s\n", serviceID)
-
-	if options.detach || versions.LessThan(apiClient.ClientVersion(), "1.29") {
-		return nil
-	}
-
-	return waitOnService(ctx, dockerCli, serviceID, options.quiet)
+	return nil
 }
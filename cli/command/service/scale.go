@@ -17,22 +17,27 @@ import (
 
 type scaleOptions struct {
 	detach bool
+	batch  command.BatchOptions
 }
 
 func newScaleCommand(dockerCli command.Cli) *cobra.Command {
-	options := &scaleOptions{}
+	options := &scaleOptions{batch: command.NewBatchOptions()}
 
 	cmd := &cobra.Command{
 		Use:   "scale SERVICE=REPLICAS [SERVICE=REPLICAS...]",
 		Short: "Scale one or multiple replicated services",
-		Args:  scaleArgs,
+		Args:  command.BatchArgs(&options.batch, scaleArgs, cli.ExactArgs(1)),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.batch.Enabled() {
+				return runBatchScale(dockerCli, options, args[0])
+			}
 			return runScale(dockerCli, options, args)
 		},
 	}
 
 	flags := cmd.Flags()
 	addDetachFlag(flags, &options.detach)
+	command.AddBatchFlags(flags, &options.batch, "services")
 	return cmd
 }
 
@@ -94,6 +99,34 @@ func runScale(dockerCli command.Cli, options *scaleOptions, args []string) error
 	return errors.Errorf(strings.Join(errs, "\n"))
 }
 
+// runBatchScale scales the services selected by --filter or --all
+func runBatchScale(dockerCli command.Cli, options *scaleOptions, scaleStr string) error {
+	ctx := context.Background()
+
+	scale, err := strconv.ParseUint(scaleStr, 10, 64)
+	if err != nil {
+		return errors.Errorf("invalid replicas value %s: %v", scaleStr, err)
+	}
+	services, err := selectServices(ctx, dockerCli.Client(), options.batch)
+	if err != nil || len(services) == 0 {
+		return err
+	}
+	names := serviceNames(services)
+	if !command.ConfirmBatch(dockerCli, fmt.Sprintf("scaled to %d", scale), names, options.batch.Yes) {
+		return nil
+	}
+	err = command.RunBatch(ctx, dockerCli, names, func(ctx context.Context, dockerCli command.Cli, name string) error {
+		return runServiceScale(ctx, dockerCli, name, scale)
+	})
+	if err != nil {
+		return err
+	}
+	if options.detach || versions.LessThan(dockerCli.Client().ClientVersion(), "1.29") {
+		return nil
+	}
+	return waitOnServices(ctx, dockerCli, names, false)
+}
+
 func runServiceScale(ctx context.Context, dockerCli command.Cli, serviceID string, scale uint64) error {
 	client := dockerCli.Client()
 
@@ -24,17 +24,22 @@ import (
 
 func newUpdateCommand(dockerCli command.Cli) *cobra.Command {
 	options := newServiceOptions()
+	batch := command.NewBatchOptions()
 
 	cmd := &cobra.Command{
 		Use:   "update [OPTIONS] SERVICE",
 		Short: "Update a service",
-		Args:  cli.ExactArgs(1),
+		Args:  command.BatchArgs(&batch, cli.ExactArgs(1), cli.NoArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if batch.Enabled() {
+				return runBatchUpdate(dockerCli, cmd.Flags(), options, batch)
+			}
 			return runUpdate(dockerCli, cmd.Flags(), options, args[0])
 		},
 	}
 
 	flags := cmd.Flags()
+	command.AddBatchFlags(flags, &batch, "services")
 	flags.String("image", "", "Service image tag")
 	flags.Var(&ShlexOpt{}, "args", "Service command args")
 	flags.Bool(flagRollback, false, "Rollback to previous specification")
@@ -102,11 +107,47 @@ func newListOptsVar() *opts.ListOpts {
 	return opts.NewListOptsRef(&[]string{}, nil)
 }
 
-// nolint: gocyclo
 func runUpdate(dockerCli command.Cli, flags *pflag.FlagSet, options *serviceOptions, serviceID string) error {
-	apiClient := dockerCli.Client()
 	ctx := context.Background()
 
+	if err := updateOneService(ctx, dockerCli, flags, options, serviceID); err != nil {
+		return err
+	}
+	if options.detach || versions.LessThan(dockerCli.Client().ClientVersion(), "1.29") {
+		return nil
+	}
+	return waitOnService(ctx, dockerCli, serviceID, options.quiet)
+}
+
+// runBatchUpdate updates the services selected by --filter or --all
+func runBatchUpdate(dockerCli command.Cli, flags *pflag.FlagSet, options *serviceOptions, batch command.BatchOptions) error {
+	ctx := context.Background()
+
+	services, err := selectServices(ctx, dockerCli.Client(), batch)
+	if err != nil || len(services) == 0 {
+		return err
+	}
+	names := serviceNames(services)
+	if !command.ConfirmBatch(dockerCli, "updated", names, batch.Yes) {
+		return nil
+	}
+	err = command.RunBatch(ctx, dockerCli, names, func(ctx context.Context, dockerCli command.Cli, name string) error {
+		return updateOneService(ctx, dockerCli, flags, options, name)
+	})
+	if err != nil {
+		return err
+	}
+	if options.detach || versions.LessThan(dockerCli.Client().ClientVersion(), "1.29") {
+		return nil
+	}
+	return waitOnServices(ctx, dockerCli, names, options.quiet)
+}
+
+// updateOneService applies the flags to a service
+// nolint: gocyclo
+func updateOneService(ctx context.Context, dockerCli command.Cli, flags *pflag.FlagSet, options *serviceOptions, serviceID string) error {
+	apiClient := dockerCli.Client()
+
 	service, _, err := apiClient.ServiceInspectWithRaw(ctx, serviceID, types.ServiceInspectOptions{})
 	if err != nil {
 		return err
@@ -130,7 +171,8 @@ func runUpdate(dockerCli command.Cli, flags *pflag.FlagSet, options *serviceOpti
 		// Rollback can't be combined with other flags.
 		otherFlagsPassed := false
 		flags.VisitAll(func(f *pflag.Flag) {
-			if f.Name == flagRollback || f.Name == flagDetach || f.Name == flagQuiet {
+			switch f.Name {
+			case flagRollback, flagDetach, flagQuiet, "filter", "all", "yes":
 				return
 			}
 			if flags.Changed(f.Name) {
@@ -215,12 +257,7 @@ func runUpdate(dockerCli command.Cli, flags *pflag.FlagSet, options *serviceOpti
 	}
 
 	fmt.Fprintf(dockerCli.Out(), "%s\n", serviceID)
-
-	if options.detach || versions.LessThan(apiClient.ClientVersion(), "1.29") {
-		return nil
-	}
-
-	return waitOnService(ctx, dockerCli, serviceID, options.quiet)
+	return nil
 }
 
 // nolint: gocyclo
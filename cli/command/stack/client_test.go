@@ -37,6 +37,13 @@ type fakeClient struct {
 
 	serviceUpdateFunc func(serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error)
 
+	secretInspectFunc func(id string) (swarm.Secret, []byte, error)
+	secretCreateFunc  func(secret swarm.SecretSpec) (types.SecretCreateResponse, error)
+	secretUpdateFunc  func(id string, version swarm.Version, secret swarm.SecretSpec) error
+	configInspectFunc func(id string) (swarm.Config, []byte, error)
+	configCreateFunc  func(config swarm.ConfigSpec) (types.ConfigCreateResponse, error)
+	configUpdateFunc  func(id string, version swarm.Version, config swarm.ConfigSpec) error
+
 	serviceRemoveFunc func(serviceID string) error
 	networkRemoveFunc func(networkID string) error
 	secretRemoveFunc  func(secretID string) error
@@ -143,6 +150,48 @@ func (cli *fakeClient) ServiceUpdate(ctx context.Context, serviceID string, vers
 	return types.ServiceUpdateResponse{}, nil
 }
 
+func (cli *fakeClient) SecretInspectWithRaw(ctx context.Context, id string) (swarm.Secret, []byte, error) {
+	if cli.secretInspectFunc != nil {
+		return cli.secretInspectFunc(id)
+	}
+	return swarm.Secret{}, nil, nil
+}
+
+func (cli *fakeClient) SecretCreate(ctx context.Context, secret swarm.SecretSpec) (types.SecretCreateResponse, error) {
+	if cli.secretCreateFunc != nil {
+		return cli.secretCreateFunc(secret)
+	}
+	return types.SecretCreateResponse{}, nil
+}
+
+func (cli *fakeClient) SecretUpdate(ctx context.Context, id string, version swarm.Version, secret swarm.SecretSpec) error {
+	if cli.secretUpdateFunc != nil {
+		return cli.secretUpdateFunc(id, version, secret)
+	}
+	return nil
+}
+
+func (cli *fakeClient) ConfigInspectWithRaw(ctx context.Context, id string) (swarm.Config, []byte, error) {
+	if cli.configInspectFunc != nil {
+		return cli.configInspectFunc(id)
+	}
+	return swarm.Config{}, nil, nil
+}
+
+func (cli *fakeClient) ConfigCreate(ctx context.Context, config swarm.ConfigSpec) (types.ConfigCreateResponse, error) {
+	if cli.configCreateFunc != nil {
+		return cli.configCreateFunc(config)
+	}
+	return types.ConfigCreateResponse{}, nil
+}
+
+func (cli *fakeClient) ConfigUpdate(ctx context.Context, id string, version swarm.Version, config swarm.ConfigSpec) error {
+	if cli.configUpdateFunc != nil {
+		return cli.configUpdateFunc(id, version, config)
+	}
+	return nil
+}
+
 func (cli *fakeClient) ServiceRemove(ctx context.Context, serviceID string) error {
 	if cli.serviceRemoveFunc != nil {
 		return cli.serviceRemoveFunc(serviceID)
@@ -214,12 +263,15 @@ func configFromName(name string) swarm.Config {
 }
 
 func namespaceFromFilters(filters filters.Args) string {
-	label := filters.Get("label")[0]
-	return strings.TrimPrefix(label, convert.LabelNamespace+"=")
+	labels := filters.Get("label")
+	if len(labels) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(labels[0], convert.LabelNamespace+"=")
 }
 
 func belongToNamespace(id, namespace string) bool {
-	return strings.HasPrefix(id, namespace+"_")
+	return namespace == "" || strings.HasPrefix(id, namespace+"_")
 }
 
 func objectName(namespace, name string) string {
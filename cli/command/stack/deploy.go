@@ -1,12 +1,8 @@
 package stack
 
 import (
-	"fmt"
-
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
-	"github.com/docker/cli/cli/compose/convert"
-	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/api/types/versions"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -27,6 +23,7 @@ type deployOptions struct {
 	resolveImage     string
 	sendRegistryAuth bool
 	prune            bool
+	pruneDryRun      bool
 }
 
 func newDeployCommand(dockerCli command.Cli) *cobra.Command {
@@ -49,6 +46,8 @@ func newDeployCommand(dockerCli command.Cli) *cobra.Command {
 	addRegistryAuthFlag(&opts.sendRegistryAuth, flags)
 	flags.BoolVar(&opts.prune, "prune", false, "Prune services that are no longer referenced")
 	flags.SetAnnotation("prune", "version", []string{"1.27"})
+	flags.BoolVar(&opts.pruneDryRun, "prune-dry-run", false, "List the objects that --prune would remove, without deploying")
+	flags.SetAnnotation("prune-dry-run", "version", []string{"1.27"})
 	flags.StringVar(&opts.resolveImage, "resolve-image", resolveImageAlways,
 		`Query the registry to resolve image digest and supported platforms ("`+resolveImageAlways+`"|"`+resolveImageChanged+`"|"`+resolveImageNever+`")`)
 	flags.SetAnnotation("resolve-image", "version", []string{"1.30"})
@@ -102,22 +101,3 @@ func checkDaemonIsSwarmManager(ctx context.Context, dockerCli command.Cli) error
 	}
 	return nil
 }
-
-// pruneServices removes services that are no longer referenced in the source
-func pruneServices(ctx context.Context, dockerCli command.Cli, namespace convert.Namespace, services map[string]struct{}) bool {
-	client := dockerCli.Client()
-
-	oldServices, err := getServices(ctx, client, namespace.Name())
-	if err != nil {
-		fmt.Fprintf(dockerCli.Err(), "Failed to list services: %s", err)
-		return true
-	}
-
-	pruneServices := []swarm.Service{}
-	for _, service := range oldServices {
-		if _, exists := services[namespace.Descope(service.Spec.Name)]; !exists {
-			pruneServices = append(pruneServices, service)
-		}
-	}
-	return removeServices(ctx, dockerCli, pruneServices)
-}
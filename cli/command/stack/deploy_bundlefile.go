@@ -21,23 +21,25 @@ func deployBundle(ctx context.Context, dockerCli command.Cli, opts deployOptions
 
 	namespace := convert.NewNamespace(opts.namespace)
 
-	if opts.prune {
-		services := map[string]struct{}{}
-		for service := range bundle.Services {
-			services[service] = struct{}{}
-		}
-		pruneServices(ctx, dockerCli, namespace, services)
-	}
-
+	declared := newStackObjects()
 	networks := make(map[string]types.NetworkCreate)
-	for _, service := range bundle.Services {
+	for internalName, service := range bundle.Services {
+		declared.services[internalName] = struct{}{}
 		for _, networkName := range service.Networks {
+			declared.networks[networkName] = struct{}{}
 			networks[networkName] = types.NetworkCreate{
 				Labels: convert.AddStackLabel(namespace, nil),
 			}
 		}
 	}
 
+	if opts.pruneDryRun {
+		return pruneDryRun(ctx, dockerCli, namespace, declared)
+	}
+	if opts.prune {
+		pruneServices(ctx, dockerCli, namespace, declared.services)
+	}
+
 	services := make(map[string]swarm.ServiceSpec)
 	for internalName, service := range bundle.Services {
 		name := namespace.Scope(internalName)
@@ -87,5 +89,12 @@ func deployBundle(ctx context.Context, dockerCli command.Cli, opts deployOptions
 	if err := createNetworks(ctx, dockerCli, namespace, networks); err != nil {
 		return err
 	}
-	return deployServices(ctx, dockerCli, services, namespace, opts.sendRegistryAuth, opts.resolveImage)
+	if err := deployServices(ctx, dockerCli, services, namespace, opts.sendRegistryAuth, opts.resolveImage); err != nil {
+		return err
+	}
+
+	if opts.prune {
+		pruneObjects(ctx, dockerCli, namespace, declared)
+	}
+	return nil
 }
@@ -1,6 +1,7 @@
 package stack
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/docker/cli/cli/command"
+	servicecli "github.com/docker/cli/cli/command/service"
 	"github.com/docker/cli/cli/compose/convert"
 	"github.com/docker/cli/cli/compose/loader"
 	composetypes "github.com/docker/cli/cli/compose/types"
@@ -19,6 +21,7 @@ import (
 	apiclient "github.com/docker/docker/client"
 	dockerclient "github.com/docker/docker/client"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 )
 
@@ -56,16 +59,17 @@ func deployCompose(ctx context.Context, dockerCli command.Cli, opts deployOption
 
 	namespace := convert.NewNamespace(opts.namespace)
 
+	serviceNetworks := getServicesDeclaredNetworks(config.Services)
+	networks, externalNetworks := convert.Networks(namespace, config.Networks, serviceNetworks)
+
+	declared := getDeclaredObjects(config, networks)
+	if opts.pruneDryRun {
+		return pruneDryRun(ctx, dockerCli, namespace, declared)
+	}
 	if opts.prune {
-		services := map[string]struct{}{}
-		for _, service := range config.Services {
-			services[service.Name] = struct{}{}
-		}
-		pruneServices(ctx, dockerCli, namespace, services)
+		pruneServices(ctx, dockerCli, namespace, declared.services)
 	}
 
-	serviceNetworks := getServicesDeclaredNetworks(config.Services)
-	networks, externalNetworks := convert.Networks(namespace, config.Networks, serviceNetworks)
 	if err := validateExternalNetworks(ctx, dockerCli.Client(), externalNetworks); err != nil {
 		return err
 	}
@@ -77,7 +81,8 @@ func deployCompose(ctx context.Context, dockerCli command.Cli, opts deployOption
 	if err != nil {
 		return err
 	}
-	if err := createSecrets(ctx, dockerCli, secrets); err != nil {
+	rotatedSecrets, err := createSecrets(ctx, dockerCli, secrets)
+	if err != nil {
 		return err
 	}
 
@@ -85,15 +90,68 @@ func deployCompose(ctx context.Context, dockerCli command.Cli, opts deployOption
 	if err != nil {
 		return err
 	}
-	if err := createConfigs(ctx, dockerCli, configs); err != nil {
+	rotatedConfigs, err := createConfigs(ctx, dockerCli, configs)
+	if err != nil {
 		return err
 	}
 
+	useRotatedObjects(namespace, config, rotatedSecrets, rotatedConfigs)
 	services, err := convert.Services(namespace, config, dockerCli.Client())
 	if err != nil {
 		return err
 	}
-	return deployServices(ctx, dockerCli, services, namespace, opts.sendRegistryAuth, opts.resolveImage)
+	checkPlacement(ctx, dockerCli, services)
+	if err := deployServices(ctx, dockerCli, services, namespace, opts.sendRegistryAuth, opts.resolveImage); err != nil {
+		return err
+	}
+
+	removeStaleObjects(ctx, dockerCli, namespace, secrets, rotatedSecrets, configs, rotatedConfigs)
+	if opts.prune {
+		pruneObjects(ctx, dockerCli, namespace, declared)
+	}
+	return nil
+}
+
+// getDeclaredObjects returns the services, secrets, configs and networks that
+// are part of the stack, excluding external secrets and configs.
+func getDeclaredObjects(config *composetypes.Config, networks map[string]types.NetworkCreate) stackObjects {
+	declared := newStackObjects()
+	for _, service := range config.Services {
+		declared.services[service.Name] = struct{}{}
+	}
+	for name, secret := range config.Secrets {
+		if !secret.External.External {
+			declared.secrets[name] = struct{}{}
+		}
+	}
+	for name, config := range config.Configs {
+		if !config.External.External {
+			declared.configs[name] = struct{}{}
+		}
+	}
+	for name := range networks {
+		declared.networks[name] = struct{}{}
+	}
+	return declared
+}
+
+// checkPlacement warns about services whose tasks can not be scheduled on any
+// node of the swarm.
+func checkPlacement(ctx context.Context, dockerCli command.Cli, services map[string]swarm.ServiceSpec) {
+	checker, err := servicecli.NewPlacementChecker(ctx, dockerCli.Client())
+	if err != nil {
+		logrus.Debugf("skipping the placement check of the stack services: %v", err)
+		return
+	}
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		spec := services[name]
+		servicecli.WarnIfUnschedulable(dockerCli.Err(), spec.Name, checker.Check(spec))
+	}
 }
 
 func getServicesDeclaredNetworks(serviceConfigs []composetypes.ServiceConfig) map[string]struct{} {
@@ -207,58 +265,135 @@ func validateExternalNetworks(
 	return nil
 }
 
+// createSecrets creates or updates the secrets of the stack. Secrets can not be
+// updated once created, so when the content of a secret changed, a new secret
+// is created with the content hash appended to its name. The returned map
+// holds the names of these rotated secrets, keyed by their name in the stack.
 func createSecrets(
 	ctx context.Context,
 	dockerCli command.Cli,
 	secrets []swarm.SecretSpec,
-) error {
+) (map[string]string, error) {
 	client := dockerCli.Client()
+	rotated := map[string]string{}
 
 	for _, secretSpec := range secrets {
-		secret, _, err := client.SecretInspectWithRaw(ctx, secretSpec.Name)
-		switch {
-		case err == nil:
+		name := secretSpec.Name
+		hash := secretSpec.Labels[convert.LabelContentHash]
+
+		secret, _, err := client.SecretInspectWithRaw(ctx, name)
+		if err != nil && !apiclient.IsErrNotFound(err) {
+			return nil, err
+		}
+		exists := err == nil
+		changed := exists && secret.Spec.Labels[convert.LabelContentHash] != hash
+
+		if changed && secret.Spec.Labels[convert.LabelContentHash] == "" {
+			// the content of secrets created before the hash label existed
+			// can't be compared. The update only adds the label, and is
+			// rejected by swarm if the content changed, in which case the
+			// secret is rotated.
+			if err := client.SecretUpdate(ctx, secret.ID, secret.Meta.Version, secretSpec); err == nil {
+				continue
+			}
+		}
+
+		if !exists || changed {
+			// the secret may already have been rotated by an earlier deploy
+			rotatedSecret, _, err := client.SecretInspectWithRaw(ctx, convert.RotatedName(name, hash))
+			switch {
+			case err == nil:
+				secret, exists = rotatedSecret, true
+				rotated[name] = rotatedSecret.Spec.Name
+			case !apiclient.IsErrNotFound(err):
+				return nil, err
+			case changed:
+				if err := convert.ValidateRotatedName(name, hash); err != nil {
+					return nil, errors.Wrapf(err, "failed to rotate secret %s", name)
+				}
+				secretSpec.Name = convert.RotatedName(name, hash)
+				rotated[name] = secretSpec.Name
+				exists = false
+				fmt.Fprintf(dockerCli.Out(), "Rotating secret %s to %s\n", name, secretSpec.Name)
+			}
+		}
+
+		if exists {
 			// secret already exists, then we update that
+			secretSpec.Name = secret.Spec.Name
 			if err := client.SecretUpdate(ctx, secret.ID, secret.Meta.Version, secretSpec); err != nil {
-				return errors.Wrapf(err, "failed to update secret %s", secretSpec.Name)
-			}
-		case apiclient.IsErrSecretNotFound(err):
-			// secret does not exist, then we create a new one.
-			if _, err := client.SecretCreate(ctx, secretSpec); err != nil {
-				return errors.Wrapf(err, "failed to create secret %s", secretSpec.Name)
+				return nil, errors.Wrapf(err, "failed to update secret %s", secretSpec.Name)
 			}
-		default:
-			return err
+			continue
+		}
+		// secret does not exist, then we create a new one.
+		if _, err := client.SecretCreate(ctx, secretSpec); err != nil {
+			return nil, errors.Wrapf(err, "failed to create secret %s", secretSpec.Name)
 		}
 	}
-	return nil
+	return rotated, nil
 }
 
+// createConfigs creates or updates the configs of the stack, rotating the
+// configs whose content changed in the same way as createSecrets.
 func createConfigs(
 	ctx context.Context,
 	dockerCli command.Cli,
 	configs []swarm.ConfigSpec,
-) error {
+) (map[string]string, error) {
 	client := dockerCli.Client()
+	rotated := map[string]string{}
 
 	for _, configSpec := range configs {
-		config, _, err := client.ConfigInspectWithRaw(ctx, configSpec.Name)
-		switch {
-		case err == nil:
+		name := configSpec.Name
+		hash := configSpec.Labels[convert.LabelContentHash]
+
+		config, _, err := client.ConfigInspectWithRaw(ctx, name)
+		if err != nil && !apiclient.IsErrNotFound(err) {
+			return nil, err
+		}
+		exists := err == nil
+		// unlike secrets, the content of a config can be compared directly,
+		// so configs created before the hash label existed are not rotated
+		// needlessly.
+		changed := exists &&
+			config.Spec.Labels[convert.LabelContentHash] != hash &&
+			!bytes.Equal(config.Spec.Data, configSpec.Data)
+
+		if !exists || changed {
+			// the config may already have been rotated by an earlier deploy
+			rotatedConfig, _, err := client.ConfigInspectWithRaw(ctx, convert.RotatedName(name, hash))
+			switch {
+			case err == nil:
+				config, exists = rotatedConfig, true
+				rotated[name] = rotatedConfig.Spec.Name
+			case !apiclient.IsErrNotFound(err):
+				return nil, err
+			case changed:
+				if err := convert.ValidateRotatedName(name, hash); err != nil {
+					return nil, errors.Wrapf(err, "failed to rotate config %s", name)
+				}
+				configSpec.Name = convert.RotatedName(name, hash)
+				rotated[name] = configSpec.Name
+				exists = false
+				fmt.Fprintf(dockerCli.Out(), "Rotating config %s to %s\n", name, configSpec.Name)
+			}
+		}
+
+		if exists {
 			// config already exists, then we update that
+			configSpec.Name = config.Spec.Name
 			if err := client.ConfigUpdate(ctx, config.ID, config.Meta.Version, configSpec); err != nil {
-				errors.Wrapf(err, "failed to update config %s", configSpec.Name)
+				return nil, errors.Wrapf(err, "failed to update config %s", configSpec.Name)
 			}
-		case apiclient.IsErrConfigNotFound(err):
-			// config does not exist, then we create a new one.
-			if _, err := client.ConfigCreate(ctx, configSpec); err != nil {
-				errors.Wrapf(err, "failed to create config %s", configSpec.Name)
-			}
-		default:
-			return err
+			continue
+		}
+		// config does not exist, then we create a new one.
+		if _, err := client.ConfigCreate(ctx, configSpec); err != nil {
+			return nil, errors.Wrapf(err, "failed to create config %s", configSpec.Name)
 		}
 	}
-	return nil
+	return rotated, nil
 }
 
 func createNetworks(
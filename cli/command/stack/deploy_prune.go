@@ -0,0 +1,254 @@
+package stack
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/compose/convert"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/docker/client"
+	"golang.org/x/net/context"
+)
+
+// stackObjects holds the unscoped names of the objects declared in the source
+// of a stack
+type stackObjects struct {
+	services map[string]struct{}
+	secrets  map[string]struct{}
+	configs  map[string]struct{}
+	networks map[string]struct{}
+}
+
+func newStackObjects() stackObjects {
+	return stackObjects{
+		services: map[string]struct{}{},
+		secrets:  map[string]struct{}{},
+		configs:  map[string]struct{}{},
+		networks: map[string]struct{}{},
+	}
+}
+
+// prunableObjects holds the objects of a stack that are no longer declared in
+// its source
+type prunableObjects struct {
+	secrets  []swarm.Secret
+	configs  []swarm.Config
+	networks []types.NetworkResource
+
+	// inUse maps a description of each object that is no longer declared,
+	// but still referenced by a service, to the name of that service
+	inUse map[string]string
+}
+
+// serviceReferences holds the IDs and names of the objects referenced by
+// services, mapped to the name of one of the services referencing them
+type serviceReferences struct {
+	secrets  map[string]string
+	configs  map[string]string
+	networks map[string]string
+}
+
+// pruneServices removes services that are no longer referenced in the source
+func pruneServices(ctx context.Context, dockerCli command.Cli, namespace convert.Namespace, services map[string]struct{}) bool {
+	client := dockerCli.Client()
+
+	oldServices, err := getServices(ctx, client, namespace.Name())
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Failed to list services: %s", err)
+		return true
+	}
+
+	return removeServices(ctx, dockerCli, getPrunableServices(namespace, oldServices, services))
+}
+
+// pruneObjects removes the secrets, configs and networks of the stack that are
+// no longer referenced in the source. Objects that are still in use by a
+// service, whether or not it is part of the stack, are left in place.
+func pruneObjects(ctx context.Context, dockerCli command.Cli, namespace convert.Namespace, declared stackObjects) bool {
+	prunable, err := getPrunableObjects(ctx, dockerCli.Client(), namespace, declared, nil)
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Failed to list stack objects: %s", err)
+		return true
+	}
+
+	printInUse(dockerCli, prunable.inUse)
+	hasError := removeSecrets(ctx, dockerCli, prunable.secrets)
+	hasError = removeConfigs(ctx, dockerCli, prunable.configs) || hasError
+	hasError = removeNetworks(ctx, dockerCli, prunable.networks) || hasError
+	return hasError
+}
+
+// pruneDryRun lists the services, secrets, configs and networks that --prune
+// would remove, based on the current state of the swarm, without removing them.
+func pruneDryRun(ctx context.Context, dockerCli command.Cli, namespace convert.Namespace, declared stackObjects) error {
+	client := dockerCli.Client()
+	out := dockerCli.Out()
+
+	oldServices, err := getServices(ctx, client, namespace.Name())
+	if err != nil {
+		return err
+	}
+	services := getPrunableServices(namespace, oldServices, declared.services)
+	removed := make(map[string]struct{}, len(services))
+	for _, service := range services {
+		removed[service.ID] = struct{}{}
+	}
+
+	prunable, err := getPrunableObjects(ctx, client, namespace, declared, removed)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(services, sortServiceByName(services))
+	for _, service := range services {
+		fmt.Fprintf(out, "Would remove service %s\n", service.Spec.Name)
+	}
+	for _, secret := range prunable.secrets {
+		fmt.Fprintf(out, "Would remove secret %s\n", secret.Spec.Name)
+	}
+	for _, config := range prunable.configs {
+		fmt.Fprintf(out, "Would remove config %s\n", config.Spec.Name)
+	}
+	for _, network := range prunable.networks {
+		fmt.Fprintf(out, "Would remove network %s\n", network.Name)
+	}
+	printInUse(dockerCli, prunable.inUse)
+	return nil
+}
+
+func printInUse(dockerCli command.Cli, inUse map[string]string) {
+	objects := make([]string, 0, len(inUse))
+	for object := range inUse {
+		objects = append(objects, object)
+	}
+	sort.Strings(objects)
+	for _, object := range objects {
+		fmt.Fprintf(dockerCli.Out(), "Skipping %s: still in use by service %s\n", object, inUse[object])
+	}
+}
+
+func getPrunableServices(namespace convert.Namespace, services []swarm.Service, declared map[string]struct{}) []swarm.Service {
+	prunable := []swarm.Service{}
+	for _, service := range services {
+		if _, exists := declared[namespace.Descope(service.Spec.Name)]; !exists {
+			prunable = append(prunable, service)
+		}
+	}
+	return prunable
+}
+
+// getPrunableObjects returns the secrets, configs and networks of the stack
+// that are not part of the declared objects. Services whose ID is in ignore
+// are not taken into account when checking if an object is still in use.
+func getPrunableObjects(
+	ctx context.Context,
+	apiclient client.APIClient,
+	namespace convert.Namespace,
+	declared stackObjects,
+	ignore map[string]struct{},
+) (prunableObjects, error) {
+	prunable := prunableObjects{inUse: map[string]string{}}
+
+	services, err := apiclient.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return prunable, err
+	}
+	refs := getServiceReferences(services, ignore)
+
+	secrets, err := getStackSecrets(ctx, apiclient, namespace.Name())
+	if err != nil {
+		return prunable, err
+	}
+	for _, secret := range secrets {
+		if _, exists := declared.secrets[namespace.Descope(convert.UnrotatedName(secret.Spec.Name, secret.Spec.Labels))]; exists {
+			continue
+		}
+		if service, ok := lookupReference(refs.secrets, secret.ID, secret.Spec.Name); ok {
+			prunable.inUse["secret "+secret.Spec.Name] = service
+			continue
+		}
+		prunable.secrets = append(prunable.secrets, secret)
+	}
+
+	if versions.GreaterThanOrEqualTo(apiclient.ClientVersion(), "1.30") {
+		configs, err := getStackConfigs(ctx, apiclient, namespace.Name())
+		if err != nil {
+			return prunable, err
+		}
+		for _, config := range configs {
+			if _, exists := declared.configs[namespace.Descope(convert.UnrotatedName(config.Spec.Name, config.Spec.Labels))]; exists {
+				continue
+			}
+			if service, ok := lookupReference(refs.configs, config.ID, config.Spec.Name); ok {
+				prunable.inUse["config "+config.Spec.Name] = service
+				continue
+			}
+			prunable.configs = append(prunable.configs, config)
+		}
+	}
+
+	networks, err := getStackNetworks(ctx, apiclient, namespace.Name())
+	if err != nil {
+		return prunable, err
+	}
+	for _, network := range networks {
+		if _, exists := declared.networks[namespace.Descope(network.Name)]; exists {
+			continue
+		}
+		if service, ok := lookupReference(refs.networks, network.ID, network.Name); ok {
+			prunable.inUse["network "+network.Name] = service
+			continue
+		}
+		prunable.networks = append(prunable.networks, network)
+	}
+	return prunable, nil
+}
+
+func getServiceReferences(services []swarm.Service, ignore map[string]struct{}) serviceReferences {
+	refs := serviceReferences{
+		secrets:  map[string]string{},
+		configs:  map[string]string{},
+		networks: map[string]string{},
+	}
+	for _, service := range services {
+		if _, ignored := ignore[service.ID]; ignored {
+			continue
+		}
+		name := service.Spec.Name
+		if spec := service.Spec.TaskTemplate.ContainerSpec; spec != nil {
+			for _, secret := range spec.Secrets {
+				addReference(refs.secrets, name, secret.SecretID, secret.SecretName)
+			}
+			for _, config := range spec.Configs {
+				addReference(refs.configs, name, config.ConfigID, config.ConfigName)
+			}
+		}
+		// Services created through an older API version may still have their
+		// networks attached to the ServiceSpec instead of the TaskSpec.
+		for _, network := range append(service.Spec.TaskTemplate.Networks, service.Spec.Networks...) {
+			addReference(refs.networks, name, network.Target)
+		}
+	}
+	return refs
+}
+
+func addReference(refs map[string]string, service string, idOrNames ...string) {
+	for _, idOrName := range idOrNames {
+		if idOrName != "" {
+			refs[idOrName] = service
+		}
+	}
+}
+
+// lookupReference returns the name of a service referencing the object with
+// the given ID or name.
+func lookupReference(refs map[string]string, id, name string) (string, bool) {
+	if service, ok := refs[id]; ok {
+		return service, true
+	}
+	service, ok := refs[name]
+	return service, ok
+}
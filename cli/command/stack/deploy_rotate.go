@@ -0,0 +1,108 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/compose/convert"
+	composetypes "github.com/docker/cli/cli/compose/types"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"golang.org/x/net/context"
+)
+
+// useRotatedObjects makes the services of the stack reference the rotated
+// secrets and configs instead of the ones with the name declared in the stack.
+// The rotated objects are marked as external so that they are used by their
+// name as-is, and the target name inside the container remains unchanged.
+func useRotatedObjects(namespace convert.Namespace, config *composetypes.Config, rotatedSecrets, rotatedConfigs map[string]string) {
+	for name, rotated := range rotatedSecrets {
+		internalName := namespace.Descope(name)
+		secret := config.Secrets[internalName]
+		secret.External = composetypes.External{External: true, Name: rotated}
+		config.Secrets[internalName] = secret
+	}
+	for name, rotated := range rotatedConfigs {
+		internalName := namespace.Descope(name)
+		configObj := config.Configs[internalName]
+		configObj.External = composetypes.External{External: true, Name: rotated}
+		config.Configs[internalName] = configObj
+	}
+}
+
+// removeStaleObjects removes the previous versions of rotated secrets and
+// configs of the stack, once they are no longer used by any service.
+func removeStaleObjects(
+	ctx context.Context,
+	dockerCli command.Cli,
+	namespace convert.Namespace,
+	secrets []swarm.SecretSpec,
+	rotatedSecrets map[string]string,
+	configs []swarm.ConfigSpec,
+	rotatedConfigs map[string]string,
+) bool {
+	client := dockerCli.Client()
+	if len(secrets)+len(configs) == 0 {
+		return false
+	}
+
+	services, err := client.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Failed to list services: %s", err)
+		return true
+	}
+	refs := getServiceReferences(services, nil)
+
+	currentSecrets := map[string]string{}
+	for _, secret := range secrets {
+		currentSecrets[secret.Name] = currentName(secret.Name, rotatedSecrets)
+	}
+	staleSecrets := []swarm.Secret{}
+	existingSecrets, err := getStackSecrets(ctx, client, namespace.Name())
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Failed to list secrets: %s", err)
+		return true
+	}
+	for _, secret := range existingSecrets {
+		current, declared := currentSecrets[convert.UnrotatedName(secret.Spec.Name, secret.Spec.Labels)]
+		if !declared || current == secret.Spec.Name {
+			continue
+		}
+		if _, inUse := lookupReference(refs.secrets, secret.ID, secret.Spec.Name); !inUse {
+			staleSecrets = append(staleSecrets, secret)
+		}
+	}
+
+	currentConfigs := map[string]string{}
+	for _, config := range configs {
+		currentConfigs[config.Name] = currentName(config.Name, rotatedConfigs)
+	}
+	staleConfigs := []swarm.Config{}
+	var existingConfigs []swarm.Config
+	if len(configs) > 0 {
+		existingConfigs, err = getStackConfigs(ctx, client, namespace.Name())
+		if err != nil {
+			fmt.Fprintf(dockerCli.Err(), "Failed to list configs: %s", err)
+			return true
+		}
+	}
+	for _, config := range existingConfigs {
+		current, declared := currentConfigs[convert.UnrotatedName(config.Spec.Name, config.Spec.Labels)]
+		if !declared || current == config.Spec.Name {
+			continue
+		}
+		if _, inUse := lookupReference(refs.configs, config.ID, config.Spec.Name); !inUse {
+			staleConfigs = append(staleConfigs, config)
+		}
+	}
+
+	hasError := removeSecrets(ctx, dockerCli, staleSecrets)
+	return removeConfigs(ctx, dockerCli, staleConfigs) || hasError
+}
+
+func currentName(name string, rotated map[string]string) string {
+	if current, ok := rotated[name]; ok {
+		return current
+	}
+	return name
+}
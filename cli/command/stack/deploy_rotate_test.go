@@ -0,0 +1,230 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/cli/cli/compose/convert"
+	composetypes "github.com/docker/cli/cli/compose/types"
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func secretSpecWithContent(name string, content string) swarm.SecretSpec {
+	return swarm.SecretSpec{
+		Annotations: swarm.Annotations{
+			Name:   name,
+			Labels: map[string]string{convert.LabelContentHash: convert.ContentHash([]byte(content))},
+		},
+		Data: []byte(content),
+	}
+}
+
+func TestCreateSecretsRotatesChangedSecret(t *testing.T) {
+	spec := secretSpecWithContent("foo_cert", "new")
+	newName := convert.RotatedName("foo_cert", convert.ContentHash([]byte("new")))
+
+	var created []string
+	client := &fakeClient{
+		secretInspectFunc: func(id string) (swarm.Secret, []byte, error) {
+			if id == "foo_cert" {
+				return swarm.Secret{ID: "ID-foo_cert", Spec: secretSpecWithContent("foo_cert", "old")}, nil, nil
+			}
+			return swarm.Secret{}, nil, notFound{}
+		},
+		secretCreateFunc: func(secret swarm.SecretSpec) (types.SecretCreateResponse, error) {
+			created = append(created, secret.Name)
+			return types.SecretCreateResponse{}, nil
+		},
+	}
+
+	rotated, err := createSecrets(context.Background(), test.NewFakeCli(client), []swarm.SecretSpec{spec})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo_cert": newName}, rotated)
+	assert.Equal(t, []string{newName}, created)
+}
+
+func TestCreateSecretsReusesRotatedSecret(t *testing.T) {
+	spec := secretSpecWithContent("foo_cert", "new")
+	newName := convert.RotatedName("foo_cert", convert.ContentHash([]byte("new")))
+
+	var updated []string
+	client := &fakeClient{
+		secretInspectFunc: func(id string) (swarm.Secret, []byte, error) {
+			if id == newName {
+				return swarm.Secret{ID: "ID-" + newName, Spec: secretSpecWithContent(newName, "new")}, nil, nil
+			}
+			return swarm.Secret{}, nil, notFound{}
+		},
+		secretCreateFunc: func(secret swarm.SecretSpec) (types.SecretCreateResponse, error) {
+			t.Fatalf("unexpected secret create %s", secret.Name)
+			return types.SecretCreateResponse{}, nil
+		},
+		secretUpdateFunc: func(id string, version swarm.Version, secret swarm.SecretSpec) error {
+			updated = append(updated, secret.Name)
+			return nil
+		},
+	}
+
+	rotated, err := createSecrets(context.Background(), test.NewFakeCli(client), []swarm.SecretSpec{spec})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo_cert": newName}, rotated)
+	assert.Equal(t, []string{newName}, updated)
+}
+
+func TestCreateSecretsAdoptsUnlabeledSecret(t *testing.T) {
+	spec := secretSpecWithContent("foo_cert", "same")
+
+	var updated []swarm.SecretSpec
+	client := &fakeClient{
+		secretInspectFunc: func(id string) (swarm.Secret, []byte, error) {
+			if id == "foo_cert" {
+				// created before the content hash label existed
+				return swarm.Secret{ID: "ID-foo_cert", Spec: swarm.SecretSpec{Annotations: swarm.Annotations{Name: id}}}, nil, nil
+			}
+			return swarm.Secret{}, nil, notFound{}
+		},
+		secretCreateFunc: func(secret swarm.SecretSpec) (types.SecretCreateResponse, error) {
+			t.Fatalf("unexpected secret create %s", secret.Name)
+			return types.SecretCreateResponse{}, nil
+		},
+		secretUpdateFunc: func(id string, version swarm.Version, secret swarm.SecretSpec) error {
+			updated = append(updated, secret)
+			return nil
+		},
+	}
+
+	rotated, err := createSecrets(context.Background(), test.NewFakeCli(client), []swarm.SecretSpec{spec})
+	require.NoError(t, err)
+	assert.Empty(t, rotated)
+	assert.Equal(t, []swarm.SecretSpec{spec}, updated)
+}
+
+func TestCreateSecretsRotatesUnlabeledSecretWithChangedContent(t *testing.T) {
+	spec := secretSpecWithContent("foo_cert", "new")
+	newName := convert.RotatedName("foo_cert", convert.ContentHash([]byte("new")))
+
+	var created []string
+	client := &fakeClient{
+		secretInspectFunc: func(id string) (swarm.Secret, []byte, error) {
+			if id == "foo_cert" {
+				return swarm.Secret{ID: "ID-foo_cert", Spec: swarm.SecretSpec{Annotations: swarm.Annotations{Name: id}}}, nil, nil
+			}
+			return swarm.Secret{}, nil, notFound{}
+		},
+		secretCreateFunc: func(secret swarm.SecretSpec) (types.SecretCreateResponse, error) {
+			created = append(created, secret.Name)
+			return types.SecretCreateResponse{}, nil
+		},
+		secretUpdateFunc: func(id string, version swarm.Version, secret swarm.SecretSpec) error {
+			return errors.New("only updates to Labels are allowed")
+		},
+	}
+
+	rotated, err := createSecrets(context.Background(), test.NewFakeCli(client), []swarm.SecretSpec{spec})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo_cert": newName}, rotated)
+	assert.Equal(t, []string{newName}, created)
+}
+
+func TestCreateSecretsRotatedNameTooLong(t *testing.T) {
+	name := "foo_" + strings.Repeat("a", 50)
+	spec := secretSpecWithContent(name, "new")
+
+	client := &fakeClient{
+		secretInspectFunc: func(id string) (swarm.Secret, []byte, error) {
+			if id == name {
+				return swarm.Secret{ID: "ID-" + name, Spec: secretSpecWithContent(name, "old")}, nil, nil
+			}
+			return swarm.Secret{}, nil, notFound{}
+		},
+		secretCreateFunc: func(secret swarm.SecretSpec) (types.SecretCreateResponse, error) {
+			t.Fatalf("unexpected secret create %s", secret.Name)
+			return types.SecretCreateResponse{}, nil
+		},
+	}
+
+	_, err := createSecrets(context.Background(), test.NewFakeCli(client), []swarm.SecretSpec{spec})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "longer than 64 characters")
+}
+
+func TestCreateConfigsDoesNotRotateUnchangedConfig(t *testing.T) {
+	spec := swarm.ConfigSpec{
+		Annotations: swarm.Annotations{
+			Name:   "foo_conf",
+			Labels: map[string]string{convert.LabelContentHash: convert.ContentHash([]byte("same"))},
+		},
+		Data: []byte("same"),
+	}
+
+	var updated []string
+	client := &fakeClient{
+		configInspectFunc: func(id string) (swarm.Config, []byte, error) {
+			// created before the content hash label existed
+			return swarm.Config{ID: "ID-" + id, Spec: swarm.ConfigSpec{
+				Annotations: swarm.Annotations{Name: id},
+				Data:        []byte("same"),
+			}}, nil, nil
+		},
+		configUpdateFunc: func(id string, version swarm.Version, config swarm.ConfigSpec) error {
+			updated = append(updated, config.Name)
+			return nil
+		},
+	}
+
+	rotated, err := createConfigs(context.Background(), test.NewFakeCli(client), []swarm.ConfigSpec{spec})
+	require.NoError(t, err)
+	assert.Empty(t, rotated)
+	assert.Equal(t, []string{"foo_conf"}, updated)
+}
+
+func TestUseRotatedObjects(t *testing.T) {
+	namespace := convert.NewNamespace("foo")
+	config := &composetypes.Config{
+		Secrets: map[string]composetypes.SecretConfig{
+			"cert":  {File: "cert.pem"},
+			"other": {File: "other.pem"},
+		},
+		Configs: map[string]composetypes.ConfigObjConfig{
+			"conf": {File: "nginx.conf"},
+		},
+	}
+
+	useRotatedObjects(namespace, config,
+		map[string]string{"foo_cert": "foo_cert_abc"},
+		map[string]string{"foo_conf": "foo_conf_def"})
+
+	assert.Equal(t, composetypes.External{External: true, Name: "foo_cert_abc"}, config.Secrets["cert"].External)
+	assert.Equal(t, composetypes.External{}, config.Secrets["other"].External)
+	assert.Equal(t, composetypes.External{External: true, Name: "foo_conf_def"}, config.Configs["conf"].External)
+}
+
+func TestRemoveStaleObjects(t *testing.T) {
+	namespace := convert.NewNamespace("foo")
+	hash := convert.ContentHash([]byte("new"))
+	current := convert.RotatedName("foo_cert", hash)
+
+	client := &fakeClient{
+		secretListFunc: func(options types.SecretListOptions) ([]swarm.Secret, error) {
+			return []swarm.Secret{
+				secretFromName("foo_cert"),
+				{ID: "ID-" + current, Spec: secretSpecWithContent(current, "new")},
+				secretFromName("foo_unrelated"),
+			}, nil
+		},
+	}
+	dockerCli := test.NewFakeCli(client)
+
+	hasError := removeStaleObjects(context.Background(), dockerCli, namespace,
+		[]swarm.SecretSpec{secretSpecWithContent("foo_cert", "new")},
+		map[string]string{"foo_cert": current},
+		nil, nil)
+	assert.False(t, hasError)
+	assert.Equal(t, []string{"ID-foo_cert"}, client.removedSecrets)
+}
@@ -25,6 +25,63 @@ func TestPruneServices(t *testing.T) {
 	assert.Equal(t, buildObjectIDs([]string{objectName("foo", "remove")}), client.removedServices)
 }
 
+func TestPruneObjects(t *testing.T) {
+	ctx := context.Background()
+	namespace := convert.NewNamespace("foo")
+	declared := newStackObjects()
+	declared.secrets["keep"] = struct{}{}
+	declared.configs["keep"] = struct{}{}
+	declared.networks["keep"] = struct{}{}
+
+	client := &fakeClient{
+		version:  "1.30",
+		secrets:  []string{objectName("foo", "keep"), objectName("foo", "remove"), objectName("foo", "used")},
+		configs:  []string{objectName("foo", "keep"), objectName("foo", "remove"), objectName("foo", "used")},
+		networks: []string{objectName("foo", "keep"), objectName("foo", "remove"), objectName("foo", "used")},
+		serviceListFunc: func(options types.ServiceListOptions) ([]swarm.Service, error) {
+			service := serviceFromName("other")
+			service.Spec.TaskTemplate = swarm.TaskSpec{
+				ContainerSpec: &swarm.ContainerSpec{
+					Secrets: []*swarm.SecretReference{{SecretID: objectID(objectName("foo", "used"))}},
+					Configs: []*swarm.ConfigReference{{ConfigName: objectName("foo", "used")}},
+				},
+				Networks: []swarm.NetworkAttachmentConfig{{Target: objectID(objectName("foo", "used"))}},
+			}
+			return []swarm.Service{service}, nil
+		},
+	}
+	dockerCli := test.NewFakeCli(client)
+
+	hasError := pruneObjects(ctx, dockerCli, namespace, declared)
+	assert.False(t, hasError)
+	removed := buildObjectIDs([]string{objectName("foo", "remove")})
+	assert.Equal(t, removed, client.removedSecrets)
+	assert.Equal(t, removed, client.removedConfigs)
+	assert.Equal(t, removed, client.removedNetworks)
+	assert.Contains(t, dockerCli.OutBuffer().String(), "Skipping secret foo_used: still in use by service other\n")
+}
+
+func TestPruneDryRun(t *testing.T) {
+	ctx := context.Background()
+	namespace := convert.NewNamespace("foo")
+	declared := newStackObjects()
+	declared.services["keep"] = struct{}{}
+	declared.secrets["keep"] = struct{}{}
+
+	client := &fakeClient{
+		version:  "1.30",
+		services: []string{objectName("foo", "keep"), objectName("foo", "remove")},
+		secrets:  []string{objectName("foo", "keep"), objectName("foo", "remove")},
+	}
+	dockerCli := test.NewFakeCli(client)
+
+	err := pruneDryRun(ctx, dockerCli, namespace, declared)
+	assert.NoError(t, err)
+	assert.Equal(t, "Would remove service foo_remove\nWould remove secret foo_remove\n", dockerCli.OutBuffer().String())
+	assert.Empty(t, client.removedServices)
+	assert.Empty(t, client.removedSecrets)
+}
+
 // TestServiceUpdateResolveImageChanged tests that the service's
 // image digest is preserved if the image did not change in the compose file
 func TestServiceUpdateResolveImageChanged(t *testing.T) {
@@ -0,0 +1,44 @@
+package stack
+
+import (
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/service"
+	"github.com/docker/cli/cli/compose/convert"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+type exportOptions struct {
+	namespace string
+}
+
+func newExportCommand(dockerCli command.Cli) *cobra.Command {
+	var opts exportOptions
+
+	cmd := &cobra.Command{
+		Use:   "export STACK",
+		Short: "Export the services of a stack as a compose file",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.namespace = args[0]
+			return runExport(dockerCli, opts)
+		},
+	}
+	return cmd
+}
+
+func runExport(dockerCli command.Cli, opts exportOptions) error {
+	ctx := context.Background()
+	client := dockerCli.Client()
+
+	services, err := getServices(ctx, client, opts.namespace)
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		return errors.Errorf("Nothing found in stack: %s", opts.namespace)
+	}
+	return service.WriteCompose(ctx, client, dockerCli.Out(), convert.NewNamespace(opts.namespace), services)
+}
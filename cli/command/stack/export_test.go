@@ -0,0 +1,40 @@
+package stack
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/docker/cli/cli/compose/convert"
+	"github.com/docker/cli/internal/test"
+	// Import builders to get the builder function as package function
+	. "github.com/docker/cli/internal/test/builders"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStackExportNothingFound(t *testing.T) {
+	cmd := newExportCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"foo"})
+	cmd.SetOutput(ioutil.Discard)
+	testutil.ErrorContains(t, cmd.Execute(), "Nothing found in stack: foo")
+}
+
+func TestStackExport(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		serviceListFunc: func(options types.ServiceListOptions) ([]swarm.Service, error) {
+			assert.Equal(t, []string{convert.LabelNamespace + "=foo"}, options.Filters.Get("label"))
+			return []swarm.Service{
+				*Service(ServiceName("foo_web"), ServiceImage("nginx:1.13"), ServiceLabels(map[string]string{convert.LabelNamespace: "foo"})),
+				*Service(ServiceName("foo_db"), ServiceImage("postgres:10"), ServiceLabels(map[string]string{convert.LabelNamespace: "foo"})),
+			}, nil
+		},
+	})
+	cmd := newExportCommand(cli)
+	cmd.SetArgs([]string{"foo"})
+	require.NoError(t, cmd.Execute())
+	golden.Assert(t, cli.OutBuffer().String(), "stack-export.golden")
+}
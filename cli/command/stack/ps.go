@@ -20,6 +20,7 @@ type psOptions struct {
 	noResolve bool
 	quiet     bool
 	format    string
+	why       task.WhyOptions
 }
 
 func newPsCommand(dockerCli command.Cli) *cobra.Command {
@@ -40,6 +41,7 @@ func newPsCommand(dockerCli command.Cli) *cobra.Command {
 	flags.VarP(&options.filter, "filter", "f", "Filter output based on conditions provided")
 	flags.BoolVarP(&options.quiet, "quiet", "q", false, "Only display task IDs")
 	flags.StringVar(&options.format, "format", "", "Pretty-print tasks using a Go template")
+	task.AddWhyFlags(flags, &options.why)
 
 	return cmd
 }
@@ -49,6 +51,10 @@ func runPS(dockerCli command.Cli, options psOptions) error {
 	client := dockerCli.Client()
 	ctx := context.Background()
 
+	if err := options.why.Validate(options.quiet, options.format); err != nil {
+		return err
+	}
+
 	filter := getStackFilterFromOpt(options.namespace, options.filter)
 
 	tasks, err := client.TaskList(ctx, types.TaskListOptions{Filters: filter})
@@ -60,10 +66,15 @@ func runPS(dockerCli command.Cli, options psOptions) error {
 		return fmt.Errorf("nothing found in stack: %s", namespace)
 	}
 
+	resolver := idresolver.New(client, options.noResolve)
+	if options.why.Enabled {
+		return task.PrintWhy(ctx, dockerCli, tasks, resolver, options.why)
+	}
+
 	format := options.format
 	if len(format) == 0 {
 		format = task.DefaultFormat(dockerCli.ConfigFile(), options.quiet)
 	}
 
-	return task.Print(ctx, dockerCli, tasks, idresolver.New(client, options.noResolve), !options.noTrunc, options.quiet, format)
+	return task.Print(ctx, dockerCli, tasks, resolver, !options.noTrunc, options.quiet, format)
 }
@@ -65,6 +65,27 @@ func TestStackPsEmptyStack(t *testing.T) {
 	assert.Equal(t, "", fakeCli.OutBuffer().String())
 }
 
+func TestStackPsWhy(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
+			return []swarm.Task{
+				*Task(TaskID("task-id-1"), TaskServiceID("service-id-foo"),
+					WithStatus(TaskState(swarm.TaskStateRejected), StatusErr("no suitable node (scheduling constraints not satisfied on 3 nodes)"))),
+				*Task(TaskID("task-id-2"), TaskServiceID("service-id-foo")),
+			}, nil
+		},
+	})
+	cmd := newPsCommand(cli)
+	cmd.SetArgs([]string{"--why", "--no-resolve", "foo"})
+	assert.NoError(t, cmd.Execute())
+	assert.Contains(t, cli.OutBuffer().String(), "service-id-foo: 1 task(s) rejected before being assigned to a node\n")
+
+	cmd = newPsCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"--why", "--quiet", "foo"})
+	cmd.SetOutput(ioutil.Discard)
+	assert.EqualError(t, cmd.Execute(), "--why can not be combined with --quiet or --format")
+}
+
 func TestStackPsWithQuietOption(t *testing.T) {
 	cli := test.NewFakeCli(&fakeClient{
 		taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
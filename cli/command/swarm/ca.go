@@ -1,16 +1,26 @@
 package swarm
 
 import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"reflect"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/swarm/progress"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/pkg/jsonmessage"
+	units "github.com/docker/go-units"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -22,6 +32,7 @@ type caOptions struct {
 	rootCACert PEMFile
 	rootCAKey  PEMFile
 	rotate     bool
+	inspect    bool
 	detach     bool
 	quiet      bool
 }
@@ -44,6 +55,7 @@ func newCACommand(dockerCli command.Cli) *cobra.Command {
 	flags.BoolVar(&opts.rotate, flagRotate, false, "Rotate the swarm CA - if no certificate or key are provided, new ones will be generated")
 	flags.Var(&opts.rootCACert, flagCACert, "Path to the PEM-formatted root CA certificate to use for the new cluster")
 	flags.Var(&opts.rootCAKey, flagCAKey, "Path to the PEM-formatted root CA key to use for the new cluster")
+	flags.BoolVar(&opts.inspect, "inspect", false, "Display the expiry of the root CA, and the issuer of the certificate of each node")
 
 	flags.BoolVarP(&opts.detach, "detach", "d", false, "Exit immediately instead of waiting for the root rotation to converge")
 	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Suppress progress output")
@@ -59,12 +71,22 @@ func runCA(dockerCli command.Cli, flags *pflag.FlagSet, opts caOptions) error {
 		return err
 	}
 
+	if opts.inspect && opts.rotate {
+		return errors.New("--inspect can not be used with --rotate")
+	}
 	if !opts.rotate {
 		for _, f := range []string{flagCACert, flagCAKey, flagCertExpiry, flagExternalCA} {
 			if flags.Changed(f) {
 				return fmt.Errorf("`--%s` flag requires the `--rotate` flag to update the CA", f)
 			}
 		}
+		if opts.inspect {
+			nodes, err := client.NodeList(ctx, types.NodeListOptions{})
+			if err != nil {
+				return err
+			}
+			return displayCertificates(dockerCli.Out(), swarmInspect, nodes, timeNow())
+		}
 		return displayTrustRoot(dockerCli.Out(), swarmInspect)
 	}
 
@@ -133,3 +155,89 @@ func displayTrustRoot(out io.Writer, info swarm.Swarm) error {
 	fmt.Fprintln(out, strings.TrimSpace(info.ClusterInfo.TLSInfo.TrustRoot))
 	return nil
 }
+
+// displayCertificates displays the root CA certificate of the swarm, and the
+// issuer of the certificate of each node. The Engine API does not expose the
+// certificates of the nodes, only the root CA they trust and their issuer,
+// so the expiry reported for a node is the one of the root CA it trusts.
+func displayCertificates(out io.Writer, info swarm.Swarm, nodes []swarm.Node, now time.Time) error {
+	if info.ClusterInfo.TLSInfo.TrustRoot == "" {
+		return errors.New("No CA information available")
+	}
+	root, err := parseCertificate(info.ClusterInfo.TLSInfo.TrustRoot)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "Root CA:")
+	fmt.Fprintf(out, " Subject: %s\n", distinguishedName(root.Subject))
+	fmt.Fprintf(out, " Issuer: %s\n", distinguishedName(root.Issuer))
+	fmt.Fprintf(out, " Not Before: %s\n", root.NotBefore.Format(time.RFC3339))
+	fmt.Fprintf(out, " Not After: %s (%s)\n", root.NotAfter.Format(time.RFC3339), expiresIn(root.NotAfter, now))
+	fmt.Fprintf(out, "Node Certificate Expiry: %s\n", info.Spec.CAConfig.NodeCertExpiry)
+	fmt.Fprintf(out, "Root Rotation In Progress: %t\n", info.RootRotationInProgress)
+	fmt.Fprintln(out)
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Description.Hostname < nodes[j].Description.Hostname
+	})
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tHOSTNAME\tISSUER\tTRUST ROOT EXPIRY\tTLS STATUS")
+	for _, node := range nodes {
+		issuer, expiry, status := "unknown", "unknown", "Unknown"
+		tlsInfo := node.Description.TLSInfo
+		if !reflect.DeepEqual(tlsInfo, swarm.TLSInfo{}) {
+			issuer = "invalid"
+			var rdns pkix.RDNSequence
+			if _, err := asn1.Unmarshal(tlsInfo.CertIssuerSubject, &rdns); err == nil {
+				var name pkix.Name
+				name.FillFromRDNSequence(&rdns)
+				issuer = distinguishedName(name)
+			}
+			if cert, err := parseCertificate(tlsInfo.TrustRoot); err == nil {
+				expiry = cert.NotAfter.Format(time.RFC3339)
+			}
+			// nodes that do not have the same TLS information as the swarm
+			// still have a certificate issued by a previous root CA
+			status = "Ready"
+			if !reflect.DeepEqual(tlsInfo, info.ClusterInfo.TLSInfo) {
+				status = "Needs Rotation"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", node.ID, node.Description.Hostname, issuer, expiry, status)
+	}
+	return w.Flush()
+}
+
+// parseCertificate parses the first certificate of a PEM bundle
+func parseCertificate(data string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("invalid root CA certificate: no PEM data found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid root CA certificate")
+	}
+	return cert, nil
+}
+
+// distinguishedName returns the common name, organizations and
+// organizational units of a certificate name
+func distinguishedName(name pkix.Name) string {
+	parts := []string{"CN=" + name.CommonName}
+	for _, o := range name.Organization {
+		parts = append(parts, "O="+o)
+	}
+	for _, ou := range name.OrganizationalUnit {
+		parts = append(parts, "OU="+ou)
+	}
+	return strings.Join(parts, ",")
+}
+
+func expiresIn(expiry, now time.Time) string {
+	if !expiry.After(now) {
+		return "expired"
+	}
+	return "expires in " + units.HumanDuration(expiry.Sub(now))
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/docker/cli/internal/test"
 	"github.com/docker/cli/internal/test/testutil"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/gotestyourself/gotestyourself/golden"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -113,6 +114,72 @@ func TestDisplayTrustRoot(t *testing.T) {
 	assert.Equal(t, trustRoot+"\n", buffer.String())
 }
 
+func TestDisplayCertificates(t *testing.T) {
+	root, err := parseCertificate(statusTrustRoot)
+	require.NoError(t, err)
+	tlsInfo := swarm.TLSInfo{
+		TrustRoot:           statusTrustRoot,
+		CertIssuerSubject:   root.RawSubject,
+		CertIssuerPublicKey: root.RawSubjectPublicKeyInfo,
+	}
+	info := swarm.Swarm{
+		ClusterInfo: swarm.ClusterInfo{
+			Spec: swarm.Spec{
+				CAConfig: swarm.CAConfig{NodeCertExpiry: 90 * 24 * time.Hour},
+			},
+			TLSInfo:                tlsInfo,
+			RootRotationInProgress: true,
+		},
+	}
+	oldTLSInfo := tlsInfo
+	oldTLSInfo.CertIssuerPublicKey = []byte("old key")
+	nodes := []swarm.Node{
+		{ID: "nodeID2", Description: swarm.NodeDescription{Hostname: "node-2", TLSInfo: oldTLSInfo}},
+		{ID: "nodeID1", Description: swarm.NodeDescription{Hostname: "node-1", TLSInfo: tlsInfo}},
+		{ID: "nodeID3", Description: swarm.NodeDescription{Hostname: "node-3"}},
+	}
+
+	buffer := new(bytes.Buffer)
+	now := time.Date(2017, time.October, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, displayCertificates(buffer, info, nodes, now))
+	golden.Assert(t, buffer.String(), "ca-inspect.golden")
+}
+
+func TestCAInspectErrors(t *testing.T) {
+	testCases := []struct {
+		args          []string
+		trustRoot     string
+		expectedError string
+	}{
+		{
+			args:          []string{"--inspect", "--rotate"},
+			trustRoot:     statusTrustRoot,
+			expectedError: "--inspect can not be used with --rotate",
+		},
+		{
+			args:          []string{"--inspect"},
+			trustRoot:     "root",
+			expectedError: "invalid root CA certificate",
+		},
+	}
+	for _, tc := range testCases {
+		trustRoot := tc.trustRoot
+		cmd := newCACommand(
+			test.NewFakeCli(&fakeClient{
+				swarmInspectFunc: func() (swarm.Swarm, error) {
+					return swarm.Swarm{
+						ClusterInfo: swarm.ClusterInfo{
+							TLSInfo: swarm.TLSInfo{TrustRoot: trustRoot},
+						},
+					}, nil
+				},
+			}))
+		cmd.SetArgs(tc.args)
+		cmd.SetOutput(ioutil.Discard)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
+
 func TestUpdateSwarmSpecDefaultRotate(t *testing.T) {
 	spec := swarmSpecWithFullCAConfig()
 	flags := newCACommand(nil).Flags()
@@ -18,6 +18,9 @@ type fakeClient struct {
 	swarmLeaveFunc        func() error
 	swarmUpdateFunc       func(swarm swarm.Spec, flags swarm.UpdateFlags) error
 	swarmUnlockFunc       func(req swarm.UnlockRequest) error
+	nodeListFunc          func() ([]swarm.Node, error)
+	serviceListFunc       func() ([]swarm.Service, error)
+	taskListFunc          func() ([]swarm.Task, error)
 }
 
 func (cli *fakeClient) Info(ctx context.Context) (types.Info, error) {
@@ -82,3 +85,24 @@ func (cli *fakeClient) SwarmUnlock(ctx context.Context, req swarm.UnlockRequest)
 	}
 	return nil
 }
+
+func (cli *fakeClient) NodeList(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+	if cli.nodeListFunc != nil {
+		return cli.nodeListFunc()
+	}
+	return []swarm.Node{}, nil
+}
+
+func (cli *fakeClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	if cli.serviceListFunc != nil {
+		return cli.serviceListFunc()
+	}
+	return []swarm.Service{}, nil
+}
+
+func (cli *fakeClient) TaskList(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error) {
+	if cli.taskListFunc != nil {
+		return cli.taskListFunc()
+	}
+	return []swarm.Task{}, nil
+}
@@ -26,6 +26,7 @@ func NewSwarmCommand(dockerCli command.Cli) *cobra.Command {
 		newLeaveCommand(dockerCli),
 		newUnlockCommand(dockerCli),
 		newCACommand(dockerCli),
+		newStatusCommand(dockerCli),
 	)
 	return cmd
 }
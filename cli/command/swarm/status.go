@@ -0,0 +1,403 @@
+package swarm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/templates"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+const (
+	findingWarning  = "warning"
+	findingCritical = "critical"
+
+	// caExpiryWarning is how long before the expiry of the root CA
+	// certificate a warning is reported
+	caExpiryWarning = 30 * 24 * time.Hour
+
+	// statusCritical is the exit code of `docker swarm status` when there
+	// are critical findings
+	statusCritical = 2
+)
+
+// timeNow returns the current time, and is replaced in tests
+var timeNow = time.Now
+
+type statusOptions struct {
+	format string
+	since  time.Duration
+}
+
+func newStatusCommand(dockerCli command.Cli) *cobra.Command {
+	opts := statusOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "status [OPTIONS]",
+		Short: "Display the health of the swarm",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStatus(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.format, "format", "f", "", "Format the output using the given Go template, or \"json\"")
+	flags.DurationVar(&opts.since, "since", 15*time.Minute, "Report the tasks that failed within this duration (ns|us|ms|s|m|h)")
+	return cmd
+}
+
+// statusReport is the health of a swarm
+type statusReport struct {
+	ID          string
+	Managers    managersStatus
+	Nodes       nodesStatus
+	Services    servicesStatus
+	FailedTasks failedTasksStatus
+	CA          caStatus
+	Raft        swarm.RaftConfig
+	Findings    []finding
+}
+
+type managersStatus struct {
+	Total     int
+	Reachable int
+	Quorum    int
+	Leader    string
+}
+
+type nodesStatus struct {
+	Total  int
+	Ready  int
+	Down   int
+	Active int
+	Pause  int
+	Drain  int
+}
+
+type servicesStatus struct {
+	Total int
+	// NotConverged lists the services that are not at their desired number
+	// of tasks
+	NotConverged []serviceStatus
+}
+
+type serviceStatus struct {
+	ID      string
+	Name    string
+	Mode    string
+	Running int
+	Desired int
+}
+
+type failedTasksStatus struct {
+	Since time.Duration
+	Tasks []taskStatus
+}
+
+type taskStatus struct {
+	ID        string
+	Name      string
+	Node      string
+	State     swarm.TaskState
+	Error     string `json:",omitempty"`
+	Timestamp time.Time
+}
+
+type caStatus struct {
+	Expiry                 time.Time
+	NodeCertExpiry         time.Duration
+	RootRotationInProgress bool
+}
+
+// finding is a problem found in the swarm, either a warning or critical
+type finding struct {
+	Level   string
+	Message string
+}
+
+func (r *statusReport) addFinding(level, format string, a ...interface{}) {
+	r.Findings = append(r.Findings, finding{Level: level, Message: fmt.Sprintf(format, a...)})
+}
+
+// critical returns whether there are critical findings
+func (r *statusReport) critical() bool {
+	for _, f := range r.Findings {
+		if f.Level == findingCritical {
+			return true
+		}
+	}
+	return false
+}
+
+func runStatus(dockerCli command.Cli, opts statusOptions) error {
+	ctx := context.Background()
+
+	report, err := getStatus(ctx, dockerCli.Client(), opts.since, timeNow())
+	if err != nil {
+		return err
+	}
+
+	switch opts.format {
+	case "":
+		printStatus(dockerCli.Out(), report)
+	case "json":
+		data, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(dockerCli.Out(), string(data))
+	default:
+		tmpl, err := templates.Parse(opts.format)
+		if err != nil {
+			return cli.StatusError{StatusCode: 64, Status: "Template parsing error: " + err.Error()}
+		}
+		if err := tmpl.Execute(dockerCli.Out(), report); err != nil {
+			return err
+		}
+		fmt.Fprintln(dockerCli.Out())
+	}
+
+	if report.critical() {
+		return cli.StatusError{StatusCode: statusCritical}
+	}
+	return nil
+}
+
+// getStatus collects the health of the swarm
+// nolint: gocyclo
+func getStatus(ctx context.Context, client client.APIClient, since time.Duration, now time.Time) (*statusReport, error) {
+	sw, err := client.SwarmInspect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := client.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	services, err := client.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := client.TaskList(ctx, types.TaskListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &statusReport{
+		ID:   sw.ID,
+		Raft: sw.Spec.Raft,
+		CA: caStatus{
+			NodeCertExpiry:         sw.Spec.CAConfig.NodeCertExpiry,
+			RootRotationInProgress: sw.RootRotationInProgress,
+		},
+		FailedTasks: failedTasksStatus{Since: since, Tasks: []taskStatus{}},
+		Services:    servicesStatus{Total: len(services), NotConverged: []serviceStatus{}},
+		Findings:    []finding{},
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Description.Hostname < nodes[j].Description.Hostname
+	})
+	nodeNames := map[string]string{}
+	activeNodes := map[string]struct{}{}
+	var unreachable, down []string
+	for _, node := range nodes {
+		nodeNames[node.ID] = node.Description.Hostname
+		report.Nodes.Total++
+		if node.Status.State == swarm.NodeStateReady {
+			report.Nodes.Ready++
+		} else {
+			down = append(down, fmt.Sprintf("%s is %s", node.Description.Hostname, node.Status.State))
+		}
+		if node.Status.State != swarm.NodeStateDown {
+			activeNodes[node.ID] = struct{}{}
+		}
+		if node.Status.State == swarm.NodeStateDown {
+			report.Nodes.Down++
+		}
+		switch node.Spec.Availability {
+		case swarm.NodeAvailabilityActive:
+			report.Nodes.Active++
+		case swarm.NodeAvailabilityPause:
+			report.Nodes.Pause++
+		case swarm.NodeAvailabilityDrain:
+			report.Nodes.Drain++
+		}
+		if node.ManagerStatus == nil {
+			continue
+		}
+		report.Managers.Total++
+		if node.ManagerStatus.Reachability == swarm.ReachabilityReachable {
+			report.Managers.Reachable++
+		} else {
+			unreachable = append(unreachable, node.Description.Hostname)
+		}
+		if node.ManagerStatus.Leader {
+			report.Managers.Leader = node.Description.Hostname
+		}
+	}
+	report.Managers.Quorum = report.Managers.Total/2 + 1
+
+	if report.Managers.Reachable < report.Managers.Quorum {
+		report.addFinding(findingCritical, "only %d of %d managers are reachable, the swarm has lost quorum", report.Managers.Reachable, report.Managers.Total)
+	}
+	if report.Managers.Leader == "" {
+		report.addFinding(findingCritical, "the swarm has no leader")
+	}
+	for _, name := range unreachable {
+		report.addFinding(findingWarning, "manager %s is unreachable", name)
+	}
+	if report.Managers.Total%2 == 0 {
+		report.addFinding(findingWarning, "the swarm has an even number of managers (%d), which does not improve fault tolerance", report.Managers.Total)
+	}
+	for _, msg := range down {
+		report.addFinding(findingWarning, "node %s", msg)
+	}
+
+	// the running and desired tasks are counted like `docker service ls`
+	running := map[string]int{}
+	desired := map[string]int{}
+	serviceNames := map[string]string{}
+	for _, service := range services {
+		serviceNames[service.ID] = service.Spec.Name
+	}
+	for _, task := range tasks {
+		if task.DesiredState != swarm.TaskStateShutdown {
+			desired[task.ServiceID]++
+		}
+		if _, ok := activeNodes[task.NodeID]; ok && task.Status.State == swarm.TaskStateRunning {
+			running[task.ServiceID]++
+		}
+		if task.Status.State != swarm.TaskStateFailed && task.Status.State != swarm.TaskStateRejected {
+			continue
+		}
+		if now.Sub(task.Status.Timestamp) > since {
+			continue
+		}
+		report.FailedTasks.Tasks = append(report.FailedTasks.Tasks, taskStatus{
+			ID:        task.ID,
+			Name:      taskName(task, serviceNames[task.ServiceID]),
+			Node:      nodeNames[task.NodeID],
+			State:     task.Status.State,
+			Error:     task.Status.Err,
+			Timestamp: task.Status.Timestamp,
+		})
+	}
+	sort.Slice(report.FailedTasks.Tasks, func(i, j int) bool {
+		return report.FailedTasks.Tasks[i].Timestamp.After(report.FailedTasks.Tasks[j].Timestamp)
+	})
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Spec.Name < services[j].Spec.Name
+	})
+	for _, service := range services {
+		status := serviceStatus{ID: service.ID, Name: service.Spec.Name, Running: running[service.ID]}
+		switch {
+		case service.Spec.Mode.Replicated != nil && service.Spec.Mode.Replicated.Replicas != nil:
+			status.Mode = "replicated"
+			status.Desired = int(*service.Spec.Mode.Replicated.Replicas)
+		case service.Spec.Mode.Global != nil:
+			status.Mode = "global"
+			status.Desired = desired[service.ID]
+		}
+		if status.Running != status.Desired {
+			report.Services.NotConverged = append(report.Services.NotConverged, status)
+			report.addFinding(findingWarning, "service %s has %d/%d tasks running", status.Name, status.Running, status.Desired)
+		}
+	}
+	if n := len(report.FailedTasks.Tasks); n > 0 {
+		report.addFinding(findingWarning, "%d tasks failed or were rejected in the last %s", n, since)
+	}
+
+	if sw.TLSInfo.TrustRoot != "" {
+		root, err := parseCertificate(sw.TLSInfo.TrustRoot)
+		if err != nil {
+			return nil, err
+		}
+		expiry := root.NotAfter
+		report.CA.Expiry = expiry
+		switch {
+		case !expiry.After(now):
+			report.addFinding(findingCritical, "the root CA certificate expired on %s", expiry.Format(time.RFC3339))
+		case expiry.Sub(now) < caExpiryWarning:
+			report.addFinding(findingWarning, "the root CA certificate expires on %s", expiry.Format(time.RFC3339))
+		}
+	}
+	if sw.RootRotationInProgress {
+		report.addFinding(findingWarning, "a root CA rotation is in progress")
+	}
+
+	// critical findings first
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Level == findingCritical && report.Findings[j].Level != findingCritical
+	})
+	return report, nil
+}
+
+// taskName returns the name of a task, as shown by `docker service ps`
+func taskName(task swarm.Task, serviceName string) string {
+	if serviceName == "" {
+		serviceName = task.ServiceID
+	}
+	if task.Slot != 0 {
+		return fmt.Sprintf("%s.%d", serviceName, task.Slot)
+	}
+	return fmt.Sprintf("%s.%s", serviceName, task.NodeID)
+}
+
+func printStatus(out io.Writer, report *statusReport) {
+	fmt.Fprintf(out, "Swarm: %s\n", report.ID)
+	leader := report.Managers.Leader
+	if leader == "" {
+		leader = "none"
+	}
+	fmt.Fprintf(out, "Managers: %d (%d reachable, quorum %d, leader %s)\n",
+		report.Managers.Total, report.Managers.Reachable, report.Managers.Quorum, leader)
+	fmt.Fprintf(out, "Nodes: %d (%d ready, %d down; %d active, %d paused, %d drained)\n",
+		report.Nodes.Total, report.Nodes.Ready, report.Nodes.Down, report.Nodes.Active, report.Nodes.Pause, report.Nodes.Drain)
+	fmt.Fprintf(out, "Services: %d (%d not converged)\n", report.Services.Total, len(report.Services.NotConverged))
+	for _, service := range report.Services.NotConverged {
+		fmt.Fprintf(out, " %s: %s, %d/%d\n", service.Name, service.Mode, service.Running, service.Desired)
+	}
+	fmt.Fprintf(out, "Failed tasks in the last %s: %d\n", report.FailedTasks.Since, len(report.FailedTasks.Tasks))
+	for _, task := range report.FailedTasks.Tasks {
+		fmt.Fprintf(out, " %s on %s: %s %s ago", task.Name, task.Node, task.State, units.HumanDuration(timeNow().Sub(task.Timestamp)))
+		if task.Error != "" {
+			fmt.Fprintf(out, ": %s", task.Error)
+		}
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintln(out, "Root CA:")
+	if !report.CA.Expiry.IsZero() {
+		fmt.Fprintf(out, " Expiry: %s\n", report.CA.Expiry.Format(time.RFC3339))
+	}
+	fmt.Fprintf(out, " Node Certificate Expiry: %s\n", report.CA.NodeCertExpiry)
+	fmt.Fprintf(out, " Rotation In Progress: %t\n", report.CA.RootRotationInProgress)
+	fmt.Fprintln(out, "Raft:")
+	fmt.Fprintf(out, " Snapshot Interval: %d\n", report.Raft.SnapshotInterval)
+	if report.Raft.KeepOldSnapshots != nil {
+		fmt.Fprintf(out, " Number of Old Snapshots to Retain: %d\n", *report.Raft.KeepOldSnapshots)
+	}
+	fmt.Fprintf(out, " Heartbeat Tick: %d\n", report.Raft.HeartbeatTick)
+	fmt.Fprintf(out, " Election Tick: %d\n", report.Raft.ElectionTick)
+
+	if len(report.Findings) == 0 {
+		return
+	}
+	fmt.Fprintln(out)
+	for _, f := range report.Findings {
+		fmt.Fprintf(out, "%s: %s\n", strings.ToUpper(f.Level), f.Message)
+	}
+}
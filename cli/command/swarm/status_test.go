@@ -0,0 +1,154 @@
+package swarm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/internal/test"
+	// Import builders to get the builder function as package function
+	. "github.com/docker/cli/internal/test/builders"
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusTrustRoot is a root CA certificate, valid from 2017-06-27 to
+// 2037-06-22
+const statusTrustRoot = `-----BEGIN CERTIFICATE-----
+MIIBajCCARCgAwIBAgIUe0+jYWhxN8fFOByC7yveIYgvx1kwCgYIKoZIzj0EAwIw
+EzERMA8GA1UEAxMIc3dhcm0tY2EwHhcNMTcwNjI3MTUxNDAwWhcNMzcwNjIyMTUx
+NDAwWjATMREwDwYDVQQDEwhzd2FybS1jYTBZMBMGByqGSM49AgEGCCqGSM49AwEH
+A0IABGgbOZLd7b4b262+6m4ignIecbAZKim6djNiIS1Kl5IHciXYn7gnSpsayjn7
+GQABpgkdPeM9TEQowmtR1qSnORujQjBAMA4GA1UdDwEB/wQEAwIBBjAPBgNVHRMB
+Af8EBTADAQH/MB0GA1UdDgQWBBQ6Rtcn823/fxRZyheRDFpDzuBMpTAKBggqhkjO
+PQQDAgNIADBFAiEAqD3Kb2rgsy6NoTk+zEgcUi/aGBCsvQDG3vML1PXN8j0CIBjj
+4nDj+GmHXcnKa8wXx70Z8OZEpRQIiKDDLmcXuslp
+-----END CERTIFICATE-----
+`
+
+var statusNow = time.Date(2017, 10, 19, 10, 0, 0, 0, time.UTC)
+
+func statusSwarm() (swarm.Swarm, error) {
+	keepOldSnapshots := uint64(0)
+	return swarm.Swarm{
+		ClusterInfo: swarm.ClusterInfo{
+			ID: "swarm-id",
+			Spec: swarm.Spec{
+				Raft: swarm.RaftConfig{
+					SnapshotInterval: 10000,
+					KeepOldSnapshots: &keepOldSnapshots,
+					ElectionTick:     10,
+					HeartbeatTick:    1,
+				},
+				CAConfig: swarm.CAConfig{NodeCertExpiry: 90 * 24 * time.Hour},
+			},
+			TLSInfo: swarm.TLSInfo{TrustRoot: statusTrustRoot},
+		},
+	}, nil
+}
+
+func newStatusClient(nodes []swarm.Node) *fakeClient {
+	return &fakeClient{
+		swarmInspectFunc: statusSwarm,
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return nodes, nil
+		},
+		serviceListFunc: func() ([]swarm.Service, error) {
+			return []swarm.Service{
+				*Service(ServiceID("web-id"), ServiceName("web"), ReplicatedService(2)),
+				*Service(ServiceID("db-id"), ServiceName("db"), ReplicatedService(1)),
+			}, nil
+		},
+		taskListFunc: func() ([]swarm.Task, error) {
+			running := WithStatus(TaskState(swarm.TaskStateRunning))
+			return []swarm.Task{
+				*Task(TaskServiceID("web-id"), TaskSlot(1), TaskNodeID("node-1"), TaskDesiredState(swarm.TaskStateRunning), running),
+				*Task(TaskServiceID("web-id"), TaskSlot(2), TaskNodeID("node-2"), TaskDesiredState(swarm.TaskStateRunning), running),
+				*Task(TaskServiceID("db-id"), TaskSlot(1), TaskNodeID("node-2"), TaskDesiredState(swarm.TaskStateRunning), WithStatus(TaskState(swarm.TaskStatePending))),
+				*Task(TaskServiceID("db-id"), TaskSlot(1), TaskNodeID("node-3"), TaskDesiredState(swarm.TaskStateShutdown),
+					WithStatus(TaskState(swarm.TaskStateFailed), StatusErr("task: non-zero exit (1)"), Timestamp(statusNow.Add(-5*time.Minute)))),
+				// too old to be reported
+				*Task(TaskServiceID("db-id"), TaskSlot(1), TaskNodeID("node-3"), TaskDesiredState(swarm.TaskStateShutdown),
+					WithStatus(TaskState(swarm.TaskStateFailed), Timestamp(statusNow.Add(-time.Hour)))),
+			}, nil
+		},
+	}
+}
+
+func statusNodes() []swarm.Node {
+	return []swarm.Node{
+		*Node(NodeID("node-1"), Hostname("node-1"), Manager(Leader())),
+		*Node(NodeID("node-2"), Hostname("node-2"), Manager()),
+		*Node(NodeID("node-3"), Hostname("node-3"), Manager()),
+	}
+}
+
+func TestSwarmStatus(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	timeNow = func() time.Time { return statusNow }
+
+	for _, format := range []string{"", "json"} {
+		cli := test.NewFakeCli(newStatusClient(statusNodes()))
+		cmd := newStatusCommand(cli)
+		cmd.SetArgs([]string{"--format", format})
+		require.NoError(t, cmd.Execute())
+		golden.Assert(t, cli.OutBuffer().String(), fmt.Sprintf("status%s.golden", map[string]string{"": "", "json": "-json"}[format]))
+	}
+}
+
+func TestSwarmStatusCritical(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	// the root CA certificate has expired
+	timeNow = func() time.Time { return time.Date(2037, 7, 1, 0, 0, 0, 0, time.UTC) }
+
+	nodes := statusNodes()
+	nodes[1].ManagerStatus.Reachability = swarm.ReachabilityUnreachable
+	nodes[1].Status.State = swarm.NodeStateDown
+	nodes[2].ManagerStatus.Reachability = swarm.ReachabilityUnreachable
+	nodes[2].Status.State = swarm.NodeStateDown
+
+	dockerCli := test.NewFakeCli(newStatusClient(nodes))
+	cmd := newStatusCommand(dockerCli)
+	cmd.SetArgs([]string{"--format", "{{range .Findings}}{{.Level}}: {{.Message}}\n{{end}}"})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Equal(t, 2, err.(cli.StatusError).StatusCode)
+	golden.Assert(t, dockerCli.OutBuffer().String(), "status-critical.golden")
+}
+
+func TestSwarmStatusErrors(t *testing.T) {
+	testCases := []struct {
+		args             []string
+		swarmInspectFunc func() (swarm.Swarm, error)
+		expectedError    string
+	}{
+		{
+			args:          []string{"foo"},
+			expectedError: "accepts no argument",
+		},
+		{
+			swarmInspectFunc: func() (swarm.Swarm, error) {
+				return swarm.Swarm{}, errors.New("This node is not a swarm manager.")
+			},
+			expectedError: "This node is not a swarm manager.",
+		},
+		{
+			swarmInspectFunc: func() (swarm.Swarm, error) {
+				return swarm.Swarm{ClusterInfo: swarm.ClusterInfo{TLSInfo: swarm.TLSInfo{TrustRoot: "root"}}}, nil
+			},
+			expectedError: "invalid root CA certificate",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := newStatusCommand(test.NewFakeCli(&fakeClient{swarmInspectFunc: tc.swarmInspectFunc}))
+		cmd.SetArgs(tc.args)
+		cmd.SetOutput(ioutil.Discard)
+		testutil.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
@@ -1,15 +1,50 @@
 package system
 
 import (
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/client"
+	"golang.org/x/net/context"
 )
 
 type fakeClient struct {
 	client.Client
 
-	version string
+	version           string
+	serverVersionFunc func() (types.Version, error)
+	infoFunc          func() (types.Info, error)
+	nodeListFunc      func(options types.NodeListOptions) ([]swarm.Node, error)
+	serviceListFunc   func(options types.ServiceListOptions) ([]swarm.Service, error)
 }
 
 func (cli *fakeClient) ClientVersion() string {
 	return cli.version
 }
+
+func (cli *fakeClient) ServerVersion(_ context.Context) (types.Version, error) {
+	if cli.serverVersionFunc != nil {
+		return cli.serverVersionFunc()
+	}
+	return types.Version{}, nil
+}
+
+func (cli *fakeClient) Info(_ context.Context) (types.Info, error) {
+	if cli.infoFunc != nil {
+		return cli.infoFunc()
+	}
+	return types.Info{}, nil
+}
+
+func (cli *fakeClient) NodeList(_ context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+	if cli.nodeListFunc != nil {
+		return cli.nodeListFunc(options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) ServiceList(_ context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	if cli.serviceListFunc != nil {
+		return cli.serviceListFunc(options)
+	}
+	return nil, nil
+}
@@ -20,6 +20,7 @@ func NewSystemCommand(dockerCli *command.DockerCli) *cobra.Command {
 		NewInfoCommand(dockerCli),
 		newDiskUsageCommand(dockerCli),
 		newPruneCommand(dockerCli),
+		newSupportBundleCommand(dockerCli),
 	)
 
 	return cmd
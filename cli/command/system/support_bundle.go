@@ -0,0 +1,139 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/service"
+	"github.com/docker/cli/service/logs"
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+type supportBundleOptions struct {
+	output string
+	since  string
+	until  string
+	gzip   bool
+}
+
+func newSupportBundleCommand(dockerCli command.Cli) *cobra.Command {
+	var opts supportBundleOptions
+
+	cmd := &cobra.Command{
+		Use:   "support-bundle [OPTIONS]",
+		Short: "Collect information and logs for a support request",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSupportBundle(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.output, "output", "o", "", "Directory to write the bundle to (default \"docker-support-<date>\")")
+	flags.StringVar(&opts.since, "since", "", "Collect the service logs since timestamp (e.g. 2013-01-02T13:23:37) or relative (e.g. 42m for 42 minutes)")
+	flags.StringVar(&opts.until, "until", "", "Collect the service logs before a timestamp (e.g. 2013-01-02T13:23:37) or relative (e.g. 42m for 42 minutes)")
+	flags.BoolVar(&opts.gzip, "gzip", false, "Compress the log files")
+	return cmd
+}
+
+func runSupportBundle(dockerCli command.Cli, opts supportBundleOptions) error {
+	ctx := context.Background()
+	apiClient := dockerCli.Client()
+
+	dir := opts.output
+	if dir == "" {
+		dir = "docker-support-" + time.Now().UTC().Format("20060102-150405")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "failed to create the output directory")
+	}
+
+	version := versionInfo{
+		Client: clientVersion{
+			Version:    cli.Version,
+			APIVersion: apiClient.ClientVersion(),
+			GoVersion:  runtime.Version(),
+			GitCommit:  cli.GitCommit,
+			BuildTime:  cli.BuildTime,
+			Os:         runtime.GOOS,
+			Arch:       runtime.GOARCH,
+		},
+	}
+	serverVersion, err := apiClient.ServerVersion(ctx)
+	if err != nil {
+		return err
+	}
+	version.Server = &serverVersion
+	if err := writeBundleFile(dir, "version.json", version); err != nil {
+		return err
+	}
+
+	info, err := apiClient.Info(ctx)
+	if err != nil {
+		return err
+	}
+	if err := writeBundleFile(dir, "info.json", info); err != nil {
+		return err
+	}
+
+	if !info.Swarm.ControlAvailable {
+		fmt.Fprintln(dockerCli.Err(), "This node is not a swarm manager, nodes, services and service logs are not collected.")
+	} else {
+		nodes, err := apiClient.NodeList(ctx, types.NodeListOptions{})
+		if err != nil {
+			return err
+		}
+		if err := writeBundleFile(dir, "nodes.json", nodes); err != nil {
+			return err
+		}
+		services, err := apiClient.ServiceList(ctx, types.ServiceListOptions{})
+		if err != nil {
+			return err
+		}
+		if err := writeBundleFile(dir, "services.json", services); err != nil {
+			return err
+		}
+
+		export, err := logs.NewExport(filepath.Join(dir, "logs"), logs.ExportOptions{Compress: opts.gzip, Since: opts.since, Until: opts.until})
+		if err != nil {
+			return err
+		}
+		skipped, err := service.ExportLogs(ctx, apiClient, export, services, opts.since, opts.until)
+		if err != nil {
+			export.Close()
+			return err
+		}
+		if len(skipped) > 0 {
+			fmt.Fprintf(dockerCli.Err(), "The logs of services with a TTY are not collected: %s\n", strings.Join(skipped, ", "))
+		}
+		if err := export.Close(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(dockerCli.Out(), "Support bundle written to %s\n", dir)
+	return nil
+}
+
+// writeBundleFile writes v as indented JSON to a file of the bundle
+func writeBundleFile(dir, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name), append(data, '\n'), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", name)
+	}
+	return nil
+}
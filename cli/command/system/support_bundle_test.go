@@ -0,0 +1,117 @@
+package system
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/internal/test"
+	// Import builders to get the builder function as package function
+	. "github.com/docker/cli/internal/test/builders"
+	"github.com/docker/cli/service/logs"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "support-bundle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cli := test.NewFakeCli(&fakeClient{
+		version: "1.32",
+		serverVersionFunc: func() (types.Version, error) {
+			return types.Version{Version: "17.10.0-ce"}, nil
+		},
+		infoFunc: func() (types.Info, error) {
+			return types.Info{Name: "manager1", Swarm: swarm.Info{ControlAvailable: true}}, nil
+		},
+		nodeListFunc: func(options types.NodeListOptions) ([]swarm.Node, error) {
+			return []swarm.Node{*Node(NodeID("node-1"))}, nil
+		},
+	})
+	cmd := newSupportBundleCommand(cli)
+	cmd.SetArgs([]string{"--output", dir, "--since", "1h"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "Support bundle written to "+dir+"\n", cli.OutBuffer().String())
+
+	var version versionInfo
+	readBundleFile(t, filepath.Join(dir, "version.json"), &version)
+	assert.Equal(t, "1.32", version.Client.APIVersion)
+	assert.Equal(t, "17.10.0-ce", version.Server.Version)
+
+	var info types.Info
+	readBundleFile(t, filepath.Join(dir, "info.json"), &info)
+	assert.Equal(t, "manager1", info.Name)
+
+	var nodes []swarm.Node
+	readBundleFile(t, filepath.Join(dir, "nodes.json"), &nodes)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, "node-1", nodes[0].ID)
+
+	var manifest logs.Manifest
+	readBundleFile(t, filepath.Join(dir, "logs", logs.ManifestFile), &manifest)
+	assert.Equal(t, "1h", manifest.Since)
+	assert.Empty(t, manifest.Files)
+}
+
+func TestSupportBundleSkipsTTYServices(t *testing.T) {
+	dir, err := ioutil.TempDir("", "support-bundle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cli := test.NewFakeCli(&fakeClient{
+		infoFunc: func() (types.Info, error) {
+			return types.Info{Swarm: swarm.Info{ControlAvailable: true}}, nil
+		},
+		serviceListFunc: func(options types.ServiceListOptions) ([]swarm.Service, error) {
+			service := Service(ServiceID("service-id"), ServiceName("console"))
+			service.Spec.TaskTemplate.ContainerSpec = &swarm.ContainerSpec{TTY: true}
+			return []swarm.Service{*service}, nil
+		},
+	})
+	cmd := newSupportBundleCommand(cli)
+	cmd.SetArgs([]string{"--output", dir})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "The logs of services with a TTY are not collected: console\n", cli.ErrBuffer().String())
+
+	var manifest logs.Manifest
+	readBundleFile(t, filepath.Join(dir, "logs", logs.ManifestFile), &manifest)
+	assert.Empty(t, manifest.Files)
+	assert.Equal(t, []logs.ManifestSkipped{{
+		ServiceID:   "service-id",
+		ServiceName: "console",
+		Reason:      "the logs of services with a TTY can't be split per task",
+	}}, manifest.Skipped)
+}
+
+func TestSupportBundleNotManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "support-bundle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cmd := newSupportBundleCommand(cli)
+	cmd.SetArgs([]string{"--output", dir})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, cli.ErrBuffer().String(), "This node is not a swarm manager")
+
+	for _, name := range []string{"version.json", "info.json"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.NoError(t, err)
+	}
+	for _, name := range []string{"nodes.json", "services.json", "logs"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+func readBundleFile(t *testing.T, path string, v interface{}) {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, v))
+}
@@ -1,6 +1,8 @@
 package task
 
 import (
+	"io"
+
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/client"
@@ -11,6 +13,7 @@ type fakeClient struct {
 	client.APIClient
 	nodeInspectWithRaw    func(ref string) (swarm.Node, []byte, error)
 	serviceInspectWithRaw func(ref string, options types.ServiceInspectOptions) (swarm.Service, []byte, error)
+	taskLogsFunc          func(taskID string, options types.ContainerLogsOptions) (io.ReadCloser, error)
 }
 
 func (cli *fakeClient) NodeInspectWithRaw(ctx context.Context, ref string) (swarm.Node, []byte, error) {
@@ -26,3 +29,10 @@ func (cli *fakeClient) ServiceInspectWithRaw(ctx context.Context, ref string, op
 	}
 	return swarm.Service{}, nil, nil
 }
+
+func (cli *fakeClient) TaskLogs(ctx context.Context, taskID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	if cli.taskLogsFunc != nil {
+		return cli.taskLogsFunc(taskID, options)
+	}
+	return nil, nil
+}
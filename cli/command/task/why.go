@@ -0,0 +1,262 @@
+package task
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/idresolver"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"golang.org/x/net/context"
+)
+
+// timeNow returns the current time, and is replaced in tests
+var timeNow = time.Now
+
+// WhyOptions holds the options of the report of the failed tasks printed by
+// the --why flag of the commands listing tasks
+type WhyOptions struct {
+	Enabled bool
+	Tail    string
+	Since   time.Duration
+	Limit   int
+}
+
+// AddWhyFlags adds the --why flags to a command listing tasks
+func AddWhyFlags(flags *pflag.FlagSet, options *WhyOptions) {
+	flags.BoolVar(&options.Enabled, "why", false, "Explain why tasks failed or were rejected")
+	flags.StringVar(&options.Tail, "why-tail", "10", "Number of lines of logs to show for each failure with --why")
+	flags.DurationVar(&options.Since, "why-since", 0, "Only explain the failures within this duration with --why (ns|us|ms|s|m|h) (default the whole task history)")
+	flags.IntVar(&options.Limit, "why-limit", 0, "Maximum number of failures to explain with --why, most frequent first (default no limit)")
+}
+
+// Validate checks that the --why flags can be used with the output options
+// of the command
+func (o *WhyOptions) Validate(quiet bool, format string) error {
+	if !o.Enabled {
+		return nil
+	}
+	if quiet || format != "" {
+		return errors.New("--why can not be combined with --quiet or --format")
+	}
+	if o.Since < 0 {
+		return errors.New("--why-since can not be negative")
+	}
+	if o.Limit < 0 {
+		return errors.New("--why-limit can not be negative")
+	}
+	return nil
+}
+
+// failureGroup holds the failed tasks of a service that failed with the same
+// error on the same node
+type failureGroup struct {
+	serviceID string
+	nodeID    string
+	err       string
+	tasks     []swarm.Task
+}
+
+// latest returns the most recent task of the group
+func (g failureGroup) latest() swarm.Task {
+	latest := g.tasks[0]
+	for _, task := range g.tasks[1:] {
+		if task.Status.Timestamp.After(latest.Status.Timestamp) {
+			latest = task
+		}
+	}
+	return latest
+}
+
+// isFailed returns true if the task failed or was rejected
+func isFailed(task swarm.Task) bool {
+	return task.Status.State == swarm.TaskStateFailed || task.Status.State == swarm.TaskStateRejected
+}
+
+// groupFailures groups the failed and rejected tasks by service, error and
+// node, most frequent failures first.
+func groupFailures(tasks []swarm.Task) []failureGroup {
+	groups := map[string]*failureGroup{}
+	var keys []string
+	for _, task := range tasks {
+		if !isFailed(task) {
+			continue
+		}
+		key := task.ServiceID + "\x00" + task.NodeID + "\x00" + task.Status.Err
+		group, ok := groups[key]
+		if !ok {
+			group = &failureGroup{serviceID: task.ServiceID, nodeID: task.NodeID, err: task.Status.Err}
+			groups[key] = group
+			keys = append(keys, key)
+		}
+		group.tasks = append(group.tasks, task)
+	}
+
+	result := make([]failureGroup, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *groups[key])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return len(result[i].tasks) > len(result[j].tasks)
+	})
+	return result
+}
+
+// suggestCause returns a hint about the likely cause of a task failure, or
+// an empty string if the failure is not recognized.
+func suggestCause(task swarm.Task) string {
+	err := strings.ToLower(task.Status.Err)
+	switch {
+	case strings.Contains(err, "no suitable node"):
+		return "No node satisfies the placement constraints, resource reservations or platform of the service; check the nodes with \"docker node ls\" and the constraints of the service."
+	case strings.Contains(err, "no such image"),
+		strings.Contains(err, "pull access denied"),
+		strings.Contains(err, "manifest unknown"),
+		strings.Contains(err, "not found: manifest"),
+		strings.Contains(err, "repository does not exist"):
+		return "The image could not be pulled; check the image name and tag, and that the nodes can authenticate to the registry (--with-registry-auth)."
+	case strings.Contains(err, "port is already allocated"),
+		strings.Contains(err, "address already in use"):
+		return "A published port is already in use on the node, by another service or a container."
+	case strings.Contains(err, "invalid mount config"),
+		strings.Contains(err, "bind source path does not exist"):
+		return "A mount source does not exist on the node; check the bind mounts of the service."
+	}
+
+	if status := task.Status.ContainerStatus; status.ExitCode != 0 {
+		switch status.ExitCode {
+		case 137:
+			return "The container was killed (exit code 137), possibly because it ran out of memory or did not stop in time."
+		case 126, 127:
+			return "The command of the container could not be run; check the entrypoint and command of the service."
+		default:
+			return fmt.Sprintf("The container exited with code %d; check its logs.", status.ExitCode)
+		}
+	}
+	return ""
+}
+
+// recentTasks returns the tasks whose status changed within since, or all
+// the tasks if since is 0
+func recentTasks(tasks []swarm.Task, since time.Duration, now time.Time) []swarm.Task {
+	if since == 0 {
+		return tasks
+	}
+	var recent []swarm.Task
+	for _, task := range tasks {
+		if now.Sub(task.Status.Timestamp) <= since {
+			recent = append(recent, task)
+		}
+	}
+	return recent
+}
+
+// PrintWhy prints a report of the failed and rejected tasks, grouped by error
+// and node, with the last lines of logs of the most recent task of each
+// group and a hint about the likely cause of the failure. Only the failures
+// within options.Since are reported, and at most options.Limit groups.
+func PrintWhy(ctx context.Context, dockerCli command.Cli, tasks []swarm.Task, resolver *idresolver.IDResolver, options WhyOptions) error {
+	out := dockerCli.Out()
+
+	groups := groupFailures(recentTasks(tasks, options.Since, timeNow()))
+	if len(groups) == 0 {
+		if options.Since > 0 {
+			fmt.Fprintf(out, "No failed or rejected tasks found in the last %s\n", options.Since)
+			return nil
+		}
+		fmt.Fprintln(out, "No failed or rejected tasks found")
+		return nil
+	}
+	omitted := 0
+	if options.Limit > 0 && len(groups) > options.Limit {
+		omitted = len(groups) - options.Limit
+		groups = groups[:options.Limit]
+	}
+
+	for i, group := range groups {
+		serviceName, err := resolver.Resolve(ctx, swarm.Service{}, group.serviceID)
+		if err != nil {
+			return err
+		}
+		location := "before being assigned to a node"
+		if group.nodeID != "" {
+			nodeName, err := resolver.Resolve(ctx, swarm.Node{}, group.nodeID)
+			if err != nil {
+				return err
+			}
+			location = "on node " + nodeName
+		}
+		latest := group.latest()
+
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%s: %d task(s) %s %s\n", serviceName, len(group.tasks), latest.Status.State, location)
+		fmt.Fprintf(out, "  Error:        %s\n", group.err)
+		fmt.Fprintf(out, "  Last task:    %s (%s)\n", stringid.TruncateID(latest.ID), latest.Status.Timestamp.UTC().Format("2006-01-02T15:04:05Z"))
+		if latest.Status.ContainerStatus.ContainerID != "" {
+			fmt.Fprintf(out, "  Exit code:    %d\n", latest.Status.ContainerStatus.ExitCode)
+		}
+		if hint := suggestCause(latest); hint != "" {
+			fmt.Fprintf(out, "  Likely cause: %s\n", hint)
+		}
+
+		if latest.Status.ContainerStatus.ContainerID == "" {
+			// the task never got a container, so there are no logs
+			continue
+		}
+		logs, err := taskLogs(ctx, dockerCli, latest, options.Tail)
+		if err != nil {
+			fmt.Fprintf(out, "  Logs:         unavailable: %v\n", err)
+			continue
+		}
+		if len(logs) == 0 {
+			continue
+		}
+		fmt.Fprintln(out, "  Logs:")
+		for _, line := range logs {
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+	}
+	if omitted > 0 {
+		fmt.Fprintf(out, "\n%d more failure(s) not shown, raise --why-limit to show them\n", omitted)
+	}
+	return nil
+}
+
+// taskLogs returns the last tail lines of logs of a task
+func taskLogs(ctx context.Context, dockerCli command.Cli, task swarm.Task, tail string) ([]string, error) {
+	responseBody, err := dockerCli.Client().TaskLogs(ctx, task.ID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       tail,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer responseBody.Close()
+
+	var buf bytes.Buffer
+	if task.Spec.ContainerSpec != nil && task.Spec.ContainerSpec.TTY {
+		_, err = io.Copy(&buf, responseBody)
+	} else {
+		_, err = stdcopy.StdCopy(&buf, &buf, responseBody)
+	}
+	if err != nil {
+		return nil, err
+	}
+	logs := strings.TrimRight(buf.String(), "\n")
+	if logs == "" {
+		return nil, nil
+	}
+	return strings.Split(logs, "\n"), nil
+}
@@ -0,0 +1,180 @@
+package task
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/docker/cli/cli/command/idresolver"
+	"github.com/docker/cli/internal/test"
+	// Import builders to get the builder function as package function
+	. "github.com/docker/cli/internal/test/builders"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/gotestyourself/gotestyourself/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func exited(code int) func(*swarm.TaskStatus) {
+	return func(status *swarm.TaskStatus) {
+		status.ContainerStatus = swarm.ContainerStatus{ContainerID: "container-id", ExitCode: code}
+	}
+}
+
+func TestGroupFailures(t *testing.T) {
+	tasks := []swarm.Task{
+		*Task(TaskID("1"), TaskNodeID("node-1"), WithStatus(TaskState(swarm.TaskStateFailed), StatusErr("task: non-zero exit (1)"))),
+		*Task(TaskID("2"), TaskNodeID("node-2"), WithStatus(TaskState(swarm.TaskStateRejected), StatusErr("no suitable node"))),
+		*Task(TaskID("3"), TaskNodeID("node-2"), WithStatus(TaskState(swarm.TaskStateRejected), StatusErr("no suitable node"))),
+		*Task(TaskID("4"), TaskNodeID("node-1"), WithStatus(TaskState(swarm.TaskStateRunning))),
+	}
+
+	groups := groupFailures(tasks)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "no suitable node", groups[0].err)
+	assert.Len(t, groups[0].tasks, 2)
+	assert.Equal(t, "task: non-zero exit (1)", groups[1].err)
+	assert.Len(t, groups[1].tasks, 1)
+}
+
+func TestSuggestCause(t *testing.T) {
+	testCases := []struct {
+		status   *swarm.TaskStatus
+		expected string
+	}{
+		{
+			status:   TaskStatus(StatusErr("no suitable node (scheduling constraints not satisfied on 3 nodes)")),
+			expected: "No node satisfies the placement constraints",
+		},
+		{
+			status:   TaskStatus(StatusErr("No such image: foo:latest")),
+			expected: "The image could not be pulled",
+		},
+		{
+			status:   TaskStatus(StatusErr("Bind for 0.0.0.0:80 failed: port is already allocated")),
+			expected: "A published port is already in use",
+		},
+		{
+			status:   TaskStatus(StatusErr("task: non-zero exit (137)"), exited(137)),
+			expected: "The container was killed",
+		},
+		{
+			status:   TaskStatus(StatusErr("task: non-zero exit (2)"), exited(2)),
+			expected: "The container exited with code 2",
+		},
+		{
+			status: TaskStatus(StatusErr("something unexpected")),
+		},
+	}
+	for _, tc := range testCases {
+		cause := suggestCause(swarm.Task{Status: *tc.status})
+		if tc.expected == "" {
+			assert.Empty(t, cause)
+			continue
+		}
+		assert.Contains(t, cause, tc.expected)
+	}
+}
+
+func TestPrintWhy(t *testing.T) {
+	apiClient := &fakeClient{
+		taskLogsFunc: func(taskID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+			assert.Equal(t, "2", options.Tail)
+			var buf bytes.Buffer
+			stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte("starting\n"))
+			stdcopy.NewStdWriter(&buf, stdcopy.Stderr).Write([]byte("panic: missing configuration\n"))
+			return ioutil.NopCloser(&buf), nil
+		},
+	}
+	cli := test.NewFakeCli(apiClient)
+	tasks := []swarm.Task{
+		*Task(TaskID("task-id-1"), TaskServiceID("service-id"), TaskNodeID("node-id"),
+			WithStatus(TaskState(swarm.TaskStateFailed), StatusErr("task: non-zero exit (2)"), exited(2))),
+		*Task(TaskID("task-id-2"), TaskServiceID("service-id"),
+			WithStatus(TaskState(swarm.TaskStateRejected), StatusErr("no suitable node (insufficient resources on 2 nodes)"))),
+		*Task(TaskID("task-id-3"), TaskServiceID("service-id"), TaskNodeID("node-id"),
+			WithStatus(TaskState(swarm.TaskStateRunning))),
+	}
+	err := PrintWhy(context.Background(), cli, tasks, idresolver.New(apiClient, true), WhyOptions{Tail: "2"})
+	assert.NoError(t, err)
+	golden.Assert(t, cli.OutBuffer().String(), "task-print-why.golden")
+}
+
+func TestPrintWhyWithoutFailures(t *testing.T) {
+	apiClient := &fakeClient{}
+	cli := test.NewFakeCli(apiClient)
+	tasks := []swarm.Task{*Task(WithStatus(TaskState(swarm.TaskStateRunning)))}
+	err := PrintWhy(context.Background(), cli, tasks, idresolver.New(apiClient, true), WhyOptions{Tail: "10"})
+	assert.NoError(t, err)
+	assert.Equal(t, "No failed or rejected tasks found\n", cli.OutBuffer().String())
+}
+
+func TestPrintWhySinceAndLimit(t *testing.T) {
+	defer func(now func() time.Time) { timeNow = now }(timeNow)
+	now := time.Date(2017, 10, 19, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	rejected := func(id, err string, age time.Duration) swarm.Task {
+		return *Task(TaskID(id), TaskServiceID("service-id"),
+			WithStatus(TaskState(swarm.TaskStateRejected), StatusErr(err), Timestamp(now.Add(-age))))
+	}
+	tasks := []swarm.Task{
+		rejected("task-id-1", "no suitable node (insufficient resources on 2 nodes)", time.Minute),
+		rejected("task-id-2", "no suitable node (insufficient resources on 2 nodes)", 2*time.Minute),
+		rejected("task-id-3", "No such image: redis:missing", 3*time.Minute),
+		rejected("task-id-4", "invalid mount config for type \"bind\"", 2*time.Hour),
+	}
+
+	apiClient := &fakeClient{}
+	cli := test.NewFakeCli(apiClient)
+	err := PrintWhy(context.Background(), cli, tasks, idresolver.New(apiClient, true), WhyOptions{Since: time.Hour, Limit: 1})
+	assert.NoError(t, err)
+	out := cli.OutBuffer().String()
+	assert.Contains(t, out, "service-id: 2 task(s) rejected before being assigned to a node\n")
+	assert.NotContains(t, out, "No such image")
+	assert.NotContains(t, out, "invalid mount config")
+	assert.Contains(t, out, "\n1 more failure(s) not shown, raise --why-limit to show them\n")
+
+	cli = test.NewFakeCli(apiClient)
+	err = PrintWhy(context.Background(), cli, tasks, idresolver.New(apiClient, true), WhyOptions{Since: 30 * time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, "No failed or rejected tasks found in the last 30s\n", cli.OutBuffer().String())
+}
+
+func TestWhyOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		options       WhyOptions
+		quiet         bool
+		format        string
+		expectedError string
+	}{
+		{options: WhyOptions{}, quiet: true},
+		{options: WhyOptions{Enabled: true}},
+		{
+			options:       WhyOptions{Enabled: true},
+			format:        "{{.ID}}",
+			expectedError: "--why can not be combined with --quiet or --format",
+		},
+		{
+			options:       WhyOptions{Enabled: true, Since: -time.Minute},
+			expectedError: "--why-since can not be negative",
+		},
+		{
+			options:       WhyOptions{Enabled: true, Limit: -1},
+			expectedError: "--why-limit can not be negative",
+		},
+	}
+	for _, tc := range testCases {
+		err := tc.options.Validate(tc.quiet, tc.format)
+		if tc.expectedError == "" {
+			assert.NoError(t, err)
+			continue
+		}
+		assert.EqualError(t, err, tc.expectedError)
+	}
+}
@@ -0,0 +1,113 @@
+package templating
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/cli/templates"
+	"github.com/pkg/errors"
+)
+
+// DriverGolang is the name of the template driver that renders content as a
+// Go template.
+const DriverGolang = "golang"
+
+// Options holds the settings used to render a template.
+type Options struct {
+	// Driver is the name of the template driver. Only DriverGolang is
+	// supported.
+	Driver string
+	// BaseDir is the directory that relative paths passed to File are
+	// resolved against.
+	BaseDir string
+	// SecretProvider is the path to an executable that is run with the key
+	// passed to Secret as its only argument, and prints the value on stdout.
+	SecretProvider string
+}
+
+// Context is the data passed to templates. Its methods are available from
+// the template, for example:
+//
+//	{{ .Env "DB_USER" }}
+//	{{ .File "certs/ca.pem" }}
+//	{{ .Secret "db/password" }}
+type Context struct {
+	opts Options
+}
+
+// Env returns the value of the environment variable with the given name, or
+// an error if it is not set.
+func (c Context) Env(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", errors.Errorf("environment variable %q is not set", name)
+	}
+	return value, nil
+}
+
+// File returns the content of the file at the given path.
+func (c Context) File(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.opts.BaseDir, path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Secret returns the value of the given key, as printed by the secret provider
+// executable. A single trailing newline is removed from the output.
+func (c Context) Secret(key string) (string, error) {
+	if c.opts.SecretProvider == "" {
+		return "", errors.Errorf("no secret provider configured to get %q", key)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(c.opts.SecretProvider, key)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.Errorf("secret provider failed to get %q: %v: %s", key, err, strings.TrimSpace(stderr.String()))
+	}
+	value := strings.TrimSuffix(stdout.String(), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// Render renders data as a template with the given options.
+func Render(data []byte, opts Options) ([]byte, error) {
+	if opts.Driver != DriverGolang {
+		return nil, errors.Errorf("unsupported template driver %q", opts.Driver)
+	}
+	tmpl, err := templates.Parse(string(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse template")
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, Context{opts: opts}); err != nil {
+		return nil, errors.Wrap(err, "failed to render template")
+	}
+	return buf.Bytes(), nil
+}
+
+// BaseDir returns the directory that files referenced from a template are
+// relative to: the directory of the template file, or the current directory
+// if the template is read from STDIN ("-").
+func BaseDir(templateFile string) string {
+	if templateFile == "-" {
+		return ""
+	}
+	return filepath.Dir(templateFile)
+}
+
+// ValidateDriver checks that driver is a supported template driver.
+func ValidateDriver(driver string) error {
+	if driver != "" && driver != DriverGolang {
+		return errors.Errorf("invalid template driver %q: only %q is supported", driver, DriverGolang)
+	}
+	return nil
+}
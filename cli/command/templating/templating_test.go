@@ -0,0 +1,71 @@
+package templating
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/gotestyourself/gotestyourself/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderEnvAndFile(t *testing.T) {
+	dir := fs.NewDir(t, "templating", fs.WithFile("ca.pem", "CERTIFICATE"))
+	defer dir.Remove()
+	os.Setenv("TEMPLATING_TEST_USER", "admin")
+	defer os.Unsetenv("TEMPLATING_TEST_USER")
+
+	tmpl := `user={{ .Env "TEMPLATING_TEST_USER" | upper }} ca={{ .File "ca.pem" }}`
+	out, err := Render([]byte(tmpl), Options{Driver: DriverGolang, BaseDir: dir.Path()})
+	require.NoError(t, err)
+	assert.Equal(t, "user=ADMIN ca=CERTIFICATE", string(out))
+}
+
+func TestRenderSecret(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("secret provider script requires a POSIX shell")
+	}
+	dir := fs.NewDir(t, "templating", fs.WithFile("provider", "#!/bin/sh\necho \"value-of-$1\"\n", fs.WithMode(0755)))
+	defer dir.Remove()
+
+	tmpl := `password={{ .Secret "db/password" }}`
+	out, err := Render([]byte(tmpl), Options{Driver: DriverGolang, SecretProvider: filepath.Join(dir.Path(), "provider")})
+	require.NoError(t, err)
+	assert.Equal(t, "password=value-of-db/password", string(out))
+}
+
+func TestRenderErrors(t *testing.T) {
+	testCases := []struct {
+		template      string
+		opts          Options
+		expectedError string
+	}{
+		{
+			template:      "foo",
+			opts:          Options{Driver: "jinja"},
+			expectedError: `unsupported template driver "jinja"`,
+		},
+		{
+			template:      "{{ .Env }",
+			opts:          Options{Driver: DriverGolang},
+			expectedError: "failed to parse template",
+		},
+		{
+			template:      `{{ .Env "TEMPLATING_TEST_UNSET" }}`,
+			opts:          Options{Driver: DriverGolang},
+			expectedError: `environment variable "TEMPLATING_TEST_UNSET" is not set`,
+		},
+		{
+			template:      `{{ .Secret "key" }}`,
+			opts:          Options{Driver: DriverGolang},
+			expectedError: `no secret provider configured to get "key"`,
+		},
+	}
+	for _, tc := range testCases {
+		_, err := Render([]byte(tc.template), tc.opts)
+		testutil.ErrorContains(t, err, tc.expectedError)
+	}
+}
@@ -1,6 +1,8 @@
 package convert
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"io/ioutil"
 	"strings"
 
@@ -8,11 +10,15 @@ import (
 	"github.com/docker/docker/api/types"
 	networktypes "github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/pkg/errors"
 )
 
 const (
 	// LabelNamespace is the label used to track stack resources
 	LabelNamespace = "com.docker.stack.namespace"
+	// LabelContentHash is the label used to store the hash of the content of
+	// secrets and configs, to detect when the content of their file changed
+	LabelContentHash = "com.docker.stack.content-hash"
 )
 
 // Namespace mangles names by prepending the name
@@ -49,6 +55,57 @@ func AddStackLabel(namespace Namespace, labels map[string]string) map[string]str
 	return labels
 }
 
+// ContentHash returns the hex-encoded SHA256 digest of the given data
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+const (
+	// rotatedHashLength is the number of characters of the content hash
+	// that are appended to the name of a rotated secret or config
+	rotatedHashLength = 12
+	// maxNameLength is the longest name swarm accepts for a secret or config
+	maxNameLength = 64
+)
+
+// RotatedName returns the name of the secret or config holding the content
+// with the given hash
+func RotatedName(name, hash string) string {
+	if len(hash) > rotatedHashLength {
+		hash = hash[:rotatedHashLength]
+	}
+	return name + "_" + hash
+}
+
+// ValidateRotatedName returns an error if the name of the secret or config
+// holding the content with the given hash is longer than swarm accepts
+func ValidateRotatedName(name, hash string) error {
+	if rotated := RotatedName(name, hash); len(rotated) > maxNameLength {
+		return errors.Errorf("the rotated name %s is longer than %d characters, use a shorter name for the stack or for %s", rotated, maxNameLength, name)
+	}
+	return nil
+}
+
+// UnrotatedName returns the name of a secret or config as declared in the
+// stack, without the content hash suffix of a rotated secret or config.
+func UnrotatedName(name string, labels map[string]string) string {
+	hash := labels[LabelContentHash]
+	if hash == "" {
+		return name
+	}
+	suffix := RotatedName("", hash)
+	if strings.HasSuffix(name, suffix) {
+		return strings.TrimSuffix(name, suffix)
+	}
+	return name
+}
+
+func addContentHashLabel(labels map[string]string, data []byte) map[string]string {
+	labels[LabelContentHash] = ContentHash(data)
+	return labels
+}
+
 type networkMap map[string]composetypes.NetworkConfig
 
 // Networks from the compose-file type to the engine API type
@@ -109,7 +166,7 @@ func Secrets(namespace Namespace, secrets map[string]composetypes.SecretConfig)
 		result = append(result, swarm.SecretSpec{
 			Annotations: swarm.Annotations{
 				Name:   namespace.Scope(name),
-				Labels: AddStackLabel(namespace, secret.Labels),
+				Labels: addContentHashLabel(AddStackLabel(namespace, secret.Labels), data),
 			},
 			Data: data,
 		})
@@ -133,7 +190,7 @@ func Configs(namespace Namespace, configs map[string]composetypes.ConfigObjConfi
 		result = append(result, swarm.ConfigSpec{
 			Annotations: swarm.Annotations{
 				Name:   namespace.Scope(name),
-				Labels: AddStackLabel(namespace, config.Labels),
+				Labels: addContentHashLabel(AddStackLabel(namespace, config.Labels), data),
 			},
 			Data: data,
 		})
@@ -1,6 +1,7 @@
 package convert
 
 import (
+	"strings"
 	"testing"
 
 	composetypes "github.com/docker/cli/cli/compose/types"
@@ -11,6 +12,26 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func TestUnrotatedName(t *testing.T) {
+	hash := ContentHash([]byte("content"))
+	labels := map[string]string{LabelContentHash: hash}
+
+	assert.Equal(t, "foo_cert", UnrotatedName(RotatedName("foo_cert", hash), labels))
+	assert.Equal(t, "foo_cert", UnrotatedName("foo_cert", labels))
+	assert.Equal(t, "foo_cert_other", UnrotatedName("foo_cert_other", labels))
+	assert.Equal(t, "foo_cert", UnrotatedName("foo_cert", nil))
+}
+
+func TestValidateRotatedName(t *testing.T) {
+	hash := ContentHash([]byte("content"))
+
+	assert.NoError(t, ValidateRotatedName("foo_cert", hash))
+	assert.NoError(t, ValidateRotatedName(strings.Repeat("a", 51), hash))
+	err := ValidateRotatedName(strings.Repeat("a", 52), hash)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "longer than 64 characters")
+}
+
 func TestNamespaceScope(t *testing.T) {
 	scoped := Namespace{name: "foo"}.Scope("bar")
 	assert.Equal(t, "foo_bar", scoped)
@@ -128,8 +149,9 @@ func TestSecrets(t *testing.T) {
 	secret := specs[0]
 	assert.Equal(t, "foo_one", secret.Name)
 	assert.Equal(t, map[string]string{
-		"monster":      "mash",
-		LabelNamespace: "foo",
+		"monster":        "mash",
+		LabelNamespace:   "foo",
+		LabelContentHash: ContentHash([]byte(secretText)),
 	}, secret.Labels)
 	assert.Equal(t, []byte(secretText), secret.Data)
 }
@@ -159,8 +181,9 @@ func TestConfigs(t *testing.T) {
 	config := specs[0]
 	assert.Equal(t, "foo_one", config.Name)
 	assert.Equal(t, map[string]string{
-		"monster":      "mash",
-		LabelNamespace: "foo",
+		"monster":        "mash",
+		LabelNamespace:   "foo",
+		LabelContentHash: ContentHash([]byte(configText)),
 	}, config.Labels)
 	assert.Equal(t, []byte(configText), config.Data)
 }
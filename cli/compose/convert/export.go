@@ -0,0 +1,445 @@
+package convert
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	composetypes "github.com/docker/cli/cli/compose/types"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+const (
+	// ComposeFileVersion is the version of the compose file format written
+	// by FromServices
+	ComposeFileVersion = "3.4"
+
+	defaultFileMode = os.FileMode(0444)
+)
+
+// ServiceObjects holds the networks, secrets and configs referenced by
+// services, keyed by ID
+type ServiceObjects struct {
+	Networks map[string]types.NetworkResource
+	Secrets  map[string]swarm.Secret
+	Configs  map[string]swarm.Config
+}
+
+// FromServices converts services from engine API types back to a compose
+// file configuration. Services, networks, volumes, secrets and configs that
+// belong to the namespace are declared in the compose file with the namespace
+// prefix removed from their name. Other networks, volumes, secrets and
+// configs are declared as external.
+//
+// The content of secrets and configs can not be exported; their file is set
+// to a path relative to the compose file that has to be provided.
+func FromServices(namespace Namespace, services []swarm.Service, objects ServiceObjects) *composetypes.Config {
+	config := &composetypes.Config{
+		Version:  ComposeFileVersion,
+		Networks: map[string]composetypes.NetworkConfig{},
+		Volumes:  map[string]composetypes.VolumeConfig{},
+		Secrets:  map[string]composetypes.SecretConfig{},
+		Configs:  map[string]composetypes.ConfigObjConfig{},
+	}
+	for _, service := range services {
+		config.Services = append(config.Services, fromService(namespace, service, objects, config))
+	}
+	sort.Slice(config.Services, func(i, j int) bool {
+		return config.Services[i].Name < config.Services[j].Name
+	})
+	return config
+}
+
+// inNamespace returns true if an object with the given labels was created as
+// part of the namespace
+func inNamespace(namespace Namespace, labels map[string]string) bool {
+	return namespace.Name() != "" && labels[LabelNamespace] == namespace.Name()
+}
+
+// objectName returns the name of an object in the compose file
+func objectName(namespace Namespace, name string, labels map[string]string) string {
+	if inNamespace(namespace, labels) {
+		return namespace.Descope(name)
+	}
+	return name
+}
+
+// withoutLabels returns a copy of labels without the given keys, or nil if
+// no label remains
+func withoutLabels(labels map[string]string, keys ...string) composetypes.Labels {
+	result := composetypes.Labels{}
+	for key, value := range labels {
+		result[key] = value
+	}
+	for _, key := range keys {
+		delete(result, key)
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func fromService(namespace Namespace, service swarm.Service, objects ServiceObjects, config *composetypes.Config) composetypes.ServiceConfig {
+	spec := service.Spec
+	name := objectName(namespace, spec.Name, spec.Labels)
+	result := composetypes.ServiceConfig{
+		Name:   name,
+		Deploy: fromDeploy(spec),
+		Ports:  fromEndpointSpec(spec.EndpointSpec),
+	}
+
+	if containerSpec := spec.TaskTemplate.ContainerSpec; containerSpec != nil {
+		result.Image = containerSpec.Image
+		if image, ok := spec.Labels[LabelImage]; ok {
+			result.Image = image
+		}
+		result.Entrypoint = containerSpec.Command
+		result.Command = containerSpec.Args
+		result.Hostname = containerSpec.Hostname
+		result.ExtraHosts = fromHosts(containerSpec.Hosts)
+		if dnsConfig := containerSpec.DNSConfig; dnsConfig != nil {
+			result.DNS = dnsConfig.Nameservers
+			result.DNSSearch = dnsConfig.Search
+		}
+		result.HealthCheck = fromHealthcheck(containerSpec.Healthcheck)
+		result.Environment = fromEnvironment(containerSpec.Env)
+		result.Labels = withoutLabels(containerSpec.Labels, LabelNamespace)
+		result.WorkingDir = containerSpec.Dir
+		result.User = containerSpec.User
+		result.StopGracePeriod = containerSpec.StopGracePeriod
+		result.StopSignal = containerSpec.StopSignal
+		result.Tty = containerSpec.TTY
+		result.StdinOpen = containerSpec.OpenStdin
+		result.ReadOnly = containerSpec.ReadOnly
+		if privileges := containerSpec.Privileges; privileges != nil && privileges.CredentialSpec != nil {
+			result.CredentialSpec = composetypes.CredentialSpecConfig(*privileges.CredentialSpec)
+		}
+		result.Volumes = fromMounts(namespace, containerSpec.Mounts, config)
+		result.Secrets = fromSecretReferences(namespace, containerSpec.Secrets, objects.Secrets, config)
+		result.Configs = fromConfigReferences(namespace, containerSpec.Configs, objects.Configs, config)
+	}
+
+	if logDriver := spec.TaskTemplate.LogDriver; logDriver != nil {
+		result.Logging = &composetypes.LoggingConfig{
+			Driver:  logDriver.Name,
+			Options: logDriver.Options,
+		}
+	}
+
+	networks := spec.TaskTemplate.Networks
+	if len(networks) == 0 {
+		networks = spec.Networks
+	}
+	result.Networks = fromNetworkAttachments(namespace, name, networks, objects.Networks, config)
+	return result
+}
+
+func fromDeploy(spec swarm.ServiceSpec) composetypes.DeployConfig {
+	deploy := composetypes.DeployConfig{
+		Labels: withoutLabels(spec.Labels, LabelNamespace, LabelImage),
+	}
+	switch {
+	case spec.Mode.Global != nil:
+		deploy.Mode = "global"
+	case spec.Mode.Replicated != nil:
+		deploy.Replicas = spec.Mode.Replicated.Replicas
+	}
+
+	if update := spec.UpdateConfig; update != nil {
+		parallelism := update.Parallelism
+		deploy.UpdateConfig = &composetypes.UpdateConfig{
+			Parallelism:     &parallelism,
+			Delay:           update.Delay,
+			FailureAction:   update.FailureAction,
+			Monitor:         update.Monitor,
+			MaxFailureRatio: update.MaxFailureRatio,
+			Order:           update.Order,
+		}
+	}
+
+	if resources := spec.TaskTemplate.Resources; resources != nil {
+		deploy.Resources.Limits = fromResources(resources.Limits)
+		deploy.Resources.Reservations = fromResources(resources.Reservations)
+	}
+
+	if policy := spec.TaskTemplate.RestartPolicy; policy != nil {
+		deploy.RestartPolicy = &composetypes.RestartPolicy{
+			Condition:   string(policy.Condition),
+			Delay:       policy.Delay,
+			MaxAttempts: policy.MaxAttempts,
+			Window:      policy.Window,
+		}
+	}
+
+	if placement := spec.TaskTemplate.Placement; placement != nil {
+		deploy.Placement.Constraints = placement.Constraints
+		for _, preference := range placement.Preferences {
+			if preference.Spread != nil {
+				deploy.Placement.Preferences = append(deploy.Placement.Preferences, composetypes.PlacementPreferences{
+					Spread: preference.Spread.SpreadDescriptor,
+				})
+			}
+		}
+	}
+
+	if spec.EndpointSpec != nil && spec.EndpointSpec.Mode != swarm.ResolutionModeVIP {
+		deploy.EndpointMode = string(spec.EndpointSpec.Mode)
+	}
+	return deploy
+}
+
+func fromResources(resources *swarm.Resources) *composetypes.Resource {
+	if resources == nil || (resources.NanoCPUs == 0 && resources.MemoryBytes == 0) {
+		return nil
+	}
+	result := &composetypes.Resource{
+		MemoryBytes: composetypes.UnitBytes(resources.MemoryBytes),
+	}
+	if resources.NanoCPUs != 0 {
+		result.NanoCPUs = strconv.FormatFloat(float64(resources.NanoCPUs)/1e9, 'f', -1, 64)
+	}
+	return result
+}
+
+func fromEndpointSpec(endpoint *swarm.EndpointSpec) []composetypes.ServicePortConfig {
+	if endpoint == nil {
+		return nil
+	}
+	var ports []composetypes.ServicePortConfig
+	for _, port := range endpoint.Ports {
+		ports = append(ports, composetypes.ServicePortConfig{
+			Mode:      string(port.PublishMode),
+			Target:    port.TargetPort,
+			Published: port.PublishedPort,
+			Protocol:  string(port.Protocol),
+		})
+	}
+	return ports
+}
+
+// fromHosts converts "IP host [alias...]" entries to a mapping of host to IP
+func fromHosts(hosts []string) composetypes.MappingWithColon {
+	if len(hosts) == 0 {
+		return nil
+	}
+	result := composetypes.MappingWithColon{}
+	for _, entry := range hosts {
+		fields := strings.Fields(entry)
+		for _, host := range fields[1:] {
+			result[host] = fields[0]
+		}
+	}
+	return result
+}
+
+func fromHealthcheck(healthcheck *container.HealthConfig) *composetypes.HealthCheckConfig {
+	if healthcheck == nil {
+		return nil
+	}
+	if len(healthcheck.Test) == 1 && healthcheck.Test[0] == "NONE" {
+		return &composetypes.HealthCheckConfig{Disable: true}
+	}
+	result := &composetypes.HealthCheckConfig{
+		Test: healthcheck.Test,
+	}
+	if healthcheck.Timeout != 0 {
+		result.Timeout = &healthcheck.Timeout
+	}
+	if healthcheck.Interval != 0 {
+		result.Interval = &healthcheck.Interval
+	}
+	if healthcheck.StartPeriod != 0 {
+		result.StartPeriod = &healthcheck.StartPeriod
+	}
+	if healthcheck.Retries != 0 {
+		retries := uint64(healthcheck.Retries)
+		result.Retries = &retries
+	}
+	return result
+}
+
+func fromEnvironment(env []string) composetypes.MappingWithEquals {
+	if len(env) == 0 {
+		return nil
+	}
+	result := composetypes.MappingWithEquals{}
+	for _, entry := range env {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) == 1 {
+			result[parts[0]] = nil
+			continue
+		}
+		value := parts[1]
+		result[parts[0]] = &value
+	}
+	return result
+}
+
+func fromMounts(namespace Namespace, mounts []mount.Mount, config *composetypes.Config) []composetypes.ServiceVolumeConfig {
+	var volumes []composetypes.ServiceVolumeConfig
+	for _, m := range mounts {
+		volume := composetypes.ServiceVolumeConfig{
+			Type:        string(m.Type),
+			Source:      m.Source,
+			Target:      m.Target,
+			ReadOnly:    m.ReadOnly,
+			Consistency: string(m.Consistency),
+		}
+		if m.BindOptions != nil && m.BindOptions.Propagation != "" {
+			volume.Bind = &composetypes.ServiceVolumeBind{Propagation: string(m.BindOptions.Propagation)}
+		}
+		if m.Type == mount.TypeVolume && m.Source != "" {
+			var labels map[string]string
+			if m.VolumeOptions != nil {
+				labels = m.VolumeOptions.Labels
+				if m.VolumeOptions.NoCopy {
+					volume.Volume = &composetypes.ServiceVolumeVolume{NoCopy: true}
+				}
+			}
+			volume.Source = objectName(namespace, m.Source, labels)
+			config.Volumes[volume.Source] = fromVolumeOptions(namespace, m.VolumeOptions)
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes
+}
+
+func fromVolumeOptions(namespace Namespace, options *mount.VolumeOptions) composetypes.VolumeConfig {
+	if options == nil || !inNamespace(namespace, options.Labels) {
+		return composetypes.VolumeConfig{External: composetypes.External{External: true}}
+	}
+	volume := composetypes.VolumeConfig{
+		Labels: withoutLabels(options.Labels, LabelNamespace),
+	}
+	if options.DriverConfig != nil {
+		volume.Driver = options.DriverConfig.Name
+		volume.DriverOpts = options.DriverConfig.Options
+	}
+	return volume
+}
+
+func fromNetworkAttachments(
+	namespace Namespace,
+	serviceName string,
+	attachments []swarm.NetworkAttachmentConfig,
+	networks map[string]types.NetworkResource,
+	config *composetypes.Config,
+) map[string]*composetypes.ServiceNetworkConfig {
+	if len(attachments) == 0 {
+		return nil
+	}
+	result := map[string]*composetypes.ServiceNetworkConfig{}
+	for _, attachment := range attachments {
+		network, ok := networks[attachment.Target]
+		if !ok {
+			// the target is a network name, or a network that no longer exists
+			network = types.NetworkResource{Name: attachment.Target}
+		}
+		name := objectName(namespace, network.Name, network.Labels)
+		config.Networks[name] = fromNetwork(namespace, network)
+
+		// the name of the service is added as an alias when deploying a
+		// stack, so it is not written to the compose file
+		var aliases []string
+		for _, alias := range attachment.Aliases {
+			if alias != serviceName {
+				aliases = append(aliases, alias)
+			}
+		}
+		if len(aliases) == 0 {
+			result[name] = nil
+			continue
+		}
+		result[name] = &composetypes.ServiceNetworkConfig{Aliases: aliases}
+	}
+	return result
+}
+
+func fromNetwork(namespace Namespace, network types.NetworkResource) composetypes.NetworkConfig {
+	if !inNamespace(namespace, network.Labels) {
+		return composetypes.NetworkConfig{External: composetypes.External{External: true}}
+	}
+	result := composetypes.NetworkConfig{
+		Driver:     network.Driver,
+		DriverOpts: network.Options,
+		Internal:   network.Internal,
+		Attachable: network.Attachable,
+		Labels:     withoutLabels(network.Labels, LabelNamespace),
+	}
+	if result.Driver == "overlay" {
+		// overlay is the default driver of networks of a stack
+		result.Driver = ""
+	}
+	if network.IPAM.Driver != "default" {
+		result.Ipam.Driver = network.IPAM.Driver
+	}
+	for _, pool := range network.IPAM.Config {
+		result.Ipam.Config = append(result.Ipam.Config, &composetypes.IPAMPool{Subnet: pool.Subnet})
+	}
+	return result
+}
+
+func fromSecretReferences(namespace Namespace, refs []*swarm.SecretReference, secrets map[string]swarm.Secret, config *composetypes.Config) []composetypes.ServiceSecretConfig {
+	var result []composetypes.ServiceSecretConfig
+	for _, ref := range refs {
+		secret := secrets[ref.SecretID]
+		name := objectName(namespace, UnrotatedName(ref.SecretName, secret.Spec.Labels), secret.Spec.Labels)
+		config.Secrets[name] = composetypes.SecretConfig(fromFileObject(namespace, "secrets", name, secret.Spec.Labels))
+		if ref.File == nil {
+			continue
+		}
+		result = append(result, composetypes.ServiceSecretConfig(fromFileReference(name, ref.File.Name, ref.File.UID, ref.File.GID, ref.File.Mode)))
+	}
+	return result
+}
+
+func fromConfigReferences(namespace Namespace, refs []*swarm.ConfigReference, configs map[string]swarm.Config, config *composetypes.Config) []composetypes.ServiceConfigObjConfig {
+	var result []composetypes.ServiceConfigObjConfig
+	for _, ref := range refs {
+		configObj := configs[ref.ConfigID]
+		name := objectName(namespace, UnrotatedName(ref.ConfigName, configObj.Spec.Labels), configObj.Spec.Labels)
+		config.Configs[name] = composetypes.ConfigObjConfig(fromFileObject(namespace, "configs", name, configObj.Spec.Labels))
+		if ref.File == nil {
+			continue
+		}
+		result = append(result, composetypes.ServiceConfigObjConfig(fromFileReference(name, ref.File.Name, ref.File.UID, ref.File.GID, ref.File.Mode)))
+	}
+	return result
+}
+
+// fromFileObject returns the declaration of a secret or config. Objects of
+// the namespace are read from a file in dir, named after the object.
+func fromFileObject(namespace Namespace, dir, name string, labels map[string]string) composetypes.SecretConfig {
+	if !inNamespace(namespace, labels) {
+		return composetypes.SecretConfig{External: composetypes.External{External: true}}
+	}
+	return composetypes.SecretConfig{
+		File:   "./" + dir + "/" + name,
+		Labels: withoutLabels(labels, LabelNamespace, LabelContentHash),
+	}
+}
+
+// fromFileReference returns the reference to a secret or config from a
+// service, leaving out the values that are the defaults of the compose file
+func fromFileReference(source, target, uid, gid string, mode os.FileMode) composetypes.ServiceSecretConfig {
+	ref := composetypes.ServiceSecretConfig{Source: source}
+	if target != source {
+		ref.Target = target
+	}
+	if uid != "0" {
+		ref.UID = uid
+	}
+	if gid != "0" {
+		ref.GID = gid
+	}
+	if mode != defaultFileMode {
+		fileMode := uint32(mode)
+		ref.Mode = &fileMode
+	}
+	return ref
+}
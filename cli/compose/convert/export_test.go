@@ -0,0 +1,107 @@
+package convert
+
+import (
+	"testing"
+	"time"
+
+	composetypes "github.com/docker/cli/cli/compose/types"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromServicesWithoutNamespace(t *testing.T) {
+	interval := 30 * time.Second
+	service := swarm.Service{
+		Spec: swarm.ServiceSpec{
+			Annotations: swarm.Annotations{Name: "proxy"},
+			Mode:        swarm.ServiceMode{Global: &swarm.GlobalService{}},
+			TaskTemplate: swarm.TaskSpec{
+				ContainerSpec: &swarm.ContainerSpec{
+					Image:       "nginx:1.13",
+					Hosts:       []string{"10.0.0.1 db db.local"},
+					Healthcheck: &container.HealthConfig{Test: []string{"CMD", "true"}, Interval: interval},
+					Secrets: []*swarm.SecretReference{{
+						SecretID:   "secret-id",
+						SecretName: "tls_cert",
+						File:       &swarm.SecretReferenceFileTarget{Name: "cert.pem", UID: "101", GID: "0", Mode: 0444},
+					}},
+				},
+				Resources: &swarm.ResourceRequirements{
+					Limits: &swarm.Resources{NanoCPUs: 500000000, MemoryBytes: 64 * 1024 * 1024},
+				},
+				Networks: []swarm.NetworkAttachmentConfig{{Target: "ingress-net-id"}},
+			},
+			EndpointSpec: &swarm.EndpointSpec{Mode: swarm.ResolutionModeDNSRR},
+		},
+	}
+	objects := ServiceObjects{
+		Networks: map[string]types.NetworkResource{
+			"ingress-net-id": {ID: "ingress-net-id", Name: "frontend"},
+		},
+	}
+
+	config := FromServices(NewNamespace(""), []swarm.Service{service}, objects)
+	require.Len(t, config.Services, 1)
+	proxy := config.Services[0]
+
+	assert.Equal(t, ComposeFileVersion, config.Version)
+	assert.Equal(t, "proxy", proxy.Name)
+	assert.Equal(t, "nginx:1.13", proxy.Image)
+	assert.Equal(t, "global", proxy.Deploy.Mode)
+	assert.Equal(t, "dnsrr", proxy.Deploy.EndpointMode)
+	assert.Equal(t, &composetypes.Resource{NanoCPUs: "0.5", MemoryBytes: 64 * 1024 * 1024}, proxy.Deploy.Resources.Limits)
+	assert.Nil(t, proxy.Deploy.Resources.Reservations)
+	assert.Equal(t, composetypes.MappingWithColon{"db": "10.0.0.1", "db.local": "10.0.0.1"}, proxy.ExtraHosts)
+	assert.Equal(t, &composetypes.HealthCheckConfig{Test: []string{"CMD", "true"}, Interval: &interval}, proxy.HealthCheck)
+	assert.Equal(t, []composetypes.ServiceSecretConfig{{Source: "tls_cert", Target: "cert.pem", UID: "101"}}, proxy.Secrets)
+	assert.Equal(t, map[string]*composetypes.ServiceNetworkConfig{"frontend": nil}, proxy.Networks)
+
+	external := composetypes.External{External: true}
+	assert.Equal(t, map[string]composetypes.NetworkConfig{"frontend": {External: external}}, config.Networks)
+	assert.Equal(t, map[string]composetypes.SecretConfig{"tls_cert": {External: external}}, config.Secrets)
+}
+
+func TestFromServicesWithNamespace(t *testing.T) {
+	namespace := NewNamespace("foo")
+	labels := map[string]string{LabelNamespace: "foo"}
+	services := []swarm.Service{
+		{Spec: swarm.ServiceSpec{
+			Annotations: swarm.Annotations{Name: "foo_web", Labels: labels},
+			TaskTemplate: swarm.TaskSpec{
+				ContainerSpec: &swarm.ContainerSpec{Image: "web", Healthcheck: &container.HealthConfig{Test: []string{"NONE"}}},
+				Networks:      []swarm.NetworkAttachmentConfig{{Target: "net-id", Aliases: []string{"web", "www"}}},
+			},
+		}},
+		{Spec: swarm.ServiceSpec{
+			Annotations: swarm.Annotations{Name: "other_db"},
+			TaskTemplate: swarm.TaskSpec{
+				ContainerSpec: &swarm.ContainerSpec{Image: "db"},
+			},
+		}},
+	}
+	objects := ServiceObjects{
+		Networks: map[string]types.NetworkResource{
+			"net-id": {
+				ID:     "net-id",
+				Name:   "foo_default",
+				Driver: "overlay",
+				Labels: map[string]string{LabelNamespace: "foo", "team": "web"},
+			},
+		},
+	}
+
+	config := FromServices(namespace, services, objects)
+	require.Len(t, config.Services, 2)
+	assert.Equal(t, "other_db", config.Services[0].Name)
+	assert.Equal(t, "web", config.Services[1].Name)
+	assert.Equal(t, &composetypes.HealthCheckConfig{Disable: true}, config.Services[1].HealthCheck)
+	assert.Equal(t, map[string]*composetypes.ServiceNetworkConfig{
+		"default": {Aliases: []string{"www"}},
+	}, config.Services[1].Networks)
+	assert.Equal(t, map[string]composetypes.NetworkConfig{
+		"default": {Labels: composetypes.Labels{"team": "web"}},
+	}, config.Networks)
+}
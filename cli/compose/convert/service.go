@@ -7,7 +7,6 @@ import (
 	"strings"
 	"time"
 
-	servicecli "github.com/docker/cli/cli/command/service"
 	composetypes "github.com/docker/cli/cli/compose/types"
 	"github.com/docker/cli/opts"
 	"github.com/docker/docker/api/types/container"
@@ -295,7 +294,7 @@ func convertServiceSecrets(
 		})
 	}
 
-	secrs, err := servicecli.ParseSecrets(client, refs)
+	secrs, err := ParseSecrets(client, refs)
 	if err != nil {
 		return nil, err
 	}
@@ -352,7 +351,7 @@ func convertServiceConfigObjs(
 		})
 	}
 
-	confs, err := servicecli.ParseConfigs(client, refs)
+	confs, err := ParseConfigs(client, refs)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,8 @@ package types
 
 import (
 	"time"
+
+	yaml "gopkg.in/yaml.v2"
 )
 
 // UnsupportedProperties not yet supported by this implementation of the compose file
@@ -68,6 +70,9 @@ func (cd ConfigDetails) LookupEnv(key string) (string, bool) {
 
 // Config is a full compose file configuration
 type Config struct {
+	// Version is the version of the compose file format. It is only used
+	// when writing a compose file.
+	Version  string
 	Services []ServiceConfig
 	Networks map[string]NetworkConfig
 	Volumes  map[string]VolumeConfig
@@ -75,68 +80,96 @@ type Config struct {
 	Configs  map[string]ConfigObjConfig
 }
 
+// MarshalYAML makes Config implement yaml.Marshaler, writing the services as
+// a mapping keyed by their name, and the top-level keys in the usual order.
+func (c Config) MarshalYAML() (interface{}, error) {
+	services := yaml.MapSlice{}
+	for _, service := range c.Services {
+		services = append(services, yaml.MapItem{Key: service.Name, Value: service})
+	}
+
+	m := yaml.MapSlice{}
+	if c.Version != "" {
+		m = append(m, yaml.MapItem{Key: "version", Value: c.Version})
+	}
+	m = append(m, yaml.MapItem{Key: "services", Value: services})
+	if len(c.Networks) > 0 {
+		m = append(m, yaml.MapItem{Key: "networks", Value: c.Networks})
+	}
+	if len(c.Volumes) > 0 {
+		m = append(m, yaml.MapItem{Key: "volumes", Value: c.Volumes})
+	}
+	if len(c.Secrets) > 0 {
+		m = append(m, yaml.MapItem{Key: "secrets", Value: c.Secrets})
+	}
+	if len(c.Configs) > 0 {
+		m = append(m, yaml.MapItem{Key: "configs", Value: c.Configs})
+	}
+	return m, nil
+}
+
 // ServiceConfig is the configuration of one service
 type ServiceConfig struct {
-	Name string
-
-	Build           BuildConfig
-	CapAdd          []string `mapstructure:"cap_add"`
-	CapDrop         []string `mapstructure:"cap_drop"`
-	CgroupParent    string   `mapstructure:"cgroup_parent"`
-	Command         ShellCommand
-	Configs         []ServiceConfigObjConfig
-	ContainerName   string               `mapstructure:"container_name"`
-	CredentialSpec  CredentialSpecConfig `mapstructure:"credential_spec"`
-	DependsOn       []string             `mapstructure:"depends_on"`
-	Deploy          DeployConfig
-	Devices         []string
-	DNS             StringList
-	DNSSearch       StringList `mapstructure:"dns_search"`
-	DomainName      string     `mapstructure:"domainname"`
-	Entrypoint      ShellCommand
-	Environment     MappingWithEquals
-	EnvFile         StringList `mapstructure:"env_file"`
-	Expose          StringOrNumberList
-	ExternalLinks   []string         `mapstructure:"external_links"`
-	ExtraHosts      MappingWithColon `mapstructure:"extra_hosts"`
-	Hostname        string
-	HealthCheck     *HealthCheckConfig
-	Image           string
-	Ipc             string
-	Labels          Labels
-	Links           []string
-	Logging         *LoggingConfig
-	MacAddress      string `mapstructure:"mac_address"`
-	NetworkMode     string `mapstructure:"network_mode"`
-	Networks        map[string]*ServiceNetworkConfig
-	Pid             string
-	Ports           []ServicePortConfig
-	Privileged      bool
-	ReadOnly        bool `mapstructure:"read_only"`
-	Restart         string
-	Secrets         []ServiceSecretConfig
-	SecurityOpt     []string       `mapstructure:"security_opt"`
-	StdinOpen       bool           `mapstructure:"stdin_open"`
-	StopGracePeriod *time.Duration `mapstructure:"stop_grace_period"`
-	StopSignal      string         `mapstructure:"stop_signal"`
-	Tmpfs           StringList
-	Tty             bool `mapstructure:"tty"`
-	Ulimits         map[string]*UlimitsConfig
-	User            string
-	Volumes         []ServiceVolumeConfig
-	WorkingDir      string `mapstructure:"working_dir"`
+	Name string `yaml:"-"`
+
+	Build           BuildConfig                      `yaml:"build,omitempty"`
+	CapAdd          []string                         `mapstructure:"cap_add" yaml:"cap_add,omitempty"`
+	CapDrop         []string                         `mapstructure:"cap_drop" yaml:"cap_drop,omitempty"`
+	CgroupParent    string                           `mapstructure:"cgroup_parent" yaml:"cgroup_parent,omitempty"`
+	Command         ShellCommand                     `yaml:"command,omitempty"`
+	Configs         []ServiceConfigObjConfig         `yaml:"configs,omitempty"`
+	ContainerName   string                           `mapstructure:"container_name" yaml:"container_name,omitempty"`
+	CredentialSpec  CredentialSpecConfig             `mapstructure:"credential_spec" yaml:"credential_spec,omitempty"`
+	DependsOn       []string                         `mapstructure:"depends_on" yaml:"depends_on,omitempty"`
+	Deploy          DeployConfig                     `yaml:"deploy,omitempty"`
+	Devices         []string                         `yaml:"devices,omitempty"`
+	DNS             StringList                       `yaml:"dns,omitempty"`
+	DNSSearch       StringList                       `mapstructure:"dns_search" yaml:"dns_search,omitempty"`
+	DomainName      string                           `mapstructure:"domainname" yaml:"domainname,omitempty"`
+	Entrypoint      ShellCommand                     `yaml:"entrypoint,omitempty"`
+	Environment     MappingWithEquals                `yaml:"environment,omitempty"`
+	EnvFile         StringList                       `mapstructure:"env_file" yaml:"env_file,omitempty"`
+	Expose          StringOrNumberList               `yaml:"expose,omitempty"`
+	ExternalLinks   []string                         `mapstructure:"external_links" yaml:"external_links,omitempty"`
+	ExtraHosts      MappingWithColon                 `mapstructure:"extra_hosts" yaml:"extra_hosts,omitempty"`
+	Hostname        string                           `yaml:"hostname,omitempty"`
+	HealthCheck     *HealthCheckConfig               `yaml:"healthcheck,omitempty"`
+	Image           string                           `yaml:"image,omitempty"`
+	Ipc             string                           `yaml:"ipc,omitempty"`
+	Labels          Labels                           `yaml:"labels,omitempty"`
+	Links           []string                         `yaml:"links,omitempty"`
+	Logging         *LoggingConfig                   `yaml:"logging,omitempty"`
+	MacAddress      string                           `mapstructure:"mac_address" yaml:"mac_address,omitempty"`
+	NetworkMode     string                           `mapstructure:"network_mode" yaml:"network_mode,omitempty"`
+	Networks        map[string]*ServiceNetworkConfig `yaml:"networks,omitempty"`
+	Pid             string                           `yaml:"pid,omitempty"`
+	Ports           []ServicePortConfig              `yaml:"ports,omitempty"`
+	Privileged      bool                             `yaml:"privileged,omitempty"`
+	ReadOnly        bool                             `mapstructure:"read_only" yaml:"read_only,omitempty"`
+	Restart         string                           `yaml:"restart,omitempty"`
+	Secrets         []ServiceSecretConfig            `yaml:"secrets,omitempty"`
+	SecurityOpt     []string                         `mapstructure:"security_opt" yaml:"security_opt,omitempty"`
+	StdinOpen       bool                             `mapstructure:"stdin_open" yaml:"stdin_open,omitempty"`
+	StopGracePeriod *time.Duration                   `mapstructure:"stop_grace_period" yaml:"stop_grace_period,omitempty"`
+	StopSignal      string                           `mapstructure:"stop_signal" yaml:"stop_signal,omitempty"`
+	Tmpfs           StringList                       `yaml:"tmpfs,omitempty"`
+	Tty             bool                             `mapstructure:"tty" yaml:"tty,omitempty"`
+	Ulimits         map[string]*UlimitsConfig        `yaml:"ulimits,omitempty"`
+	User            string                           `yaml:"user,omitempty"`
+	Volumes         []ServiceVolumeConfig            `yaml:"volumes,omitempty"`
+	WorkingDir      string                           `mapstructure:"working_dir" yaml:"working_dir,omitempty"`
 }
 
 // BuildConfig is a type for build
 // using the same format at libcompose: https://github.com/docker/libcompose/blob/master/yaml/build.go#L12
 type BuildConfig struct {
-	Context    string
-	Dockerfile string
-	Args       MappingWithEquals
-	Labels     Labels
-	CacheFrom  StringList `mapstructure:"cache_from"`
-	Network    string
-	Target     string
+	Context    string            `yaml:"context,omitempty"`
+	Dockerfile string            `yaml:"dockerfile,omitempty"`
+	Args       MappingWithEquals `yaml:"args,omitempty"`
+	Labels     Labels            `yaml:"labels,omitempty"`
+	CacheFrom  StringList        `mapstructure:"cache_from" yaml:"cache_from,omitempty"`
+	Network    string            `yaml:"network,omitempty"`
+	Target     string            `yaml:"target,omitempty"`
 }
 
 // ShellCommand is a string or list of string args
@@ -164,30 +197,30 @@ type MappingWithColon map[string]string
 
 // LoggingConfig the logging configuration for a service
 type LoggingConfig struct {
-	Driver  string
-	Options map[string]string
+	Driver  string            `yaml:"driver,omitempty"`
+	Options map[string]string `yaml:"options,omitempty"`
 }
 
 // DeployConfig the deployment configuration for a service
 type DeployConfig struct {
-	Mode          string
-	Replicas      *uint64
-	Labels        Labels
-	UpdateConfig  *UpdateConfig `mapstructure:"update_config"`
-	Resources     Resources
-	RestartPolicy *RestartPolicy `mapstructure:"restart_policy"`
-	Placement     Placement
-	EndpointMode  string `mapstructure:"endpoint_mode"`
+	Mode          string         `yaml:"mode,omitempty"`
+	Replicas      *uint64        `yaml:"replicas,omitempty"`
+	Labels        Labels         `yaml:"labels,omitempty"`
+	UpdateConfig  *UpdateConfig  `mapstructure:"update_config" yaml:"update_config,omitempty"`
+	Resources     Resources      `yaml:"resources,omitempty"`
+	RestartPolicy *RestartPolicy `mapstructure:"restart_policy" yaml:"restart_policy,omitempty"`
+	Placement     Placement      `yaml:"placement,omitempty"`
+	EndpointMode  string         `mapstructure:"endpoint_mode" yaml:"endpoint_mode,omitempty"`
 }
 
 // HealthCheckConfig the healthcheck configuration for a service
 type HealthCheckConfig struct {
-	Test        HealthCheckTest
-	Timeout     *time.Duration
-	Interval    *time.Duration
-	Retries     *uint64
-	StartPeriod *time.Duration `mapstructure:"start_period"`
-	Disable     bool
+	Test        HealthCheckTest `yaml:"test,omitempty"`
+	Timeout     *time.Duration  `yaml:"timeout,omitempty"`
+	Interval    *time.Duration  `yaml:"interval,omitempty"`
+	Retries     *uint64         `yaml:"retries,omitempty"`
+	StartPeriod *time.Duration  `mapstructure:"start_period" yaml:"start_period,omitempty"`
+	Disable     bool            `yaml:"disable,omitempty"`
 }
 
 // HealthCheckTest is the command run to test the health of a service
@@ -195,25 +228,25 @@ type HealthCheckTest []string
 
 // UpdateConfig the service update configuration
 type UpdateConfig struct {
-	Parallelism     *uint64
-	Delay           time.Duration
-	FailureAction   string `mapstructure:"failure_action"`
-	Monitor         time.Duration
-	MaxFailureRatio float32 `mapstructure:"max_failure_ratio"`
-	Order           string
+	Parallelism     *uint64       `yaml:"parallelism,omitempty"`
+	Delay           time.Duration `yaml:"delay,omitempty"`
+	FailureAction   string        `mapstructure:"failure_action" yaml:"failure_action,omitempty"`
+	Monitor         time.Duration `yaml:"monitor,omitempty"`
+	MaxFailureRatio float32       `mapstructure:"max_failure_ratio" yaml:"max_failure_ratio,omitempty"`
+	Order           string        `yaml:"order,omitempty"`
 }
 
 // Resources the resource limits and reservations
 type Resources struct {
-	Limits       *Resource
-	Reservations *Resource
+	Limits       *Resource `yaml:"limits,omitempty"`
+	Reservations *Resource `yaml:"reservations,omitempty"`
 }
 
 // Resource is a resource to be limited or reserved
 type Resource struct {
 	// TODO: types to convert from units and ratios
-	NanoCPUs    string    `mapstructure:"cpus"`
-	MemoryBytes UnitBytes `mapstructure:"memory"`
+	NanoCPUs    string    `mapstructure:"cpus" yaml:"cpus,omitempty"`
+	MemoryBytes UnitBytes `mapstructure:"memory" yaml:"memory,omitempty"`
 }
 
 // UnitBytes is the bytes type
@@ -221,65 +254,65 @@ type UnitBytes int64
 
 // RestartPolicy the service restart policy
 type RestartPolicy struct {
-	Condition   string
-	Delay       *time.Duration
-	MaxAttempts *uint64 `mapstructure:"max_attempts"`
-	Window      *time.Duration
+	Condition   string         `yaml:"condition,omitempty"`
+	Delay       *time.Duration `yaml:"delay,omitempty"`
+	MaxAttempts *uint64        `mapstructure:"max_attempts" yaml:"max_attempts,omitempty"`
+	Window      *time.Duration `yaml:"window,omitempty"`
 }
 
 // Placement constraints for the service
 type Placement struct {
-	Constraints []string
-	Preferences []PlacementPreferences
+	Constraints []string               `yaml:"constraints,omitempty"`
+	Preferences []PlacementPreferences `yaml:"preferences,omitempty"`
 }
 
 // PlacementPreferences is the preferences for a service placement
 type PlacementPreferences struct {
-	Spread string
+	Spread string `yaml:"spread,omitempty"`
 }
 
 // ServiceNetworkConfig is the network configuration for a service
 type ServiceNetworkConfig struct {
-	Aliases     []string
-	Ipv4Address string `mapstructure:"ipv4_address"`
-	Ipv6Address string `mapstructure:"ipv6_address"`
+	Aliases     []string `yaml:"aliases,omitempty"`
+	Ipv4Address string   `mapstructure:"ipv4_address" yaml:"ipv4_address,omitempty"`
+	Ipv6Address string   `mapstructure:"ipv6_address" yaml:"ipv6_address,omitempty"`
 }
 
 // ServicePortConfig is the port configuration for a service
 type ServicePortConfig struct {
-	Mode      string
-	Target    uint32
-	Published uint32
-	Protocol  string
+	Mode      string `yaml:"mode,omitempty"`
+	Target    uint32 `yaml:"target,omitempty"`
+	Published uint32 `yaml:"published,omitempty"`
+	Protocol  string `yaml:"protocol,omitempty"`
 }
 
 // ServiceVolumeConfig are references to a volume used by a service
 type ServiceVolumeConfig struct {
-	Type        string
-	Source      string
-	Target      string
-	ReadOnly    bool `mapstructure:"read_only"`
-	Consistency string
-	Bind        *ServiceVolumeBind
-	Volume      *ServiceVolumeVolume
+	Type        string               `yaml:"type,omitempty"`
+	Source      string               `yaml:"source,omitempty"`
+	Target      string               `yaml:"target,omitempty"`
+	ReadOnly    bool                 `mapstructure:"read_only" yaml:"read_only,omitempty"`
+	Consistency string               `yaml:"consistency,omitempty"`
+	Bind        *ServiceVolumeBind   `yaml:"bind,omitempty"`
+	Volume      *ServiceVolumeVolume `yaml:"volume,omitempty"`
 }
 
 // ServiceVolumeBind are options for a service volume of type bind
 type ServiceVolumeBind struct {
-	Propagation string
+	Propagation string `yaml:"propagation,omitempty"`
 }
 
 // ServiceVolumeVolume are options for a service volume of type volume
 type ServiceVolumeVolume struct {
-	NoCopy bool `mapstructure:"nocopy"`
+	NoCopy bool `mapstructure:"nocopy" yaml:"nocopy,omitempty"`
 }
 
 type fileReferenceConfig struct {
-	Source string
-	Target string
-	UID    string
-	GID    string
-	Mode   *uint32
+	Source string  `yaml:"source,omitempty"`
+	Target string  `yaml:"target,omitempty"`
+	UID    string  `yaml:"uid,omitempty"`
+	GID    string  `yaml:"gid,omitempty"`
+	Mode   *uint32 `yaml:"mode,omitempty"`
 }
 
 // ServiceConfigObjConfig is the config obj configuration for a service
@@ -290,60 +323,69 @@ type ServiceSecretConfig fileReferenceConfig
 
 // UlimitsConfig the ulimit configuration
 type UlimitsConfig struct {
-	Single int
-	Soft   int
-	Hard   int
+	Single int `yaml:"single,omitempty"`
+	Soft   int `yaml:"soft,omitempty"`
+	Hard   int `yaml:"hard,omitempty"`
 }
 
 // NetworkConfig for a network
 type NetworkConfig struct {
-	Driver     string
-	DriverOpts map[string]string `mapstructure:"driver_opts"`
-	Ipam       IPAMConfig
-	External   External
-	Internal   bool
-	Attachable bool
-	Labels     Labels
+	Driver     string            `yaml:"driver,omitempty"`
+	DriverOpts map[string]string `mapstructure:"driver_opts" yaml:"driver_opts,omitempty"`
+	Ipam       IPAMConfig        `yaml:"ipam,omitempty"`
+	External   External          `yaml:"external,omitempty"`
+	Internal   bool              `yaml:"internal,omitempty"`
+	Attachable bool              `yaml:"attachable,omitempty"`
+	Labels     Labels            `yaml:"labels,omitempty"`
 }
 
 // IPAMConfig for a network
 type IPAMConfig struct {
-	Driver string
-	Config []*IPAMPool
+	Driver string      `yaml:"driver,omitempty"`
+	Config []*IPAMPool `yaml:"config,omitempty"`
 }
 
 // IPAMPool for a network
 type IPAMPool struct {
-	Subnet string
+	Subnet string `yaml:"subnet,omitempty"`
 }
 
 // VolumeConfig for a volume
 type VolumeConfig struct {
-	Name       string
-	Driver     string
-	DriverOpts map[string]string `mapstructure:"driver_opts"`
-	External   External
-	Labels     Labels
+	Name       string            `yaml:"name,omitempty"`
+	Driver     string            `yaml:"driver,omitempty"`
+	DriverOpts map[string]string `mapstructure:"driver_opts" yaml:"driver_opts,omitempty"`
+	External   External          `yaml:"external,omitempty"`
+	Labels     Labels            `yaml:"labels,omitempty"`
 }
 
 // External identifies a Volume or Network as a reference to a resource that is
 // not managed, and should already exist.
 // External.name is deprecated and replaced by Volume.name
 type External struct {
-	Name     string
-	External bool
+	Name     string `yaml:"name,omitempty"`
+	External bool   `yaml:"external,omitempty"`
+}
+
+// MarshalYAML makes External implement yaml.Marshaler, writing either
+// `external: true` or `external: {name: ...}`.
+func (e External) MarshalYAML() (interface{}, error) {
+	if e.Name == "" {
+		return e.External, nil
+	}
+	return map[string]string{"name": e.Name}, nil
 }
 
 // CredentialSpecConfig for credential spec on Windows
 type CredentialSpecConfig struct {
-	File     string
-	Registry string
+	File     string `yaml:"file,omitempty"`
+	Registry string `yaml:"registry,omitempty"`
 }
 
 type fileObjectConfig struct {
-	File     string
-	External External
-	Labels   Labels
+	File     string   `yaml:"file,omitempty"`
+	External External `yaml:"external,omitempty"`
+	Labels   Labels   `yaml:"labels,omitempty"`
 }
 
 // SecretConfig for a secret
@@ -0,0 +1,19 @@
+package logs
+
+import (
+	"hash/fnv"
+)
+
+// colors are the ANSI foreground colors used for the prefixes of log lines.
+// Black, white and their bright variants are left out for readability on
+// both dark and light terminals.
+var colors = []string{"31", "32", "33", "34", "35", "36", "91", "92", "93", "94", "95", "96"}
+
+// Colorize returns the prefix of a log line wrapped in an ANSI color escape
+// sequence. The color is picked from the name of the source of the log line,
+// so that a source keeps the same color across invocations.
+func Colorize(name, prefix string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return "\x1b[" + colors[h.Sum32()%uint32(len(colors))] + "m" + prefix + "\x1b[0m"
+}
@@ -0,0 +1,162 @@
+package logs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ManifestFile is the name of the file describing the exported logs
+const ManifestFile = "manifest.json"
+
+// Manifest describes the log files of an export
+type Manifest struct {
+	Created time.Time
+	// Since and Until are the time window of the logs, as passed by the user
+	Since string `json:",omitempty"`
+	Until string `json:",omitempty"`
+	Files []ManifestEntry
+	// Skipped lists the services whose logs are not exported
+	Skipped []ManifestSkipped `json:",omitempty"`
+}
+
+// ManifestSkipped describes a service whose logs are not exported, and why
+type ManifestSkipped struct {
+	ServiceID   string
+	ServiceName string `json:",omitempty"`
+	Reason      string
+}
+
+// ManifestEntry describes an exported log file, and the task or container
+// the logs come from
+type ManifestEntry struct {
+	File           string
+	ServiceID      string `json:",omitempty"`
+	ServiceName    string `json:",omitempty"`
+	ServiceVersion uint64 `json:",omitempty"`
+	TaskID         string `json:",omitempty"`
+	Slot           int    `json:",omitempty"`
+	NodeID         string `json:",omitempty"`
+	NodeName       string `json:",omitempty"`
+	ContainerID    string `json:",omitempty"`
+	ContainerName  string `json:",omitempty"`
+	Lines          int
+}
+
+// ExportOptions holds parameters to export logs with
+type ExportOptions struct {
+	// Compress gzips the log files
+	Compress bool
+	Since    string
+	Until    string
+}
+
+// Export writes logs to a directory, one file per task or container, along
+// with a manifest. It is safe for concurrent use.
+type Export struct {
+	mu       sync.Mutex
+	dir      string
+	options  ExportOptions
+	files    []*exportFile
+	manifest Manifest
+}
+
+// NewExport creates the directory logs are exported to
+func NewExport(dir string, options ExportOptions) (*Export, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create the output directory")
+	}
+	return &Export{
+		dir:      dir,
+		options:  options,
+		manifest: Manifest{Created: time.Now().UTC(), Since: options.Since, Until: options.Until, Files: []ManifestEntry{}},
+	}, nil
+}
+
+// Create creates the log file of a task or container. The name of the file is
+// derived from name, and is recorded in the manifest along with entry.
+func (e *Export) Create(name string, entry ManifestEntry) (io.Writer, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry.File = name + ".log"
+	if e.options.Compress {
+		entry.File += ".gz"
+	}
+	f, err := os.Create(filepath.Join(e.dir, entry.File))
+	if err != nil {
+		return nil, err
+	}
+	file := &exportFile{export: e, index: len(e.manifest.Files), file: f, writer: f}
+	if e.options.Compress {
+		file.gzip = gzip.NewWriter(f)
+		file.writer = file.gzip
+	}
+	e.files = append(e.files, file)
+	e.manifest.Files = append(e.manifest.Files, entry)
+	return file, nil
+}
+
+// Skip records in the manifest a service whose logs are not exported
+func (e *Export) Skip(skipped ManifestSkipped) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.manifest.Skipped = append(e.manifest.Skipped, skipped)
+}
+
+// Close closes the log files and writes the manifest
+func (e *Export) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var firstErr error
+	for _, file := range e.files {
+		if err := file.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	data, err := json.MarshalIndent(e.manifest, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(e.dir, ManifestFile), append(data, '\n'), 0644)
+}
+
+// exportFile is a log file of an export, counting the lines written to it
+type exportFile struct {
+	export *Export
+	index  int
+	file   *os.File
+	gzip   *gzip.Writer
+	writer io.Writer
+}
+
+func (f *exportFile) Write(p []byte) (int, error) {
+	f.export.mu.Lock()
+	defer f.export.mu.Unlock()
+
+	n, err := f.writer.Write(p)
+	f.export.manifest.Files[f.index].Lines += bytes.Count(p[:n], []byte{'\n'})
+	return n, err
+}
+
+func (f *exportFile) close() error {
+	if f.gzip != nil {
+		if err := f.gzip.Close(); err != nil {
+			f.file.Close()
+			return err
+		}
+	}
+	return f.file.Close()
+}
@@ -0,0 +1,52 @@
+package logs
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logs-export")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	export, err := NewExport(filepath.Join(dir, "logs"), ExportOptions{Compress: true, Since: "1h"})
+	require.NoError(t, err)
+	w, err := export.Create("web.1.task1", ManifestEntry{ServiceName: "web", TaskID: "task1", Slot: 1})
+	require.NoError(t, err)
+	_, err = w.Write([]byte("first line\nsecond "))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("line\n"))
+	require.NoError(t, err)
+	_, err = export.Create("db.1.task2", ManifestEntry{ServiceName: "db", TaskID: "task2", Slot: 1})
+	require.NoError(t, err)
+	export.Skip(ManifestSkipped{ServiceID: "service3", ServiceName: "console", Reason: "tty"})
+	require.NoError(t, export.Close())
+
+	f, err := os.Open(filepath.Join(dir, "logs", "web.1.task1.log.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "first line\nsecond line\n", string(data))
+
+	var manifest Manifest
+	data, err = ioutil.ReadFile(filepath.Join(dir, "logs", ManifestFile))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, "1h", manifest.Since)
+	assert.Equal(t, []ManifestEntry{
+		{File: "web.1.task1.log.gz", ServiceName: "web", TaskID: "task1", Slot: 1, Lines: 2},
+		{File: "db.1.task2.log.gz", ServiceName: "db", TaskID: "task2", Slot: 1},
+	}, manifest.Files)
+	assert.Equal(t, []ManifestSkipped{{ServiceID: "service3", ServiceName: "console", Reason: "tty"}}, manifest.Skipped)
+}
@@ -0,0 +1,119 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Filter selects log messages by regular expression and, for structured
+// (JSON) log messages, by the value of their fields.
+type Filter struct {
+	grep   *regexp.Regexp
+	json   bool
+	fields map[string]string
+}
+
+// NewFilter returns a Filter for the given options. Messages must match the
+// grep regular expression if it is not empty. If parseJSON is true, messages
+// are parsed as JSON objects, and must have the fields given as "key=value".
+// Nested fields are named by joining their keys with dots, for example
+// "http.status=500".
+func NewFilter(grep string, parseJSON bool, fields []string) (*Filter, error) {
+	filter := &Filter{json: parseJSON, fields: map[string]string{}}
+	if grep != "" {
+		re, err := regexp.Compile(grep)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid --grep expression")
+		}
+		filter.grep = re
+	}
+	for _, field := range fields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid field filter %q: must be key=value", field)
+		}
+		filter.fields[parts[0]] = parts[1]
+	}
+	return filter, nil
+}
+
+// Match returns true if the message is selected by the filter
+func (f *Filter) Match(message []byte) bool {
+	if f.grep != nil && !f.grep.Match(message) {
+		return false
+	}
+	if len(f.fields) == 0 {
+		return true
+	}
+	fields, err := ParseJSONFields(message)
+	if err != nil {
+		return false
+	}
+	for key, value := range f.fields {
+		if fields[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Format returns the message as it is printed. Structured messages are
+// printed as sorted "key=value" pairs if the filter parses JSON, other
+// messages are returned unchanged.
+func (f *Filter) Format(message []byte) []byte {
+	if !f.json {
+		return message
+	}
+	fields, err := ParseJSONFields(message)
+	if err != nil {
+		return message
+	}
+	pairs := make([]string, 0, len(fields))
+	for key, value := range fields {
+		if strings.ContainsAny(value, " \t\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return []byte(strings.Join(pairs, " ") + "\n")
+}
+
+// ParseJSONFields parses a log message holding a JSON object, and returns its
+// fields as strings. Nested objects are flattened, joining their keys with
+// dots.
+func ParseJSONFields(message []byte) (map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(bytes.TrimSpace(message)))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, errors.Wrap(err, "log message is not a JSON object")
+	}
+	fields := map[string]string{}
+	flattenFields(fields, "", object)
+	return fields, nil
+}
+
+func flattenFields(fields map[string]string, prefix string, object map[string]interface{}) {
+	for key, value := range object {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenFields(fields, prefix+key+".", v)
+		case string:
+			fields[prefix+key] = v
+		case nil:
+			fields[prefix+key] = ""
+		case []interface{}:
+			data, _ := json.Marshal(v)
+			fields[prefix+key] = string(data)
+		default:
+			fields[prefix+key] = fmt.Sprint(v)
+		}
+	}
+}
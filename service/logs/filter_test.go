@@ -0,0 +1,59 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJSONFields(t *testing.T) {
+	fields, err := ParseJSONFields([]byte(`{"level":"info","count":3,"ok":true,"tags":["a","b"],"http":{"status":200,"path":"/"},"empty":null}` + "\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"level":       "info",
+		"count":       "3",
+		"ok":          "true",
+		"tags":        `["a","b"]`,
+		"http.status": "200",
+		"http.path":   "/",
+		"empty":       "",
+	}, fields)
+
+	_, err = ParseJSONFields([]byte("plain text\n"))
+	assert.Error(t, err)
+}
+
+func TestFilterMatch(t *testing.T) {
+	testCases := []struct {
+		grep     string
+		fields   []string
+		message  string
+		expected bool
+	}{
+		{message: "anything", expected: true},
+		{grep: "err(or)?", message: "an error occurred", expected: true},
+		{grep: "^err", message: "an error occurred", expected: false},
+		{fields: []string{"level=error"}, message: `{"level":"error"}`, expected: true},
+		{fields: []string{"level=error"}, message: `{"level":"info"}`, expected: false},
+		{fields: []string{"level=error"}, message: "level=error", expected: false},
+		{fields: []string{"level=error", "code=42"}, message: `{"level":"error","code":42}`, expected: true},
+		{grep: "disk", fields: []string{"level=error"}, message: `{"level":"error","msg":"network down"}`, expected: false},
+	}
+	for _, tc := range testCases {
+		filter, err := NewFilter(tc.grep, len(tc.fields) > 0, tc.fields)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, filter.Match([]byte(tc.message)), "%q %v %s", tc.grep, tc.fields, tc.message)
+	}
+}
+
+func TestFilterFormat(t *testing.T) {
+	filter, err := NewFilter("", true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "level=info msg=\"hello world\"\n", string(filter.Format([]byte(`{"msg":"hello world","level":"info"}`))))
+	assert.Equal(t, "not json\n", string(filter.Format([]byte("not json\n"))))
+
+	filter, err = NewFilter("", false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"level":"info"}`, string(filter.Format([]byte(`{"level":"info"}`))))
+}
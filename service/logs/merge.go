@@ -0,0 +1,73 @@
+package logs
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Merger writes the log lines of several sources to the same output. Lines
+// are either written as soon as they are received, or, if the merger sorts,
+// buffered until Flush is called and written in timestamp order. It is safe
+// for concurrent use.
+type Merger struct {
+	mu     sync.Mutex
+	stdout io.Writer
+	stderr io.Writer
+	sort   bool
+	lines  []mergedLine
+}
+
+type mergedLine struct {
+	timestamp time.Time
+	stderr    bool
+	data      []byte
+}
+
+// NewMerger returns a Merger writing to stdout and stderr
+func NewMerger(stdout, stderr io.Writer, sort bool) *Merger {
+	return &Merger{stdout: stdout, stderr: stderr, sort: sort}
+}
+
+// WriteLine writes a log line with the given timestamp. The timestamp is only
+// used to sort lines.
+func (m *Merger) WriteLine(timestamp time.Time, stderr bool, line []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sort {
+		data := make([]byte, len(line))
+		copy(data, line)
+		m.lines = append(m.lines, mergedLine{timestamp: timestamp, stderr: stderr, data: data})
+		return nil
+	}
+	return m.write(stderr, line)
+}
+
+// Flush writes the buffered lines, sorted by timestamp. Lines with the same
+// timestamp keep the order in which they were received.
+func (m *Merger) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sort.SliceStable(m.lines, func(i, j int) bool {
+		return m.lines[i].timestamp.Before(m.lines[j].timestamp)
+	})
+	for _, line := range m.lines {
+		if err := m.write(line.stderr, line.data); err != nil {
+			return err
+		}
+	}
+	m.lines = nil
+	return nil
+}
+
+func (m *Merger) write(stderr bool, line []byte) error {
+	w := m.stdout
+	if stderr {
+		w = m.stderr
+	}
+	_, err := w.Write(line)
+	return err
+}
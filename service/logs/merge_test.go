@@ -0,0 +1,36 @@
+package logs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergerSorted(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	merger := NewMerger(stdout, stderr, true)
+
+	base := time.Date(2017, 10, 19, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, merger.WriteLine(base.Add(2*time.Second), false, []byte("b\n")))
+	require.NoError(t, merger.WriteLine(base.Add(time.Second), false, []byte("a\n")))
+	require.NoError(t, merger.WriteLine(base.Add(2*time.Second), false, []byte("c\n")))
+	require.NoError(t, merger.WriteLine(base, true, []byte("err\n")))
+	assert.Empty(t, stdout.String())
+
+	require.NoError(t, merger.Flush())
+	assert.Equal(t, "a\nb\nc\n", stdout.String())
+	assert.Equal(t, "err\n", stderr.String())
+}
+
+func TestMergerUnsorted(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	merger := NewMerger(stdout, nil, false)
+
+	require.NoError(t, merger.WriteLine(time.Now(), false, []byte("b\n")))
+	require.NoError(t, merger.WriteLine(time.Time{}, false, []byte("a\n")))
+	assert.Equal(t, "b\na\n", stdout.String())
+}
@@ -1,4 +1,4 @@
-/*Package logs contains tools for parsing docker log lines.
+/*Package logs contains tools for parsing, filtering and merging docker log lines.
  */
 package logs
 
@@ -0,0 +1,28 @@
+package logs
+
+import (
+	"time"
+
+	timetypes "github.com/docker/docker/api/types/time"
+	"github.com/pkg/errors"
+)
+
+// ParseUntil parses the end of the time window of logs. It accepts the same
+// timestamps and relative durations as the --until option of docker events.
+func ParseUntil(value string, reference time.Time) (time.Time, error) {
+	ts, err := timetypes.GetTimestamp(value, reference)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid --until value")
+	}
+	sec, nsec, err := timetypes.ParseTimestamps(ts, 0)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid --until value")
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// After returns true if a log line was written after the end of the time
+// window. Lines without a timestamp are never after it.
+func After(timestamp, until time.Time) bool {
+	return !until.IsZero() && !timestamp.IsZero() && timestamp.After(until)
+}
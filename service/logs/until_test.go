@@ -0,0 +1,38 @@
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/cli/internal/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUntil(t *testing.T) {
+	reference := time.Date(2017, 10, 19, 10, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{value: "2017-10-19T09:30:00Z", expected: time.Date(2017, 10, 19, 9, 30, 0, 0, time.UTC)},
+		{value: "1508405400.5", expected: time.Date(2017, 10, 19, 9, 30, 0, 500000000, time.UTC)},
+		{value: "10m", expected: time.Date(2017, 10, 19, 9, 50, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		until, err := ParseUntil(tc.value, reference)
+		require.NoError(t, err)
+		assert.True(t, tc.expected.Equal(until), "%s: expected %s, got %s", tc.value, tc.expected, until)
+	}
+
+	_, err := ParseUntil("yesterday", reference)
+	testutil.ErrorContains(t, err, "invalid --until value")
+}
+
+func TestAfter(t *testing.T) {
+	until := time.Date(2017, 10, 19, 10, 0, 0, 0, time.UTC)
+	assert.False(t, After(until, until))
+	assert.True(t, After(until.Add(time.Nanosecond), until))
+	assert.False(t, After(time.Time{}, until))
+	assert.False(t, After(until.Add(time.Hour), time.Time{}))
+}